@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"mini-app-backend/internal/bot"
 	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
 	"mini-app-backend/internal/logger"
 	"mini-app-backend/internal/server"
+	"mini-app-backend/internal/utils"
 	"net/http"
 	"sync"
 )
@@ -18,12 +21,25 @@ func main() {
 		logger.GetLogger().Fatal("❌ TELEGRAM_BOT_TOKEN не установлен")
 	}
 
-	tgBot, err := bot.New(cfg)
+	keyring, err := utils.NewKeyringFromConfig(cfg)
+	if err != nil {
+		logger.GetLogger().Fatalf("Invalid cookie encryption keys: %v", err)
+	}
+	utils.SetKeyring(keyring)
+
+	// Bot and server share one pool so the connection limits apply to the process.
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		logger.GetLogger().Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	tgBot, err := bot.New(cfg, db)
 	if err != nil {
 		logger.GetLogger().Fatalf("Failed created bot: %v", err)
 	}
 
-	httpServer := server.New(cfg)
+	httpServer := server.New(cfg, db)
 
 	var wg sync.WaitGroup
 	wg.Add(2)
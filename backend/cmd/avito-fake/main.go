@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"mini-app-backend/internal/avitofake"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	fixturesPath := flag.String("fixtures", "", "JSON fixtures file (built-in demo data when empty)")
+	flag.Parse()
+
+	fixtures := avitofake.DefaultFixtures()
+	if *fixturesPath != "" {
+		loaded, err := avitofake.LoadFixtures(*fixturesPath)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		fixtures = loaded
+	}
+
+	for _, account := range fixtures.Accounts {
+		log.Printf("👤 Account %d (%s): client_id=%s client_secret=%s",
+			account.Account.ID, account.Account.Name, account.ClientID, account.ClientSecret)
+	}
+
+	log.Printf("🧪 Fake Avito API listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, avitofake.New(fixtures)); err != nil {
+		log.Fatalf("Failed start fake Avito API: %v", err)
+	}
+}
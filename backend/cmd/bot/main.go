@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"mini-app-backend/internal/bot"
 	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
 )
 
 func main() {
@@ -14,7 +16,13 @@ func main() {
 		log.Fatal("❌ TELEGRAM_BOT_TOKEN not downloaded")
 	}
 
-	tgBot, err := bot.New(cfg)
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	tgBot, err := bot.New(cfg, db)
 	if err != nil {
 		log.Fatalf("Failed created bot: %v", err)
 	}
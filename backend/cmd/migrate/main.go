@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/migrations"
+)
+
+const usage = `Usage: go run ./cmd/migrate [flags] <command>
+
+Commands:
+  up             apply all pending migrations
+  down [n]       roll back the last n migrations (default 1)
+  status         list migrations and when they were applied
+  create <name>  add an empty up/down pair to both migrations directories
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", migrations.Dir, "Postgres migrations directory used by create")
+	sqliteDir := flag.String("sqlite-dir", migrations.SQLiteDir, "SQLite migrations directory used by create")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("create needs a migration name")
+		}
+		// Both dialects keep the same versions, so every migration is written twice.
+		for _, d := range []string{*dir, *sqliteDir} {
+			upPath, downPath, err := migrations.Create(d, args[0])
+			if err != nil {
+				log.Fatalf("Failed to create migration: %v", err)
+			}
+			log.Printf("Created %s", upPath)
+			log.Printf("Created %s", downPath)
+		}
+		return
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	loaded, err := migrations.Embedded(database.DialectOf(db))
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	migrator := migrations.New(db, loaded)
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("✅ Applied %d migration(s)", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", args[0])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		log.Printf("✅ Reverted %d migration(s)", reverted)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-24s  %s\n", status.Version, status.Name, applied)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
 	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
 	"mini-app-backend/internal/server"
+	"mini-app-backend/internal/utils"
 )
 
 func main() {
 	cfg := config.Load()
 
-	httpServer := server.New(cfg)
+	keyring, err := utils.NewKeyringFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid cookie encryption keys: %v", err)
+	}
+	utils.SetKeyring(keyring)
+
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	httpServer := server.New(cfg, db)
 
 	log.Println("🌐 Start HTTP server...")
 	if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
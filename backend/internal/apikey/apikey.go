@@ -0,0 +1,224 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeMessagesRead  Scope = "messages:read"
+	ScopeMessagesWrite Scope = "messages:write"
+	ScopeAvitoSend     Scope = "avito:send"
+)
+
+var AllScopes = []Scope{ScopeMessagesRead, ScopeMessagesWrite, ScopeAvitoSend}
+
+// Keys look like "mak_<prefix>_<secret>"; only the prefix is stored in clear.
+const (
+	keyPrefix       = "mak_"
+	prefixBytes     = 4
+	secretBytes     = 24
+	lastUsedGranule = time.Minute
+)
+
+var (
+	ErrInvalidKey   = errors.NewAppError(http.StatusUnauthorized, "Invalid API key")
+	ErrKeyNotFound  = errors.NewAppError(http.StatusNotFound, "API key not found")
+	ErrInvalidScope = errors.NewAppError(http.StatusBadRequest, "Unknown API key scope")
+	ErrNoScopes     = errors.NewAppError(http.StatusBadRequest, "At least one scope is required")
+)
+
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Hash       string     `json:"-" db:"hash"`
+	Scopes     []Scope    `json:"scopes" db:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type AuditEntry struct {
+	KeyID      int64     `json:"key_id" db:"key_id"`
+	UserID     int64     `json:"user_id" db:"user_id"`
+	Method     string    `json:"method" db:"method"`
+	Path       string    `json:"path" db:"path"`
+	StatusCode int       `json:"status_code" db:"status_code"`
+	RemoteAddr string    `json:"remote_addr" db:"remote_addr"`
+	RequestID  string    `json:"request_id" db:"request_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(key *APIKey) error
+	GetAPIKeyByPrefix(prefix string) (*APIKey, error)
+	GetAPIKeysByUserID(userID int64) ([]*APIKey, error)
+	RevokeAPIKey(userID, id int64, revokedAt time.Time) (bool, error)
+	TouchAPIKey(id int64, usedAt time.Time) error
+	CreateAuditEntry(entry *AuditEntry) error
+}
+
+type APIKeyService struct {
+	repo APIKeyRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+	}
+}
+
+func ParseScopes(values []string) ([]Scope, error) {
+	if len(values) == 0 {
+		return nil, ErrNoScopes
+	}
+
+	seen := make(map[Scope]bool)
+	var scopes []Scope
+	for _, value := range values {
+		scope := Scope(strings.TrimSpace(value))
+		valid := false
+		for _, known := range AllScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+// IssueKey creates a key and returns it together with the plaintext value,
+// which is never stored and cannot be shown again.
+func (s *APIKeyService) IssueKey(userID int64, name string, scopes []Scope) (*APIKey, string, error) {
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	displayPrefix := keyPrefix + prefix
+	plaintext := displayPrefix + "_" + secret
+
+	key := &APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    displayPrefix,
+		Hash:      hashKey(plaintext),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+func (s *APIKeyService) GetKeysByUserID(userID int64) ([]*APIKey, error) {
+	return s.repo.GetAPIKeysByUserID(userID)
+}
+
+func (s *APIKeyService) RevokeKey(userID, id int64) error {
+	revoked, err := s.repo.RevokeAPIKey(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// IsAPIKey reports whether the value has the shape of a personal API key.
+func IsAPIKey(value string) bool {
+	return strings.HasPrefix(value, keyPrefix)
+}
+
+// Authenticate resolves an active key from its plaintext value and records its use.
+func (s *APIKeyService) Authenticate(plaintext string) (*APIKey, error) {
+	if !IsAPIKey(plaintext) {
+		return nil, ErrInvalidKey
+	}
+
+	rest := strings.TrimPrefix(plaintext, keyPrefix)
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(keyPrefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || key.RevokedAt != nil {
+		return nil, ErrInvalidKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashKey(plaintext))) != 1 {
+		return nil, ErrInvalidKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedGranule {
+		if err := s.repo.TouchAPIKey(key.ID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyService) RecordAudit(entry *AuditEntry) error {
+	entry.CreatedAt = time.Now()
+	return s.repo.CreateAuditEntry(entry)
+}
+
+func hashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
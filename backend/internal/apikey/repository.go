@@ -0,0 +1,198 @@
+package apikey
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+func joinScopes(scopes []Scope) string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return strings.Join(values, ",")
+}
+
+func splitScopes(value string) []Scope {
+	var scopes []Scope
+	for _, part := range strings.Split(value, ",") {
+		if part != "" {
+			scopes = append(scopes, Scope(part))
+		}
+	}
+	return scopes
+}
+
+func scanAPIKey(scan func(dest ...interface{}) error) (*APIKey, error) {
+	key := &APIKey{}
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&scopes,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = splitScopes(scopes)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
+
+func (r *SQLRepository) CreateAPIKey(key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRow(query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		joinScopes(key.Scopes),
+		key.CreatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		return err
+	}
+
+	key.ID = id
+	return nil
+}
+
+func (r *SQLRepository) GetAPIKeyByPrefix(prefix string) (*APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, hash, scopes, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE prefix = $1
+	`
+
+	key, err := scanAPIKey(r.db.QueryRow(query, prefix).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting API key by prefix: %v", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *SQLRepository) GetAPIKeysByUserID(userID int64) ([]*APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, hash, scopes, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		log.Printf("Error getting API keys by user ID: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning API key: %v", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (r *SQLRepository) RevokeAPIKey(userID, id int64, revokedAt time.Time) (bool, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id, userID, revokedAt)
+	if err != nil {
+		log.Printf("Error revoking API key: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *SQLRepository) TouchAPIKey(id int64, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	_, err := r.db.Exec(query, id, usedAt)
+	if err != nil {
+		log.Printf("Error updating API key last used: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) CreateAuditEntry(entry *AuditEntry) error {
+	query := `
+		INSERT INTO api_key_audit_log (key_id, user_id, method, path, status_code, remote_addr, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(query,
+		entry.KeyID,
+		entry.UserID,
+		entry.Method,
+		entry.Path,
+		entry.StatusCode,
+		entry.RemoteAddr,
+		entry.RequestID,
+		entry.CreatedAt,
+	)
+
+	if err != nil {
+		log.Printf("Error creating API key audit entry: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package auth signs Telegram users in: it stores the user, their personal
+// workspace and the starter message template as one unit of work.
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/workspace"
+)
+
+const (
+	defaultMessageText = "Ваше сообщение по умолчанию"
+	defaultMessageName = "Автоответчик"
+)
+
+// Repos are the repositories a login writes to, bound to one transaction.
+type Repos struct {
+	Users      user.UserRepository
+	Workspaces workspace.WorkspaceRepository
+	Messages   message.MessageRepository
+}
+
+// NewTransactor runs logins in a transaction on db.
+func NewTransactor(db *sql.DB) *database.SQLTransactor[*Repos] {
+	return database.NewTransactor(db, func(tx database.DBTX) *Repos {
+		return &Repos{
+			Users:      user.NewSQLRepository(tx),
+			Workspaces: workspace.NewSQLRepository(tx),
+			Messages:   message.NewSQLMessageRepository(tx),
+		}
+	})
+}
+
+type Login struct {
+	User      *user.User
+	Workspace *workspace.Workspace
+	// Created is true for the request that registered the user.
+	Created bool
+}
+
+type Service struct {
+	tx           database.Transactor[*Repos]
+	clientID     string
+	clientSecret string
+	now          func() time.Time
+}
+
+// NewService takes the Avito credentials the starter message template is
+// attached to.
+func NewService(tx database.Transactor[*Repos], clientID, clientSecret string) *Service {
+	return &Service{
+		tx:           tx,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		now:          time.Now,
+	}
+}
+
+// Login registers telegramUser on first sign-in and returns the stored user
+// otherwise. The insert is an upsert and only the request that actually
+// inserted the row creates the starter template, so concurrent first logins
+// end with one user, one personal workspace and one template.
+func (s *Service) Login(ctx context.Context, telegramUser *user.User) (*Login, error) {
+	login := &Login{}
+
+	err := s.tx.WithTx(ctx, func(repos *Repos) error {
+		existing, err := repos.Users.GetUserByID(ctx, telegramUser.ID)
+		if err != nil {
+			return err
+		}
+
+		// Known users keep their stored profile; the web app only signs them in.
+		login.User = existing
+		if existing == nil {
+			registered := *telegramUser
+			registered.CreatedAt = s.now()
+			registered.UpdatedAt = registered.CreatedAt
+
+			login.Created, err = repos.Users.UpsertUser(ctx, &registered)
+			if err != nil {
+				return err
+			}
+			login.User = &registered
+		}
+
+		login.Workspace, err = workspace.NewWorkspaceService(repos.Workspaces, database.NoTx[workspace.WorkspaceRepository]{Repos: repos.Workspaces}).EnsurePersonalWorkspace(ctx, login.User.ID, login.User.Username)
+		if err != nil {
+			return err
+		}
+
+		if !login.Created {
+			return nil
+		}
+
+		_, err = message.NewMessageService(repos.Messages).CreateMessage(ctx,
+			login.Workspace.ID,
+			s.clientID,
+			s.clientSecret,
+			defaultMessageText,
+			defaultMessageName,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return login, nil
+}
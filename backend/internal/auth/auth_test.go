@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/workspace"
+)
+
+// The fakes embed the interfaces so only the methods a login uses need bodies.
+
+type fakeUsers struct {
+	user.UserRepository
+	users map[int64]*user.User
+}
+
+func (f *fakeUsers) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	return f.users[id], nil
+}
+
+func (f *fakeUsers) UpsertUser(ctx context.Context, u *user.User) (bool, error) {
+	_, exists := f.users[u.ID]
+	copied := *u
+	f.users[u.ID] = &copied
+	return !exists, nil
+}
+
+type fakeWorkspaces struct {
+	workspace.WorkspaceRepository
+	personal map[int64]*workspace.Workspace
+	members  int
+}
+
+func (f *fakeWorkspaces) GetPersonalWorkspace(userID int64) (*workspace.Workspace, error) {
+	return f.personal[userID], nil
+}
+
+func (f *fakeWorkspaces) CreateWorkspace(w *workspace.Workspace) error {
+	w.ID = int64(len(f.personal) + 1)
+	f.personal[w.OwnerID] = w
+	return nil
+}
+
+func (f *fakeWorkspaces) AddMember(member *workspace.Member) error {
+	f.members++
+	return nil
+}
+
+type fakeMessages struct {
+	message.MessageRepository
+	messages []*message.Message
+	fail     error
+}
+
+func (f *fakeMessages) CreateMessage(ctx context.Context, m *message.Message) error {
+	if f.fail != nil {
+		return f.fail
+	}
+	f.messages = append(f.messages, m)
+	return nil
+}
+
+func newRepos() *Repos {
+	return &Repos{
+		Users:      &fakeUsers{users: make(map[int64]*user.User)},
+		Workspaces: &fakeWorkspaces{personal: make(map[int64]*workspace.Workspace)},
+		Messages:   &fakeMessages{},
+	}
+}
+
+func TestLoginRegistersOnce(t *testing.T) {
+	repos := newRepos()
+	service := NewService(database.NoTx[*Repos]{Repos: repos}, "client", "secret")
+
+	first, err := service.Login(context.Background(), &user.User{ID: 7, FirstName: "Anna", Username: "anna"})
+	if err != nil {
+		t.Fatalf("first Login: %v", err)
+	}
+	if !first.Created || first.Workspace == nil || first.Workspace.Name != "anna" {
+		t.Fatalf("first login = %+v", first)
+	}
+
+	second, err := service.Login(context.Background(), &user.User{ID: 7, FirstName: "Changed"})
+	if err != nil {
+		t.Fatalf("second Login: %v", err)
+	}
+	if second.Created || second.User.FirstName != "Anna" || second.Workspace.ID != first.Workspace.ID {
+		t.Errorf("second login = %+v, user %+v", second, second.User)
+	}
+
+	messages := repos.Messages.(*fakeMessages).messages
+	if len(messages) != 1 || messages[0].WorkspaceID != first.Workspace.ID || messages[0].ClientID != "client" {
+		t.Errorf("starter templates = %+v", messages)
+	}
+}
+
+func TestLoginFailsWhenStarterTemplateFails(t *testing.T) {
+	repos := newRepos()
+	repos.Messages.(*fakeMessages).fail = errors.New("insert failed")
+	service := NewService(database.NoTx[*Repos]{Repos: repos}, "client", "secret")
+
+	// With a real transaction the user and workspace roll back with the
+	// template; here it is enough that the error reaches the caller.
+	if _, err := service.Login(context.Background(), &user.User{ID: 7, FirstName: "Anna"}); err == nil {
+		t.Fatal("expected the template error to fail the login")
+	}
+}
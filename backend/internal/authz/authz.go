@@ -0,0 +1,70 @@
+package authz
+
+import (
+	"context"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/workspace"
+	"net/http"
+)
+
+var (
+	ErrClientNotFound  = errors.NewAppError(http.StatusNotFound, "Client not found")
+	ErrMessageNotFound = errors.NewAppError(http.StatusNotFound, "Message not found")
+)
+
+// Authorizer checks that resources belong to the caller's active workspace.
+// Foreign resources are reported as not found so their existence is not leaked.
+type Authorizer struct {
+	userService    *user.UserService
+	messageService *message.MessageService
+}
+
+func NewAuthorizer(userService *user.UserService, messageService *message.MessageService) *Authorizer {
+	return &Authorizer{
+		userService:    userService,
+		messageService: messageService,
+	}
+}
+
+// AuthorizeClient returns the client row with the given Avito client ID if it
+// belongs to the active workspace and the caller has at least the given role.
+func (a *Authorizer) AuthorizeClient(ctx context.Context, access *workspace.Access, clientID string, role workspace.Role) (*user.Client, error) {
+	client, err := a.userService.GetClientByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil || client.WorkspaceID != access.WorkspaceID {
+		return nil, ErrClientNotFound
+	}
+
+	if err := access.Require(role); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// AuthorizeMessage returns the message if the client it is attached to belongs
+// to the active workspace and the caller has at least the given role.
+func (a *Authorizer) AuthorizeMessage(ctx context.Context, access *workspace.Access, messageID string, role workspace.Role) (*message.Message, error) {
+	msg, err := a.messageService.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg == nil {
+		return nil, ErrMessageNotFound
+	}
+
+	if _, err := a.AuthorizeClient(ctx, access, msg.ClientID, role); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	return msg, nil
+}
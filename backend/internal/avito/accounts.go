@@ -0,0 +1,34 @@
+package avito
+
+import (
+	"context"
+	"net/http"
+)
+
+type Account struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Email      string   `json:"email"`
+	Phone      string   `json:"phone"`
+	Phones     []string `json:"phones"`
+	ProfileURL string   `json:"profile_url"`
+}
+
+// GetSelf returns the account the current token belongs to.
+func (c *Client) GetSelf(ctx context.Context) (*Account, error) {
+	var account Account
+	if err := c.do(ctx, http.MethodGet, "/core/v1/accounts/self", nil, nil, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetSelfWithToken is GetSelf for a token that is not yet bound to a request,
+// such as one just obtained from an authorization code.
+func (c *Client) GetSelfWithToken(ctx context.Context, token *Token) (*Account, error) {
+	var account Account
+	if err := c.doWithToken(ctx, token, http.MethodGet, "/core/v1/accounts/self", nil, nil, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
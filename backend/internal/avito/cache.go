@@ -0,0 +1,67 @@
+package avito
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache is a small concurrency-safe cache for short-lived API responses.
+// Expired entries are dropped lazily and by a sweep on every Set.
+type TTLCache[T any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]ttlEntry[T]
+}
+
+type ttlEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func NewTTLCache[T any](ttl time.Duration) *TTLCache[T] {
+	return &TTLCache[T]{
+		ttl:     ttl,
+		entries: make(map[string]ttlEntry[T]),
+	}
+}
+
+func (c *TTLCache[T]) Get(key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero T
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (c *TTLCache[T]) Set(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = ttlEntry[T]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// DeletePrefix drops every entry whose key starts with prefix.
+func (c *TTLCache[T]) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package avito
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mini-app-backend/internal/httpclient"
+	"mini-app-backend/internal/logger"
+	"net/url"
+)
+
+const DefaultBaseURL = "https://api.avito.ru"
+
+// The limiter is one token bucket per host, so these numbers cap the whole
+// process, every user and Avito account together, not each account. Avito's
+// own quota is per account; the global cap only keeps a busy instance from
+// eating 429s and can be raised with WithRateLimit.
+const (
+	defaultRateLimit = 5
+	defaultRateBurst = 10
+)
+
+// Client is a typed client for the Avito public API. Every call resolves its
+// token through the configured TokenSource, so one client serves all users.
+type Client struct {
+	http         *httpclient.Client
+	baseURL      string
+	clientID     string
+	clientSecret string
+	tokens       TokenSource
+	tokenManager *TokenManager
+	rateLimit    float64
+	rateBurst    int
+	httpOptions  []httpclient.Option
+}
+
+type Option func(*Client)
+
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = baseURL
+		}
+	}
+}
+
+// WithCredentials sets the application credentials used for the
+// client_credentials grant and for authorization code exchanges.
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(c *Client) {
+		c.clientID = clientID
+		c.clientSecret = clientSecret
+	}
+}
+
+// WithTokenSource overrides how access tokens are resolved for API calls.
+func WithTokenSource(tokens TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithRateLimit sets the process-wide cap on requests to the Avito API. A
+// non-positive rate disables the limiter.
+func WithRateLimit(ratePerSecond float64, burst int) Option {
+	return func(c *Client) {
+		c.rateLimit = ratePerSecond
+		c.rateBurst = burst
+	}
+}
+
+func WithHTTPOptions(opts ...httpclient.Option) Option {
+	return func(c *Client) {
+		c.httpOptions = append(c.httpOptions, opts...)
+	}
+}
+
+func NewClient(opts ...Option) *Client {
+	client := &Client{
+		baseURL:   DefaultBaseURL,
+		rateLimit: defaultRateLimit,
+		rateBurst: defaultRateBurst,
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	httpOptions := append([]httpclient.Option{
+		httpclient.WithLogger(logger.GetLogger()),
+		httpclient.WithRetryPolicy(httpclient.DefaultRetryPolicy()),
+		httpclient.WithRateLimit(client.rateLimit, client.rateBurst),
+		httpclient.WithCircuitBreaker(httpclient.DefaultBreakerConfig()),
+	}, client.httpOptions...)
+	httpOptions = append(httpOptions, httpclient.WithBaseURL(client.baseURL))
+	client.http = httpclient.NewClient(httpOptions...)
+
+	client.tokenManager = NewTokenManager(client.ClientCredentialsToken)
+
+	if client.tokens == nil {
+		client.tokens = &contextTokenSource{client: client}
+	}
+
+	return client
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *Client) TokenMetrics() TokenMetrics {
+	return c.tokenManager.Metrics()
+}
+
+func (c *Client) BreakerStatuses() []httpclient.BreakerStatus {
+	return c.http.BreakerStatuses()
+}
+
+// do performs an authorized JSON request. body and target may be nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, target interface{}) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.doWithToken(ctx, token, method, path, query, body, target)
+	if !IsUnauthorized(err) {
+		return err
+	}
+
+	invalidator, ok := c.tokens.(TokenInvalidator)
+	if !ok {
+		return err
+	}
+
+	invalidator.Invalidate(ctx, token)
+
+	token, err = c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.doWithToken(ctx, token, method, path, query, body, target)
+}
+
+func (c *Client) doWithToken(ctx context.Context, token *Token, method, path string, query url.Values, body, target interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	if len(query) > 0 {
+		path = path + "?" + query.Encode()
+	}
+
+	resp, err := c.http.DoRequest(ctx, method, path, reader, map[string]string{
+		"Authorization": token.AuthorizationHeader(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	if target == nil || len(resp.Body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(resp.Body, target); err != nil {
+		return fmt.Errorf("error unmarshaling Avito response: %v", err)
+	}
+
+	return nil
+}
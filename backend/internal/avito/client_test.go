@@ -0,0 +1,259 @@
+package avito
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewClient(append([]Option{WithBaseURL(server.URL)}, opts...)...)
+}
+
+func TestClientCredentialsFromContext(t *testing.T) {
+	var tokenRequests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token/":
+			tokenRequests++
+			if err := r.ParseForm(); err != nil {
+				t.Fatalf("parse form: %v", err)
+			}
+			if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "cookie-id" {
+				t.Errorf("unexpected token form: %v", r.Form)
+			}
+			w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":86400}`))
+		case "/core/v1/accounts/self":
+			if got := r.Header.Get("Authorization"); got != "Bearer abc" {
+				t.Errorf("Authorization = %q", got)
+			}
+			w.Write([]byte(`{"id":42,"name":"Seller"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}, WithCredentials("app-id", "app-secret"))
+
+	ctx := context.WithValue(context.Background(), "avito_client_id", "cookie-id")
+	ctx = context.WithValue(ctx, "avito_client_secret", "cookie-secret")
+
+	account, err := client.GetSelf(ctx)
+	if err != nil {
+		t.Fatalf("GetSelf: %v", err)
+	}
+	if account.ID != 42 || account.Name != "Seller" {
+		t.Errorf("account = %+v", account)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1", tokenRequests)
+	}
+}
+
+func TestOAuthTokenFromContextSkipsTokenEndpoint(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token/" {
+			t.Error("token endpoint must not be called")
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer oauth-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.URL.Query().Get("per_page") != "50" || r.URL.Query().Get("status") != "active" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"meta":{"page":1,"per_page":50},"resources":[{"id":7,"title":"Bike","price":1000,"status":"active"}]}`))
+	})
+
+	ctx := context.WithValue(context.Background(), "avito_access_token", "oauth-token")
+	ctx = context.WithValue(ctx, "avito_token_type", "Bearer")
+
+	items, err := client.ListItems(ctx, ListItemsParams{PerPage: 50, Status: "active"})
+	if err != nil {
+		t.Fatalf("ListItems: %v", err)
+	}
+	if len(items.Resources) != 1 || items.Resources[0].ID != 7 || items.Meta.PerPage != 50 {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/messenger/v1/accounts/42/chats/chat-1/messages" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req sendMessageRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if req.Type != "text" || req.Message.Text != "hello" {
+			t.Errorf("body = %s", body)
+		}
+		w.Write([]byte(`{"id":"m1","content":{"text":"hello"},"direction":"out","type":"text"}`))
+	}, WithTokenSource(StaticToken("Bearer", "t")))
+
+	message, err := client.SendMessage(context.Background(), 42, "chat-1", "hello")
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if message.ID != "m1" || message.Content.Text != "hello" {
+		t.Errorf("message = %+v", message)
+	}
+}
+
+func TestListMessagesAcceptsBothShapes(t *testing.T) {
+	for name, payload := range map[string]string{
+		"array":   `[{"id":"a"},{"id":"b"}]`,
+		"wrapped": `{"messages":[{"id":"a"},{"id":"b"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(payload))
+			}, WithTokenSource(StaticToken("Bearer", "t")))
+
+			messages, err := client.ListMessages(context.Background(), 1, "c", 10, 0)
+			if err != nil {
+				t.Fatalf("ListMessages: %v", err)
+			}
+			if len(messages) != 2 || messages[1].ID != "b" {
+				t.Errorf("messages = %+v", messages)
+			}
+		})
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+	}{
+		{"oauth", http.StatusBadRequest, `{"error":"invalid_grant","error_description":"code expired"}`, "invalid_grant", "code expired"},
+		{"nested", http.StatusForbidden, `{"error":{"code":403,"message":"forbidden"}}`, "403", "forbidden"},
+		{"plain", http.StatusNotFound, `{"message":"no such chat"}`, "", "no such chat"},
+		{"not json", http.StatusBadGateway, `<html>`, "", "Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}, WithTokenSource(StaticToken("Bearer", "t")))
+
+			_, err := client.GetChat(context.Background(), 1, "c")
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("err = %T %v, want *APIError", err, err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.code || apiErr.Message != tt.message {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+			if StatusCode(err) != tt.status {
+				t.Errorf("StatusCode = %d", StatusCode(err))
+			}
+		})
+	}
+}
+
+func TestMissingCredentials(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected")
+	})
+
+	if _, err := client.GetSelf(context.Background()); err != ErrNoCredentials {
+		t.Errorf("err = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestRequireUserCredentialsSkipsAppCredentials(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token/" {
+			t.Error("the application's credentials were used")
+		}
+		w.Write([]byte(`{"id":42}`))
+	}, WithCredentials("app-id", "app-secret"))
+
+	ctx := RequireUserCredentials(context.Background())
+	if _, err := client.GetSelf(ctx); err != ErrNoCredentials {
+		t.Errorf("err = %v, want ErrNoCredentials", err)
+	}
+
+	ctx = context.WithValue(ctx, "avito_access_token", "user-token")
+	if _, err := client.GetSelf(ctx); err != nil {
+		t.Errorf("GetSelf with the user's own token: %v", err)
+	}
+}
+
+func TestListAllItemsFollowsPages(t *testing.T) {
+	var pages []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		switch page {
+		case "1":
+			w.Write([]byte(`{"meta":{"page":1,"per_page":2},"resources":[{"id":1},{"id":2}]}`))
+		case "2":
+			w.Write([]byte(`{"meta":{"page":2,"per_page":2},"resources":[{"id":3}]}`))
+		default:
+			t.Errorf("unexpected page %s", page)
+		}
+	}, WithTokenSource(StaticToken("Bearer", "t")))
+
+	items, err := client.ListAllItems(context.Background(), ListItemsParams{PerPage: 2, Status: "active,old"}, 10)
+	if err != nil {
+		t.Fatalf("ListAllItems: %v", err)
+	}
+	if len(items.Resources) != 3 || items.Resources[2].ID != 3 {
+		t.Errorf("items = %+v", items.Resources)
+	}
+	if len(pages) != 2 {
+		t.Errorf("pages = %v", pages)
+	}
+	if items.Truncated {
+		t.Error("a listing that ended on a short page is marked truncated")
+	}
+}
+
+func TestListAllItemsReportsTruncation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta":{"per_page":2},"resources":[{"id":1},{"id":2}]}`))
+	}, WithTokenSource(StaticToken("Bearer", "t")))
+
+	items, err := client.ListAllItems(context.Background(), ListItemsParams{PerPage: 2, Page: 3}, 2)
+	if err != nil {
+		t.Fatalf("ListAllItems: %v", err)
+	}
+	if len(items.Resources) != 4 || !items.Truncated || items.NextPage != 5 {
+		t.Errorf("items = %d resources, truncated %v, next page %d; want 4, true, 5", len(items.Resources), items.Truncated, items.NextPage)
+	}
+
+	body, _ := json.Marshal(items)
+	if !strings.Contains(string(body), `"truncated":true,"next_page":5`) {
+		t.Errorf("response body %s does not report the truncation", body)
+	}
+}
+
+func TestApplyVASIsNotRetried(t *testing.T) {
+	var attempts int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}, WithTokenSource(StaticToken("Bearer", "t")))
+
+	if _, err := client.ApplyVAS(context.Background(), 42, 7, VASHighlight); StatusCode(err) != http.StatusBadGateway {
+		t.Fatalf("ApplyVAS err = %v, want the 502", err)
+	}
+	if attempts != 1 {
+		t.Errorf("purchase sent %d times, want once", attempts)
+	}
+}
@@ -0,0 +1,87 @@
+package avito
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"mini-app-backend/internal/httpclient"
+	"net/http"
+)
+
+// APIError is a non-2xx response from Avito.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("avito: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("avito: %d", e.StatusCode)
+}
+
+// newAPIError understands the error shapes Avito uses: the OAuth
+// {"error", "error_description"} pair, {"error": {"code", "message"}} and a bare {"message"}.
+func newAPIError(resp *httpclient.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(resp.Body),
+	}
+
+	var payload struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+		Message          string          `json:"message"`
+	}
+	if err := json.Unmarshal(resp.Body, &payload); err != nil {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+
+	var nested struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	}
+	var code string
+	switch {
+	case json.Unmarshal(payload.Error, &code) == nil:
+		apiErr.Code = code
+		apiErr.Message = payload.ErrorDescription
+	case json.Unmarshal(payload.Error, &nested) == nil:
+		apiErr.Code = string(nested.Code)
+		apiErr.Message = nested.Message
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = payload.Message
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+// StatusCode returns the Avito status of err, or 0 when err is not an APIError.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+func IsRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
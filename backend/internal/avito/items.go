@@ -0,0 +1,182 @@
+package avito
+
+import (
+	"context"
+	"fmt"
+	"mini-app-backend/internal/httpclient"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	ItemStatusActive   = "active"
+	ItemStatusRemoved  = "removed"
+	ItemStatusOld      = "old"
+	ItemStatusBlocked  = "blocked"
+	ItemStatusRejected = "rejected"
+)
+
+// MaxItemsPerPage is the largest per_page Avito accepts for the items listing.
+const MaxItemsPerPage = 100
+
+func IsValidItemStatus(status string) bool {
+	switch status {
+	case ItemStatusActive, ItemStatusRemoved, ItemStatusOld, ItemStatusBlocked, ItemStatusRejected:
+		return true
+	}
+	return false
+}
+
+type ItemCategory struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Item struct {
+	ID       int64        `json:"id"`
+	Title    string       `json:"title"`
+	Address  string       `json:"address"`
+	Price    float64      `json:"price"`
+	Status   string       `json:"status"`
+	URL      string       `json:"url"`
+	Category ItemCategory `json:"category"`
+}
+
+type ItemsMeta struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+type ItemsResponse struct {
+	Meta      ItemsMeta `json:"meta"`
+	Resources []Item    `json:"resources"`
+	// Truncated is set by ListAllItems when it stopped at maxPages on a full
+	// page; NextPage is where to continue.
+	Truncated bool `json:"truncated,omitempty"`
+	NextPage  int  `json:"next_page,omitempty"`
+}
+
+// ListItemsParams mirrors the listing filters. Status may hold several
+// comma-separated statuses; UpdatedAtFrom is YYYY-MM-DD.
+type ListItemsParams struct {
+	Page          int
+	PerPage       int
+	Status        string
+	Category      string
+	UpdatedAtFrom string
+}
+
+func (c *Client) ListItems(ctx context.Context, params ListItemsParams) (*ItemsResponse, error) {
+	query := url.Values{}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(params.PerPage))
+	}
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	if params.Category != "" {
+		query.Set("category", params.Category)
+	}
+	if params.UpdatedAtFrom != "" {
+		query.Set("updatedAtFrom", params.UpdatedAtFrom)
+	}
+
+	var response ItemsResponse
+	if err := c.do(ctx, http.MethodGet, "/core/v1/items", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListAllItems follows pages from params.Page until a short page or maxPages.
+// The returned meta describes the first page requested. Hitting maxPages marks
+// the result Truncated, since more items may follow.
+func (c *Client) ListAllItems(ctx context.Context, params ListItemsParams, maxPages int) (*ItemsResponse, error) {
+	if params.PerPage <= 0 {
+		params.PerPage = MaxItemsPerPage
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	all := &ItemsResponse{Meta: ItemsMeta{Page: params.Page, PerPage: params.PerPage}}
+	for fetched := 0; ; fetched++ {
+		if maxPages > 0 && fetched == maxPages {
+			all.Truncated = true
+			all.NextPage = params.Page
+			break
+		}
+
+		page, err := c.ListItems(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		all.Resources = append(all.Resources, page.Resources...)
+		if len(page.Resources) < params.PerPage {
+			break
+		}
+		params.Page++
+	}
+
+	return all, nil
+}
+
+const (
+	VASHighlight = "highlight"
+	VASXL        = "xl"
+	VASPushUp    = "pushup"
+	VASPremium   = "premium"
+	VASVIP       = "vip"
+)
+
+func IsValidVAS(vasID string) bool {
+	switch vasID {
+	case VASHighlight, VASXL, VASPushUp, VASPremium, VASVIP:
+		return true
+	}
+	return false
+}
+
+type updatePriceRequest struct {
+	Price int64 `json:"price"`
+}
+
+type UpdatePriceResult struct {
+	Success bool `json:"success"`
+}
+
+func (c *Client) UpdateItemPrice(ctx context.Context, itemID, price int64) (*UpdatePriceResult, error) {
+	var response struct {
+		Result UpdatePriceResult `json:"result"`
+	}
+	path := fmt.Sprintf("/core/v1/items/%d/update_price", itemID)
+	if err := c.do(ctx, http.MethodPost, path, nil, updatePriceRequest{Price: price}, &response); err != nil {
+		return nil, err
+	}
+	return &response.Result, nil
+}
+
+type applyVASRequest struct {
+	VASID string `json:"vas_id"`
+}
+
+type VASResult struct {
+	Amount float64 `json:"amount"`
+}
+
+// ApplyVAS buys a promotion for the item. Avito has no call for removing a
+// promotion early; it simply expires. The purchase is sent without retries: a
+// timeout after Avito has charged must not buy the promotion twice.
+func (c *Client) ApplyVAS(ctx context.Context, userID, itemID int64, vasID string) (*VASResult, error) {
+	var result VASResult
+	path := fmt.Sprintf("/core/v1/accounts/%d/items/%d/vas", userID, itemID)
+	if err := c.do(httpclient.WithoutRetries(ctx), http.MethodPut, path, nil, applyVASRequest{VASID: vasID}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,184 @@
+package avito
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type ChatUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type ChatItem struct {
+	ID    int64  `json:"item_id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type ChatContext struct {
+	Type  string   `json:"type"`
+	Value ChatItem `json:"value"`
+}
+
+type MessageContent struct {
+	Text string `json:"text,omitempty"`
+}
+
+type Message struct {
+	ID        string         `json:"id"`
+	AuthorID  int64          `json:"author_id"`
+	Content   MessageContent `json:"content"`
+	Created   int64          `json:"created"`
+	Direction string         `json:"direction"`
+	IsRead    bool           `json:"is_read"`
+	Read      int64          `json:"read,omitempty"`
+	Type      string         `json:"type"`
+}
+
+type Chat struct {
+	ID          string      `json:"id"`
+	Context     ChatContext `json:"context"`
+	Created     int64       `json:"created"`
+	Updated     int64       `json:"updated"`
+	Users       []ChatUser  `json:"users"`
+	LastMessage *Message    `json:"last_message,omitempty"`
+}
+
+type ChatsResponse struct {
+	Chats []Chat `json:"chats"`
+}
+
+type ListChatsParams struct {
+	ItemIDs    []int64
+	UnreadOnly bool
+	ChatTypes  []string
+	Limit      int
+	Offset     int
+}
+
+func (c *Client) ListChats(ctx context.Context, userID int64, params ListChatsParams) (*ChatsResponse, error) {
+	query := url.Values{}
+	if len(params.ItemIDs) > 0 {
+		ids := make([]string, len(params.ItemIDs))
+		for i, id := range params.ItemIDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		query.Set("item_ids", strings.Join(ids, ","))
+	}
+	if params.UnreadOnly {
+		query.Set("unread_only", "true")
+	}
+	if len(params.ChatTypes) > 0 {
+		query.Set("chat_types", strings.Join(params.ChatTypes, ","))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	var response ChatsResponse
+	path := fmt.Sprintf("/messenger/v2/accounts/%d/chats", userID)
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetChat(ctx context.Context, userID int64, chatID string) (*Chat, error) {
+	var chat Chat
+	path := fmt.Sprintf("/messenger/v2/accounts/%d/chats/%s", userID, url.PathEscape(chatID))
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// ListMessages returns chat messages, newest first.
+func (c *Client) ListMessages(ctx context.Context, userID int64, chatID string, limit, offset int) ([]Message, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var raw json.RawMessage
+	path := fmt.Sprintf("/messenger/v3/accounts/%d/chats/%s/messages/", userID, url.PathEscape(chatID))
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &raw); err != nil {
+		return nil, err
+	}
+
+	// v3 answers with a bare array; older deployments wrap it in {"messages": [...]}.
+	var messages []Message
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return messages, nil
+	}
+
+	var wrapped struct {
+		Messages []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("error unmarshaling Avito messages: %v", err)
+	}
+	return wrapped.Messages, nil
+}
+
+type sendMessageRequest struct {
+	Message MessageContent `json:"message"`
+	Type    string         `json:"type"`
+}
+
+func (c *Client) SendMessage(ctx context.Context, userID int64, chatID, text string) (*Message, error) {
+	body := sendMessageRequest{
+		Message: MessageContent{Text: text},
+		Type:    "text",
+	}
+
+	var message Message
+	path := fmt.Sprintf("/messenger/v1/accounts/%d/chats/%s/messages", userID, url.PathEscape(chatID))
+	if err := c.do(ctx, http.MethodPost, path, nil, body, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (c *Client) MarkChatRead(ctx context.Context, userID int64, chatID string) error {
+	path := fmt.Sprintf("/messenger/v1/accounts/%d/chats/%s/read", userID, url.PathEscape(chatID))
+	return c.do(ctx, http.MethodPost, path, nil, nil, nil)
+}
+
+type WebhookSubscription struct {
+	URL     string `json:"url"`
+	Version string `json:"version"`
+}
+
+type webhookRequest struct {
+	URL string `json:"url"`
+}
+
+func (c *Client) SubscribeWebhook(ctx context.Context, webhookURL string) error {
+	return c.do(ctx, http.MethodPost, "/messenger/v3/webhook", nil, webhookRequest{URL: webhookURL}, nil)
+}
+
+func (c *Client) UnsubscribeWebhook(ctx context.Context, webhookURL string) error {
+	return c.do(ctx, http.MethodPost, "/messenger/v1/webhook/unsubscribe", nil, webhookRequest{URL: webhookURL}, nil)
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	var response struct {
+		Subscriptions []WebhookSubscription `json:"subscriptions"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/messenger/v1/subscriptions", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Subscriptions, nil
+}
@@ -0,0 +1,69 @@
+package avito
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type ReviewAnswer struct {
+	ID        int64  `json:"id"`
+	Text      string `json:"text"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type ReviewItem struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type ReviewSender struct {
+	Name string `json:"name"`
+}
+
+type Review struct {
+	ID        int64         `json:"id"`
+	Score     int           `json:"score"`
+	Stage     string        `json:"stage"`
+	Text      string        `json:"text"`
+	CreatedAt int64         `json:"createdAt"`
+	CanAnswer bool          `json:"canAnswer"`
+	Answer    *ReviewAnswer `json:"answer,omitempty"`
+	Item      *ReviewItem   `json:"item,omitempty"`
+	Sender    *ReviewSender `json:"sender,omitempty"`
+}
+
+type ReviewsResponse struct {
+	Total   int      `json:"total"`
+	Reviews []Review `json:"reviews"`
+}
+
+func (c *Client) ListReviews(ctx context.Context, offset, limit int) (*ReviewsResponse, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var response ReviewsResponse
+	if err := c.do(ctx, http.MethodGet, "/ratings/v1/reviews", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type answerReviewRequest struct {
+	ReviewID int64  `json:"reviewId"`
+	Message  string `json:"message"`
+}
+
+func (c *Client) AnswerReview(ctx context.Context, reviewID int64, text string) (*ReviewAnswer, error) {
+	var answer ReviewAnswer
+	body := answerReviewRequest{ReviewID: reviewID, Message: text}
+	if err := c.do(ctx, http.MethodPost, "/ratings/v1/answers", nil, body, &answer); err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
@@ -0,0 +1,49 @@
+package avito
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	StatsFieldUniqViews     = "uniqViews"
+	StatsFieldUniqContacts  = "uniqContacts"
+	StatsFieldUniqFavorites = "uniqFavorites"
+)
+
+// ItemStatsRequest dates are YYYY-MM-DD. Avito accepts at most 200 item IDs per call.
+type ItemStatsRequest struct {
+	DateFrom       string   `json:"dateFrom"`
+	DateTo         string   `json:"dateTo"`
+	Fields         []string `json:"fields"`
+	ItemIDs        []int64  `json:"itemIds"`
+	PeriodGrouping string   `json:"periodGrouping,omitempty"`
+}
+
+type ItemStatsDay struct {
+	Date          string `json:"date"`
+	UniqViews     int    `json:"uniqViews"`
+	UniqContacts  int    `json:"uniqContacts"`
+	UniqFavorites int    `json:"uniqFavorites"`
+}
+
+type ItemStats struct {
+	ItemID int64          `json:"itemId"`
+	Stats  []ItemStatsDay `json:"stats"`
+}
+
+type ItemStatsResponse struct {
+	Result struct {
+		Items []ItemStats `json:"items"`
+	} `json:"result"`
+}
+
+func (c *Client) GetItemStats(ctx context.Context, userID int64, req ItemStatsRequest) (*ItemStatsResponse, error) {
+	var response ItemStatsResponse
+	path := fmt.Sprintf("/stats/v1/accounts/%d/items", userID)
+	if err := c.do(ctx, http.MethodPost, path, nil, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
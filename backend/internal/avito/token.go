@@ -0,0 +1,184 @@
+package avito
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrNoCredentials = stderrors.New("avito: no access token or client credentials available")
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+func (t *Token) AuthorizationHeader() string {
+	tokenType := t.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenInvalidator is implemented by token sources that cache tokens. The
+// client calls it when Avito rejects a token with 401, then retries once.
+type TokenInvalidator interface {
+	Invalidate(ctx context.Context, token *Token)
+}
+
+type staticTokenSource struct {
+	token *Token
+}
+
+// StaticToken returns a TokenSource that always yields the given token.
+func StaticToken(tokenType, accessToken string) TokenSource {
+	return &staticTokenSource{token: &Token{AccessToken: accessToken, TokenType: tokenType}}
+}
+
+func (s *staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.token, nil
+}
+
+type userCredentialsKey struct{}
+
+// RequireUserCredentials marks ctx so calls made with it never fall back to
+// the application's own credentials: without an OAuth token or avito_*
+// credentials of the request's own they fail with ErrNoCredentials.
+func RequireUserCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userCredentialsKey{}, true)
+}
+
+func requiresUserCredentials(ctx context.Context) bool {
+	required, _ := ctx.Value(userCredentialsKey{}).(bool)
+	return required
+}
+
+// contextTokenSource resolves tokens the way requests carry them: an OAuth
+// token from a connected account first, then client credentials from the
+// avito_* cookies, then the application's own credentials unless the context
+// requires the user's.
+type contextTokenSource struct {
+	client *Client
+}
+
+func (s *contextTokenSource) Token(ctx context.Context) (*Token, error) {
+	if accessToken, ok := ctx.Value("avito_access_token").(string); ok && accessToken != "" {
+		tokenType, _ := ctx.Value("avito_token_type").(string)
+		return &Token{AccessToken: accessToken, TokenType: tokenType}, nil
+	}
+
+	clientID, clientSecret := s.credentials(ctx)
+	if clientID == "" || clientSecret == "" {
+		return nil, ErrNoCredentials
+	}
+
+	return s.client.tokenManager.Token(ctx, clientID, clientSecret)
+}
+
+// Invalidate only affects cached client_credentials tokens; OAuth tokens are
+// owned and refreshed by the connected account.
+func (s *contextTokenSource) Invalidate(ctx context.Context, token *Token) {
+	if accessToken, ok := ctx.Value("avito_access_token").(string); ok && accessToken != "" {
+		return
+	}
+
+	clientID, clientSecret := s.credentials(ctx)
+	s.client.tokenManager.Invalidate(clientID, clientSecret, token)
+}
+
+func (s *contextTokenSource) credentials(ctx context.Context) (string, string) {
+	clientID, clientIDOk := ctx.Value("avito_client_id").(string)
+	clientSecret, clientSecretOk := ctx.Value("avito_client_secret").(string)
+	if !clientIDOk || !clientSecretOk {
+		if requiresUserCredentials(ctx) {
+			return "", ""
+		}
+		return s.client.clientID, s.client.clientSecret
+	}
+	return clientID, clientSecret
+}
+
+// AccountKey identifies the Avito account a request acts as, for keying
+// per-account caches. Only hashes of credentials are included.
+func (c *Client) AccountKey(ctx context.Context) string {
+	source, ok := c.tokens.(*contextTokenSource)
+	if !ok {
+		return "static"
+	}
+
+	if accessToken, ok := ctx.Value("avito_access_token").(string); ok && accessToken != "" {
+		return "oauth:" + hashCredential(accessToken)
+	}
+
+	clientID, clientSecret := source.credentials(ctx)
+	return "client:" + tokenCacheKey(clientID, clientSecret)
+}
+
+func (c *Client) ClientCredentialsToken(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", clientID)
+	form.Set("client_secret", clientSecret)
+
+	return c.requestToken(ctx, form)
+}
+
+// ExchangeCode redeems an authorization code using the application's credentials.
+func (c *Client) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("code", code)
+
+	return c.requestToken(ctx, form)
+}
+
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("refresh_token", refreshToken)
+
+	return c.requestToken(ctx, form)
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	resp, err := c.http.Post(ctx, "/token/", strings.NewReader(form.Encode()), map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+
+	var token Token
+	if err := json.Unmarshal(resp.Body, &token); err != nil {
+		return nil, fmt.Errorf("error unmarshaling token response: %v", err)
+	}
+
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("empty access token in response")
+	}
+
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+
+	return &token, nil
+}
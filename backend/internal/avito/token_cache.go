@@ -0,0 +1,162 @@
+package avito
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// tokenRefreshLeeway drops cached tokens shortly before Avito expires them.
+	tokenRefreshLeeway = time.Minute
+	// defaultTokenTTL is used when Avito omits expires_in.
+	defaultTokenTTL = time.Hour
+)
+
+// errTokenFetchPanicked is what callers waiting on a shared fetch get when it
+// panics; the panic itself continues in the caller that ran the fetch.
+var errTokenFetchPanicked = stderrors.New("avito: token request panicked")
+
+type cachedToken struct {
+	token     *Token
+	expiresAt time.Time
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// TokenMetrics is a snapshot of the token cache counters.
+type TokenMetrics struct {
+	Hits            int64 `json:"hits"`
+	Misses          int64 `json:"misses"`
+	Refreshes       int64 `json:"refreshes"`
+	RefreshFailures int64 `json:"refresh_failures"`
+	Invalidations   int64 `json:"invalidations"`
+}
+
+// TokenManager caches client_credentials tokens per account. Concurrent misses
+// for the same account share one request to /token/.
+type TokenManager struct {
+	fetch func(ctx context.Context, clientID, clientSecret string) (*Token, error)
+
+	mu      sync.Mutex
+	entries map[string]*cachedToken
+	calls   map[string]*tokenCall
+
+	hits            atomic.Int64
+	misses          atomic.Int64
+	refreshes       atomic.Int64
+	refreshFailures atomic.Int64
+	invalidations   atomic.Int64
+}
+
+func NewTokenManager(fetch func(ctx context.Context, clientID, clientSecret string) (*Token, error)) *TokenManager {
+	return &TokenManager{
+		fetch:   fetch,
+		entries: make(map[string]*cachedToken),
+		calls:   make(map[string]*tokenCall),
+	}
+}
+
+// tokenCacheKey includes the secret so a wrong secret for a known client ID
+// can never be answered from the cache.
+func tokenCacheKey(clientID, clientSecret string) string {
+	return clientID + ":" + hashCredential(clientID+"\x00"+clientSecret)
+}
+
+func hashCredential(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (m *TokenManager) Token(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+	key := tokenCacheKey(clientID, clientSecret)
+
+	m.mu.Lock()
+	if entry, ok := m.entries[key]; ok && time.Until(entry.expiresAt) > tokenRefreshLeeway {
+		m.mu.Unlock()
+		m.hits.Add(1)
+		return entry.token, nil
+	}
+
+	m.misses.Add(1)
+
+	if call, ok := m.calls[key]; ok {
+		m.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &tokenCall{done: make(chan struct{})}
+	m.calls[key] = call
+	m.mu.Unlock()
+
+	m.runCall(ctx, key, call, clientID, clientSecret)
+
+	return call.token, call.err
+}
+
+// runCall performs the shared fetch. The call is completed in a defer, so
+// waiters are released with an error even if fetch panics.
+func (m *TokenManager) runCall(ctx context.Context, key string, call *tokenCall, clientID, clientSecret string) {
+	call.err = errTokenFetchPanicked
+	defer m.completeCall(key, call)
+
+	// The shared fetch must not be cancelled by the first caller going away.
+	call.token, call.err = m.fetch(context.WithoutCancel(ctx), clientID, clientSecret)
+}
+
+func (m *TokenManager) completeCall(key string, call *tokenCall) {
+	m.mu.Lock()
+	delete(m.calls, key)
+	if call.err == nil {
+		ttl := time.Duration(call.token.ExpiresIn) * time.Second
+		if ttl <= 0 {
+			ttl = defaultTokenTTL
+		}
+		m.entries[key] = &cachedToken{token: call.token, expiresAt: time.Now().Add(ttl)}
+	}
+	m.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		m.refreshFailures.Add(1)
+	} else {
+		m.refreshes.Add(1)
+	}
+}
+
+// Invalidate drops the cached token if it is still the one that was rejected,
+// leaving a token refreshed in the meantime by another request untouched.
+func (m *TokenManager) Invalidate(clientID, clientSecret string, rejected *Token) {
+	key := tokenCacheKey(clientID, clientSecret)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok && (rejected == nil || entry.token.AccessToken == rejected.AccessToken) {
+		delete(m.entries, key)
+		m.invalidations.Add(1)
+	}
+}
+
+func (m *TokenManager) Metrics() TokenMetrics {
+	return TokenMetrics{
+		Hits:            m.hits.Load(),
+		Misses:          m.misses.Load(),
+		Refreshes:       m.refreshes.Load(),
+		RefreshFailures: m.refreshFailures.Load(),
+		Invalidations:   m.invalidations.Load(),
+	}
+}
@@ -0,0 +1,183 @@
+package avito
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenManagerCachesUntilExpiry(t *testing.T) {
+	var fetches atomic.Int64
+	manager := NewTokenManager(func(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+		n := fetches.Add(1)
+		return &Token{AccessToken: fmt.Sprintf("t%d", n), ExpiresIn: 3600}, nil
+	})
+
+	ctx := context.Background()
+	first, _ := manager.Token(ctx, "id", "secret")
+	second, _ := manager.Token(ctx, "id", "secret")
+	if first != second || fetches.Load() != 1 {
+		t.Fatalf("expected cached token, fetches = %d", fetches.Load())
+	}
+
+	if _, err := manager.Token(ctx, "id", "other-secret"); err != nil || fetches.Load() != 2 {
+		t.Fatalf("different secret must not hit the cache, fetches = %d", fetches.Load())
+	}
+
+	metrics := manager.Metrics()
+	if metrics.Hits != 1 || metrics.Misses != 2 || metrics.Refreshes != 2 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+}
+
+func TestTokenManagerRefreshesNearExpiry(t *testing.T) {
+	var fetches atomic.Int64
+	manager := NewTokenManager(func(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+		fetches.Add(1)
+		return &Token{AccessToken: "t", ExpiresIn: int(tokenRefreshLeeway / time.Second)}, nil
+	})
+
+	manager.Token(context.Background(), "id", "secret")
+	manager.Token(context.Background(), "id", "secret")
+	if fetches.Load() != 2 {
+		t.Errorf("token inside the refresh leeway must be refetched, fetches = %d", fetches.Load())
+	}
+}
+
+func TestTokenManagerSingleFlight(t *testing.T) {
+	var fetches atomic.Int64
+	release := make(chan struct{})
+	manager := NewTokenManager(func(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+		fetches.Add(1)
+		<-release
+		return &Token{AccessToken: "t", ExpiresIn: 3600}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := manager.Token(context.Background(), "id", "secret"); err != nil || token.AccessToken != "t" {
+				t.Errorf("token = %v, err = %v", token, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("fetches = %d, want 1", fetches.Load())
+	}
+}
+
+func TestTokenManagerCountsFailures(t *testing.T) {
+	manager := NewTokenManager(func(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+		return nil, fmt.Errorf("boom")
+	})
+
+	if _, err := manager.Token(context.Background(), "id", "secret"); err == nil {
+		t.Fatal("expected error")
+	}
+	if metrics := manager.Metrics(); metrics.RefreshFailures != 1 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+}
+
+func TestTokenManagerReleasesWaitersWhenFetchPanics(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	manager := NewTokenManager(func(ctx context.Context, clientID, clientSecret string) (*Token, error) {
+		close(started)
+		<-release
+		panic("boom")
+	})
+
+	leader := make(chan interface{})
+	go func() {
+		defer func() { leader <- recover() }()
+		manager.Token(context.Background(), "id", "secret")
+	}()
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, err := manager.Token(context.Background(), "id", "secret")
+		waiter <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if recovered := <-leader; recovered != "boom" {
+		t.Errorf("leader recovered %v, want the fetch panic", recovered)
+	}
+	select {
+	case err := <-waiter:
+		if err != errTokenFetchPanicked {
+			t.Errorf("waiter err = %v, want errTokenFetchPanicked", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter is still blocked after the fetch panicked")
+	}
+	if metrics := manager.Metrics(); metrics.RefreshFailures != 1 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+}
+
+func TestClientRetriesOnceAfterUnauthorized(t *testing.T) {
+	var tokenRequests, apiRequests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token/" {
+			tokenRequests++
+			fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":3600}`, tokenRequests)
+			return
+		}
+
+		apiRequests++
+		if r.Header.Get("Authorization") == "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"token revoked"}`))
+			return
+		}
+		w.Write([]byte(`{"id":1}`))
+	}, WithCredentials("id", "secret"))
+
+	if _, err := client.GetSelf(context.Background()); err != nil {
+		t.Fatalf("GetSelf: %v", err)
+	}
+	if tokenRequests != 2 || apiRequests != 2 {
+		t.Errorf("token requests = %d, api requests = %d", tokenRequests, apiRequests)
+	}
+	if metrics := client.TokenMetrics(); metrics.Invalidations != 1 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+
+	if _, err := client.GetSelf(context.Background()); err != nil {
+		t.Fatalf("GetSelf: %v", err)
+	}
+	if tokenRequests != 2 {
+		t.Errorf("refreshed token must be cached, token requests = %d", tokenRequests)
+	}
+}
+
+func TestClientDoesNotRetryStaticTokens(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}, WithTokenSource(StaticToken("Bearer", "t")))
+
+	if _, err := client.GetSelf(context.Background()); !IsUnauthorized(err) {
+		t.Fatalf("err = %v, want 401", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
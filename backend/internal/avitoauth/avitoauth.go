@@ -0,0 +1,387 @@
+package avitoauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/httpclient"
+	"mini-app-backend/internal/logger"
+	"mini-app-backend/internal/utils"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAuthorizeURL = "https://avito.ru/oauth"
+	defaultScopes       = "messenger:read,messenger:write,items:info,user:read"
+
+	// StateTTL bounds how long a started flow may take; the handler's state
+	// cookie lives as long.
+	StateTTL = 10 * time.Minute
+	// refreshLeeway refreshes tokens slightly before Avito expires them so
+	// in-flight requests do not race the expiry.
+	refreshLeeway = time.Minute
+)
+
+var (
+	ErrNotConfigured      = errors.NewAppError(http.StatusServiceUnavailable, "Avito OAuth is not configured")
+	ErrInvalidState       = errors.NewAppError(http.StatusBadRequest, "Invalid or expired OAuth state")
+	ErrConnectionNotFound = errors.NewAppError(http.StatusNotFound, "Avito account not found")
+	ErrReconnectRequired  = errors.NewAppError(http.StatusUnauthorized, "Avito account must be reconnected")
+)
+
+// Connection is an Avito account connected through the authorization_code flow.
+// Tokens are kept encrypted with the cookie keyring at rest and decrypted by the service.
+type Connection struct {
+	ID           int64     `json:"id" db:"id"`
+	UserID       int64     `json:"user_id" db:"user_id"`
+	AvitoUserID  int64     `json:"avito_user_id" db:"avito_user_id"`
+	Name         string    `json:"name" db:"name"`
+	Scope        string    `json:"scope" db:"scope"`
+	TokenType    string    `json:"-" db:"token_type"`
+	AccessToken  string    `json:"-" db:"access_token"`
+	RefreshToken string    `json:"-" db:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type State struct {
+	State     string    `json:"state" db:"state"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Repository interface {
+	CreateState(state *State) error
+	ConsumeState(state string) (*State, error)
+	UpsertConnection(connection *Connection) error
+	GetConnection(userID, id int64) (*Connection, error)
+	GetLatestConnection(userID int64) (*Connection, error)
+	GetConnectionsByUserID(userID int64) ([]*Connection, error)
+	UpdateConnectionTokens(connection *Connection) error
+	DeleteConnection(userID, id int64) (bool, error)
+	// ListConnections pages through every connection by id, for TokenRotator.
+	ListConnections(afterID int64, limit int) ([]*Connection, error)
+	// ReplaceConnectionTokens swaps the stored tokens only if they still
+	// equal the old ones, so a concurrent refresh is never overwritten.
+	ReplaceConnectionTokens(id int64, oldAccessToken, oldRefreshToken, accessToken, refreshToken string) (bool, error)
+}
+
+type Service struct {
+	repo         Repository
+	keyring      *utils.Keyring
+	client       *avito.Client
+	clientID     string
+	clientSecret string
+	scopes       string
+	authorizeURL string
+
+	refreshMu    sync.Mutex
+	refreshCalls map[refreshKey]*refreshCall
+}
+
+type refreshKey struct {
+	userID int64
+	id     int64
+}
+
+type refreshCall struct {
+	done       chan struct{}
+	connection *Connection
+	err        error
+}
+
+// errRefreshPanicked is what requests waiting on a refresh get when it panics;
+// the panic itself continues in the request that ran it.
+var errRefreshPanicked = stderrors.New("avito token refresh panicked")
+
+func NewService(repo Repository, keyring *utils.Keyring, client *avito.Client, cfg *config.Config) *Service {
+	scopes := cfg.AvitoOAuthScopes
+	if scopes == "" {
+		scopes = defaultScopes
+	}
+
+	authorizeURL := cfg.AvitoOAuthURL
+	if authorizeURL == "" {
+		authorizeURL = defaultAuthorizeURL
+	}
+
+	return &Service{
+		repo:         repo,
+		keyring:      keyring,
+		client:       client,
+		clientID:     cfg.AvitoClientId,
+		clientSecret: cfg.AvitoClientSecret,
+		scopes:       scopes,
+		authorizeURL: authorizeURL,
+		refreshCalls: make(map[refreshKey]*refreshCall),
+	}
+}
+
+// AuthorizationURL starts the flow for a user and returns the URL together
+// with its state. The state is stored server-side because the session cookie
+// is SameSite=Strict and is not sent on Avito's redirect back; the caller must
+// also bind it to the browser, see StateBinding.
+func (s *Service) AuthorizationURL(userID int64) (string, string, error) {
+	if s.clientID == "" || s.clientSecret == "" {
+		return "", "", ErrNotConfigured
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	state := &State{
+		State:     hex.EncodeToString(buf),
+		UserID:    userID,
+		ExpiresAt: now.Add(StateTTL),
+		CreatedAt: now,
+	}
+
+	if err := s.repo.CreateState(state); err != nil {
+		return "", "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", s.clientID)
+	params.Set("scope", s.scopes)
+	params.Set("state", state.State)
+
+	return s.authorizeURL + "?" + params.Encode(), state.State, nil
+}
+
+// StateBinding is what the browser that started the flow keeps in a cookie.
+// Without it anyone could send their own authorization URL to a victim and
+// have the victim's Avito account linked to the sender's user.
+func StateBinding(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckStateBinding reports whether binding came from the browser that started
+// the flow for state.
+func CheckStateBinding(state, binding string) bool {
+	return subtle.ConstantTimeCompare([]byte(StateBinding(state)), []byte(binding)) == 1
+}
+
+// Exchange completes the flow: it redeems the code and stores the connected account.
+func (s *Service) Exchange(ctx context.Context, state, code string) (*Connection, error) {
+	if s.clientID == "" || s.clientSecret == "" {
+		return nil, ErrNotConfigured
+	}
+
+	stored, err := s.repo.ConsumeState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidState
+	}
+
+	token, err := s.client.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, tokenError(err)
+	}
+
+	account, err := s.client.GetSelfWithToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &Connection{
+		UserID:      stored.UserID,
+		AvitoUserID: account.ID,
+		Name:        account.Name,
+	}
+
+	if err := s.applyToken(connection, token); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertConnection(connection); err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (s *Service) GetConnectionsByUserID(userID int64) ([]*Connection, error) {
+	return s.repo.GetConnectionsByUserID(userID)
+}
+
+func (s *Service) DeleteConnection(userID, id int64) error {
+	deleted, err := s.repo.DeleteConnection(userID, id)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return ErrConnectionNotFound
+	}
+
+	return nil
+}
+
+// AccessToken returns a valid token for the user's connected account, refreshing
+// it when it is about to expire. accountID 0 selects the most recently connected
+// account. An empty accessToken with a nil error means the user has no connection.
+func (s *Service) AccessToken(ctx context.Context, userID, accountID int64) (string, string, error) {
+	var connection *Connection
+	var err error
+	if accountID > 0 {
+		connection, err = s.repo.GetConnection(userID, accountID)
+		if err == nil && connection == nil {
+			return "", "", ErrConnectionNotFound
+		}
+	} else {
+		connection, err = s.repo.GetLatestConnection(userID)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if connection == nil {
+		return "", "", nil
+	}
+
+	if time.Until(connection.ExpiresAt) < refreshLeeway {
+		connection, err = s.refresh(ctx, connection.UserID, connection.ID)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	accessToken, err := s.keyring.Decrypt(connection.AccessToken)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt Avito access token: %v", err)
+	}
+
+	return connection.TokenType, accessToken, nil
+}
+
+// refresh renews one connection's tokens. Concurrent requests for the same
+// connection share one call, so the refresh token is spent once; other
+// connections refresh independently and never wait on a slow one.
+func (s *Service) refresh(ctx context.Context, userID, id int64) (*Connection, error) {
+	key := refreshKey{userID: userID, id: id}
+
+	s.refreshMu.Lock()
+	if call, ok := s.refreshCalls[key]; ok {
+		s.refreshMu.Unlock()
+		select {
+		case <-call.done:
+			return call.connection, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &refreshCall{done: make(chan struct{}), err: errRefreshPanicked}
+	s.refreshCalls[key] = call
+	s.refreshMu.Unlock()
+
+	defer func() {
+		s.refreshMu.Lock()
+		delete(s.refreshCalls, key)
+		s.refreshMu.Unlock()
+		close(call.done)
+	}()
+
+	// The shared refresh must not be cancelled by the first caller going away.
+	call.connection, call.err = s.refreshConnection(context.WithoutCancel(ctx), userID, id)
+	return call.connection, call.err
+}
+
+// refreshConnection reloads the connection and refreshes it if it is still
+// about to expire.
+func (s *Service) refreshConnection(ctx context.Context, userID, id int64) (*Connection, error) {
+	connection, err := s.repo.GetConnection(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if connection == nil {
+		return nil, ErrConnectionNotFound
+	}
+
+	if time.Until(connection.ExpiresAt) >= refreshLeeway {
+		return connection, nil
+	}
+
+	refreshToken, err := s.keyring.Decrypt(connection.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt Avito refresh token: %v", err)
+	}
+
+	token, err := s.client.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, tokenError(err)
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	if err := s.applyToken(connection, token); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateConnectionTokens(connection); err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// applyToken seals the tokens with the active key, so every refresh also
+// migrates them off retired keys.
+func (s *Service) applyToken(connection *Connection, token *avito.Token) error {
+	accessToken, err := s.keyring.Encrypt(token.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := s.keyring.Encrypt(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	connection.AccessToken = accessToken
+	connection.RefreshToken = refreshToken
+	connection.TokenType = token.TokenType
+	connection.Scope = token.Scope
+	connection.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return nil
+}
+
+// tokenError maps a rejected grant to ErrReconnectRequired: the code or
+// refresh token is no longer valid and only the user can fix that. An open
+// circuit becomes a 503 so callers fail fast instead of reporting a bad gateway.
+func tokenError(err error) error {
+	if httpclient.IsCircuitOpen(err) {
+		return errors.ErrServiceUnavailable
+	}
+
+	status := avito.StatusCode(err)
+	if status == http.StatusBadRequest || status == http.StatusUnauthorized {
+		logger.Errorf("Avito rejected token request: %v", err)
+		return ErrReconnectRequired
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package avitoauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/utils"
+)
+
+type memoryRepository struct {
+	Repository
+
+	mu          sync.Mutex
+	connections map[int64]*Connection
+}
+
+func (m *memoryRepository) GetConnection(userID, id int64) (*Connection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	connection, ok := m.connections[id]
+	if !ok || connection.UserID != userID {
+		return nil, nil
+	}
+	copied := *connection
+	return &copied, nil
+}
+
+func (m *memoryRepository) UpdateConnectionTokens(connection *Connection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *connection
+	m.connections[connection.ID] = &copied
+	return nil
+}
+
+// newRefreshFixture connects user 1 to two accounts whose tokens have expired.
+// The token endpoint holds refreshes of "slow-refresh" until release is closed.
+func newRefreshFixture(t *testing.T) (*Service, *atomic.Int64, chan struct{}) {
+	t.Helper()
+
+	var slowRefreshes atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("refresh_token") == "slow-refresh" {
+			slowRefreshes.Add(1)
+			<-release
+		}
+		w.Write([]byte(`{"access_token":"fresh","refresh_token":"next","expires_in":3600}`))
+	}))
+	t.Cleanup(server.Close)
+
+	keyring, err := utils.NewKeyring("test", map[string][]byte{"test": []byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	repo := &memoryRepository{connections: make(map[int64]*Connection)}
+	for id, refreshToken := range map[int64]string{1: "slow-refresh", 2: "fast-refresh"} {
+		access, _ := keyring.Encrypt("stale")
+		refresh, _ := keyring.Encrypt(refreshToken)
+		repo.connections[id] = &Connection{ID: id, UserID: 1, AccessToken: access, RefreshToken: refresh, ExpiresAt: time.Now().Add(-time.Minute)}
+	}
+
+	client := avito.NewClient(avito.WithBaseURL(server.URL), avito.WithCredentials("id", "secret"))
+	cfg := &config.Config{AvitoClientId: "id", AvitoClientSecret: "secret"}
+	return NewService(repo, keyring, client, cfg), &slowRefreshes, release
+}
+
+func TestRefreshDoesNotWaitOnOtherConnections(t *testing.T) {
+	service, slowRefreshes, release := newRefreshFixture(t)
+	defer close(release)
+
+	go service.AccessToken(context.Background(), 1, 1)
+	for slowRefreshes.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := service.AccessToken(context.Background(), 1, 2)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AccessToken: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refresh of one account waited on another account's slow refresh")
+	}
+}
+
+func TestRefreshSharesOneCallPerConnection(t *testing.T) {
+	service, slowRefreshes, release := newRefreshFixture(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, accessToken, err := service.AccessToken(context.Background(), 1, 1); err != nil || accessToken != "fresh" {
+				t.Errorf("AccessToken = %q, %v", accessToken, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := slowRefreshes.Load(); n != 1 {
+		t.Errorf("refresh token spent %d times, want once", n)
+	}
+}
@@ -0,0 +1,255 @@
+package avitoauth
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+const connectionColumns = `id, user_id, avito_user_id, name, scope, token_type, access_token, refresh_token, expires_at, created_at, updated_at`
+
+func scanConnection(scan func(dest ...interface{}) error) (*Connection, error) {
+	connection := &Connection{}
+	err := scan(
+		&connection.ID,
+		&connection.UserID,
+		&connection.AvitoUserID,
+		&connection.Name,
+		&connection.Scope,
+		&connection.TokenType,
+		&connection.AccessToken,
+		&connection.RefreshToken,
+		&connection.ExpiresAt,
+		&connection.CreatedAt,
+		&connection.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (r *SQLRepository) CreateState(state *State) error {
+	query := `
+		INSERT INTO avito_oauth_states (state, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(query, state.State, state.UserID, state.ExpiresAt, state.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating OAuth state: %v", err)
+		return err
+	}
+
+	// Abandoned flows never reach the callback, so expired states are swept here.
+	_, err = r.db.Exec(`DELETE FROM avito_oauth_states WHERE expires_at < $1`, state.CreatedAt)
+	if err != nil {
+		log.Printf("Error cleaning up OAuth states: %v", err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) ConsumeState(state string) (*State, error) {
+	query := `
+		DELETE FROM avito_oauth_states
+		WHERE state = $1
+		RETURNING state, user_id, expires_at, created_at
+	`
+
+	stored := &State{}
+	err := r.db.QueryRow(query, state).Scan(&stored.State, &stored.UserID, &stored.ExpiresAt, &stored.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error consuming OAuth state: %v", err)
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+func (r *SQLRepository) UpsertConnection(connection *Connection) error {
+	query := `
+		INSERT INTO avito_connections (user_id, avito_user_id, name, scope, token_type, access_token, refresh_token, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (user_id, avito_user_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			scope = EXCLUDED.scope,
+			token_type = EXCLUDED.token_type,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		connection.UserID,
+		connection.AvitoUserID,
+		connection.Name,
+		connection.Scope,
+		connection.TokenType,
+		connection.AccessToken,
+		connection.RefreshToken,
+		connection.ExpiresAt,
+		time.Now(),
+	).Scan(&connection.ID, &connection.CreatedAt, &connection.UpdatedAt)
+
+	if err != nil {
+		log.Printf("Error upserting Avito connection: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetConnection(userID, id int64) (*Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM avito_connections WHERE id = $1 AND user_id = $2`
+
+	connection, err := scanConnection(r.db.QueryRow(query, id, userID).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting Avito connection: %v", err)
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (r *SQLRepository) GetLatestConnection(userID int64) (*Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM avito_connections WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	connection, err := scanConnection(r.db.QueryRow(query, userID).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting latest Avito connection: %v", err)
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (r *SQLRepository) GetConnectionsByUserID(userID int64) ([]*Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM avito_connections WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		log.Printf("Error getting Avito connections: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connections []*Connection
+	for rows.Next() {
+		connection, err := scanConnection(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning Avito connection: %v", err)
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+
+	return connections, nil
+}
+
+func (r *SQLRepository) UpdateConnectionTokens(connection *Connection) error {
+	query := `
+		UPDATE avito_connections
+		SET scope = $2, token_type = $3, access_token = $4, refresh_token = $5, expires_at = $6, updated_at = $7
+		WHERE id = $1
+	`
+
+	connection.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query,
+		connection.ID,
+		connection.Scope,
+		connection.TokenType,
+		connection.AccessToken,
+		connection.RefreshToken,
+		connection.ExpiresAt,
+		connection.UpdatedAt,
+	)
+
+	if err != nil {
+		log.Printf("Error updating Avito connection tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) ListConnections(afterID int64, limit int) ([]*Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM avito_connections WHERE id > $1 ORDER BY id LIMIT $2`
+
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		log.Printf("Error listing Avito connections: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connections []*Connection
+	for rows.Next() {
+		connection, err := scanConnection(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning Avito connection: %v", err)
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+
+	return connections, rows.Err()
+}
+
+func (r *SQLRepository) ReplaceConnectionTokens(id int64, oldAccessToken, oldRefreshToken, accessToken, refreshToken string) (bool, error) {
+	query := `
+		UPDATE avito_connections
+		SET access_token = $4, refresh_token = $5
+		WHERE id = $1 AND access_token = $2 AND refresh_token = $3
+	`
+
+	result, err := r.db.Exec(query, id, oldAccessToken, oldRefreshToken, accessToken, refreshToken)
+	if err != nil {
+		log.Printf("Error replacing Avito connection tokens: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *SQLRepository) DeleteConnection(userID, id int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM avito_connections WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting Avito connection: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,107 @@
+package avitoauth
+
+import (
+	"context"
+	"mini-app-backend/internal/logger"
+	"mini-app-backend/internal/utils"
+	"time"
+)
+
+const (
+	defaultTokenRotationInterval  = time.Hour
+	defaultTokenRotationBatchSize = 100
+)
+
+// TokenRotator re-encrypts stored OAuth tokens that were sealed with a retired
+// key, so the key can be dropped from COOKIE_ENCRYPTION_KEYS without
+// disconnecting accounts. The tokens are the only values kept encrypted at
+// rest; client secrets are matched by value and stay as they are.
+type TokenRotator struct {
+	repo      Repository
+	keyring   *utils.Keyring
+	interval  time.Duration
+	batchSize int
+}
+
+func NewTokenRotator(repo Repository, keyring *utils.Keyring) *TokenRotator {
+	return &TokenRotator{
+		repo:      repo,
+		keyring:   keyring,
+		interval:  defaultTokenRotationInterval,
+		batchSize: defaultTokenRotationBatchSize,
+	}
+}
+
+// Start runs a rotation pass immediately and then every interval until ctx is done.
+func (r *TokenRotator) Start(ctx context.Context) {
+	if r.keyring == nil {
+		logger.Warn("Token rotation disabled: no encryption keyring")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		rotated, err := r.RotateOnce(ctx)
+		if err != nil {
+			logger.Errorf("Token rotation failed: %v", err)
+		} else if rotated > 0 {
+			logger.Infof("🔑 Re-encrypted tokens of %d Avito connections with key %s", rotated, r.keyring.ActiveKeyID())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RotateOnce re-encrypts every connection whose tokens need it and returns how
+// many were rewritten. A connection refreshed meanwhile already carries tokens
+// sealed with the active key, so losing that race is not an error.
+func (r *TokenRotator) RotateOnce(ctx context.Context) (int, error) {
+	rotated := 0
+
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return rotated, err
+		}
+
+		connections, err := r.repo.ListConnections(afterID, r.batchSize)
+		if err != nil {
+			return rotated, err
+		}
+
+		for _, connection := range connections {
+			afterID = connection.ID
+
+			if !r.keyring.NeedsRotation(connection.AccessToken) && !r.keyring.NeedsRotation(connection.RefreshToken) {
+				continue
+			}
+
+			accessToken, _, err := r.keyring.Reencrypt(connection.AccessToken)
+			if err != nil {
+				return rotated, err
+			}
+			refreshToken, _, err := r.keyring.Reencrypt(connection.RefreshToken)
+			if err != nil {
+				return rotated, err
+			}
+
+			replaced, err := r.repo.ReplaceConnectionTokens(connection.ID, connection.AccessToken, connection.RefreshToken, accessToken, refreshToken)
+			if err != nil {
+				return rotated, err
+			}
+			if replaced {
+				rotated++
+			}
+		}
+
+		if len(connections) < r.batchSize {
+			return rotated, nil
+		}
+	}
+}
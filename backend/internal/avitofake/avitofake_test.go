@@ -0,0 +1,121 @@
+package avitofake
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newFakeClient(t *testing.T, opts ...httpclient.Option) (*Server, *avito.Client) {
+	t.Helper()
+
+	fake, server := NewTestServer(nil)
+	t.Cleanup(server.Close)
+
+	client := avito.NewClient(
+		avito.WithBaseURL(server.URL),
+		avito.WithCredentials("fake-client-id", "fake-client-secret"),
+		avito.WithHTTPOptions(opts...),
+	)
+	return fake, client
+}
+
+func TestClientAgainstFake(t *testing.T) {
+	_, client := newFakeClient(t)
+	ctx := context.Background()
+
+	account, err := client.GetSelf(ctx)
+	if err != nil {
+		t.Fatalf("GetSelf: %v", err)
+	}
+	if account.ID != 1001 {
+		t.Fatalf("account = %+v", account)
+	}
+
+	items, err := client.ListItems(ctx, avito.ListItemsParams{Status: "active,old"})
+	if err != nil || len(items.Resources) != 3 {
+		t.Fatalf("ListItems = %+v, %v", items, err)
+	}
+
+	if _, err := client.SendMessage(ctx, account.ID, "chat-1", "Да, продаётся"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	messages, err := client.ListMessages(ctx, account.ID, "chat-1", 10, 0)
+	if err != nil || len(messages) != 2 || messages[0].Content.Text != "Да, продаётся" {
+		t.Fatalf("ListMessages = %+v, %v", messages, err)
+	}
+
+	if _, err := client.GetChat(ctx, account.ID+1, "chat-1"); avito.StatusCode(err) != http.StatusForbidden {
+		t.Errorf("foreign account err = %v", err)
+	}
+}
+
+func TestFailureInjection(t *testing.T) {
+	fake, client := newFakeClient(t, httpclient.WithTimeout(200*time.Millisecond))
+	ctx := context.Background()
+
+	fake.Inject(Failure{Path: "/core/v1/accounts/self", Status: http.StatusTooManyRequests, Times: 1})
+	if _, err := client.GetSelf(ctx); err != nil {
+		t.Errorf("429 once should be retried: %v", err)
+	}
+
+	fake.Inject(Failure{Path: "/core/v1/items", Status: http.StatusInternalServerError})
+	if _, err := client.ListItems(ctx, avito.ListItemsParams{}); avito.StatusCode(err) != http.StatusInternalServerError {
+		t.Errorf("500 err = %v", err)
+	}
+	fake.ClearFailures()
+
+	fake.Inject(Failure{Path: "/ratings/", Delay: time.Second})
+	if _, err := client.ListReviews(ctx, 0, 10); err == nil {
+		t.Error("slow response should time out")
+	}
+}
+
+func TestRevokedTokenIsRefreshed(t *testing.T) {
+	fake, client := newFakeClient(t)
+	ctx := context.Background()
+
+	if _, err := client.GetSelf(ctx); err != nil {
+		t.Fatalf("GetSelf: %v", err)
+	}
+	fake.RevokeTokens()
+	if _, err := client.GetSelf(ctx); err != nil {
+		t.Errorf("GetSelf after revoke: %v", err)
+	}
+}
+
+func TestIncomingDeliversWebhook(t *testing.T) {
+	delivered := make(chan map[string]interface{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event map[string]interface{}
+		json.Unmarshal(body, &event)
+		delivered <- event
+	}))
+	defer receiver.Close()
+
+	fake, client := newFakeClient(t)
+	if err := client.SubscribeWebhook(context.Background(), receiver.URL); err != nil {
+		t.Fatalf("SubscribeWebhook: %v", err)
+	}
+
+	if _, err := fake.Incoming(1001, "chat-2", "Можно посмотреть сегодня?"); err != nil {
+		t.Fatalf("Incoming: %v", err)
+	}
+
+	select {
+	case event := <-delivered:
+		payload := event["payload"].(map[string]interface{})
+		if payload["type"] != "message" {
+			t.Errorf("event = %v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+}
@@ -0,0 +1,111 @@
+package avitofake
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-app-backend/internal/avito"
+	"os"
+	"time"
+)
+
+// Fixtures describe the sellers the fake knows about. Each account logs in
+// with its own client credentials, as a real Avito business account does.
+type Fixtures struct {
+	Accounts []AccountFixture `json:"accounts"`
+}
+
+type AccountFixture struct {
+	ClientID     string         `json:"client_id"`
+	ClientSecret string         `json:"client_secret"`
+	Account      avito.Account  `json:"account"`
+	Items        []avito.Item   `json:"items"`
+	Chats        []ChatFixture  `json:"chats"`
+	Reviews      []avito.Review `json:"reviews"`
+}
+
+// ChatFixture is a chat with its history, oldest message first.
+type ChatFixture struct {
+	avito.Chat
+	Messages []avito.Message `json:"messages"`
+}
+
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("error parsing fixtures %s: %v", path, err)
+	}
+
+	return &fixtures, nil
+}
+
+// DefaultFixtures is one seller with a few listings, chats and reviews —
+// enough to click through the mini app.
+func DefaultFixtures() *Fixtures {
+	const sellerID = 1001
+	created := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC).Unix()
+
+	item := func(id int64, title string, price float64, status string) avito.Item {
+		return avito.Item{
+			ID:       id,
+			Title:    title,
+			Address:  "Москва",
+			Price:    price,
+			Status:   status,
+			URL:      fmt.Sprintf("https://www.avito.ru/moskva/%d", id),
+			Category: avito.ItemCategory{ID: 114, Name: "Велосипеды"},
+		}
+	}
+
+	chat := func(id string, itemID int64, title string, buyerID int64, buyer string, texts ...string) ChatFixture {
+		fixture := ChatFixture{Chat: avito.Chat{
+			ID:      id,
+			Context: avito.ChatContext{Type: "item", Value: avito.ChatItem{ID: itemID, Title: title}},
+			Created: created,
+			Updated: created,
+			Users:   []avito.ChatUser{{ID: sellerID, Name: "Демо продавец"}, {ID: buyerID, Name: buyer}},
+		}}
+		for i, text := range texts {
+			fixture.Messages = append(fixture.Messages, avito.Message{
+				ID:        fmt.Sprintf("%s-m%d", id, i+1),
+				AuthorID:  buyerID,
+				Content:   avito.MessageContent{Text: text},
+				Created:   created + int64(i*60),
+				Direction: "in",
+				Type:      "text",
+			})
+		}
+		return fixture
+	}
+
+	return &Fixtures{Accounts: []AccountFixture{{
+		ClientID:     "fake-client-id",
+		ClientSecret: "fake-client-secret",
+		Account: avito.Account{
+			ID:         sellerID,
+			Name:       "Демо продавец",
+			Email:      "seller@example.com",
+			Phone:      "+79990000000",
+			ProfileURL: "https://www.avito.ru/user/demo",
+		},
+		Items: []avito.Item{
+			item(2001, "Горный велосипед", 25000, avito.ItemStatusActive),
+			item(2002, "Детский велосипед", 7000, avito.ItemStatusActive),
+			item(2003, "Шоссейный велосипед", 54000, avito.ItemStatusOld),
+		},
+		Chats: []ChatFixture{
+			chat("chat-1", 2001, "Горный велосипед", 3001, "Иван", "Здравствуйте! Велосипед ещё продаётся?"),
+			chat("chat-2", 2002, "Детский велосипед", 3002, "Мария", "Добрый день", "Подойдёт ребёнку 6 лет?"),
+		},
+		Reviews: []avito.Review{
+			{ID: 4001, Score: 5, Stage: "done", Text: "Отличный продавец", CreatedAt: created, CanAnswer: true,
+				Item: &avito.ReviewItem{ID: 2001, Title: "Горный велосипед"}, Sender: &avito.ReviewSender{Name: "Иван"}},
+			{ID: 4002, Score: 2, Stage: "done", Text: "Долго не отвечал", CreatedAt: created + 3600, CanAnswer: true,
+				Item: &avito.ReviewItem{ID: 2002, Title: "Детский велосипед"}, Sender: &avito.ReviewSender{Name: "Мария"}},
+		},
+	}}}
+}
@@ -0,0 +1,751 @@
+package avitofake
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-app-backend/internal/avito"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("POST /token/", s.handleToken)
+	s.mux.HandleFunc("GET /oauth", s.handleAuthorize)
+
+	s.mux.HandleFunc("GET /core/v1/accounts/self", s.handleSelf)
+	s.mux.HandleFunc("GET /core/v1/items", s.handleItems)
+	s.mux.HandleFunc("POST /core/v1/items/{item_id}/update_price", s.handleUpdatePrice)
+	s.mux.HandleFunc("PUT /core/v1/accounts/{user_id}/items/{item_id}/vas", s.handleVAS)
+	s.mux.HandleFunc("POST /stats/v1/accounts/{user_id}/items", s.handleStats)
+
+	s.mux.HandleFunc("GET /messenger/v2/accounts/{user_id}/chats", s.handleChats)
+	s.mux.HandleFunc("GET /messenger/v2/accounts/{user_id}/chats/{chat_id}", s.handleChat)
+	s.mux.HandleFunc("GET /messenger/v3/accounts/{user_id}/chats/{chat_id}/messages/", s.handleMessages)
+	s.mux.HandleFunc("POST /messenger/v1/accounts/{user_id}/chats/{chat_id}/messages", s.handleSend)
+	s.mux.HandleFunc("POST /messenger/v1/accounts/{user_id}/chats/{chat_id}/read", s.handleRead)
+	s.mux.HandleFunc("POST /messenger/v3/webhook", s.handleSubscribe)
+	s.mux.HandleFunc("POST /messenger/v1/webhook/unsubscribe", s.handleUnsubscribe)
+	s.mux.HandleFunc("POST /messenger/v1/subscriptions", s.handleSubscriptions)
+
+	s.mux.HandleFunc("GET /ratings/v1/reviews", s.handleReviews)
+	s.mux.HandleFunc("POST /ratings/v1/answers", s.handleAnswer)
+
+	s.mux.HandleFunc("POST /_fake/failures", s.handleInjectFailure)
+	s.mux.HandleFunc("DELETE /_fake/failures", s.handleClearFailures)
+	s.mux.HandleFunc("POST /_fake/incoming", s.handleIncoming)
+	s.mux.HandleFunc("POST /_fake/revoke", s.handleRevoke)
+	s.mux.HandleFunc("GET /_fake/requests", s.handleRequests)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Form.Get("grant_type") {
+	case "client_credentials":
+		accountID, ok := s.clients[r.Form.Get("client_id")+"\x00"+r.Form.Get("client_secret")]
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_client", "unknown client credentials")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.issueToken(accountID, false))
+	case "authorization_code":
+		accountID, ok := s.codes[r.Form.Get("code")]
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code expired")
+			return
+		}
+		delete(s.codes, r.Form.Get("code"))
+		writeJSON(w, http.StatusOK, s.issueToken(accountID, true))
+	case "refresh_token":
+		accountID, ok := s.refreshTokens[r.Form.Get("refresh_token")]
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token revoked")
+			return
+		}
+		delete(s.refreshTokens, r.Form.Get("refresh_token"))
+		writeJSON(w, http.StatusOK, s.issueToken(accountID, true))
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", r.Form.Get("grant_type"))
+	}
+}
+
+// handleAuthorize approves the consent screen immediately. ?account_id picks
+// the seller; by default the first fixture account is used.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.String() == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	accountID, _ := strconv.ParseInt(query.Get("account_id"), 10, 64)
+	if _, ok := s.accounts[accountID]; !ok {
+		accountID = 0
+		for id := range s.accounts {
+			if accountID == 0 || id < accountID {
+				accountID = id
+			}
+		}
+	}
+	code := s.nextID("fake-code")
+	s.codes[code] = accountID
+	s.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) handleSelf(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account.fixture.Account)
+}
+
+func (s *Server) handleItems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	page := intParam(query.Get("page"), 1)
+	if page < 1 {
+		page = 1
+	}
+	perPage := intParam(query.Get("per_page"), 25)
+
+	statuses := map[string]bool{}
+	for _, status := range strings.Split(query.Get("status"), ",") {
+		if status != "" {
+			statuses[status] = true
+		}
+	}
+	if len(statuses) == 0 {
+		statuses[avito.ItemStatusActive] = true
+	}
+
+	var matched []avito.Item
+	for _, item := range account.fixture.Items {
+		if !statuses[item.Status] {
+			continue
+		}
+		if category := query.Get("category"); category != "" && category != strconv.FormatInt(item.Category.ID, 10) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	writeJSON(w, http.StatusOK, avito.ItemsResponse{
+		Meta:      avito.ItemsMeta{Page: page, PerPage: perPage},
+		Resources: paginate(matched, (page-1)*perPage, perPage),
+	})
+}
+
+func (s *Server) findItem(account *accountState, itemID int64) *avito.Item {
+	for i := range account.fixture.Items {
+		if account.fixture.Items[i].ID == itemID {
+			return &account.fixture.Items[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleUpdatePrice(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, _ := strconv.ParseInt(r.PathValue("item_id"), 10, 64)
+	item := s.findItem(account, itemID)
+	if item == nil {
+		writeError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	var req struct {
+		Price int64 `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Price <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid price")
+		return
+	}
+
+	item.Price = float64(req.Price)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]bool{"success": true}})
+}
+
+func (s *Server) handleVAS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, _ := strconv.ParseInt(r.PathValue("item_id"), 10, 64)
+	if s.findItem(account, itemID) == nil {
+		writeError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	var req struct {
+		VASID string `json:"vas_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !avito.IsValidVAS(req.VASID) {
+		writeError(w, http.StatusBadRequest, "invalid vas_id")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"amount": 99})
+}
+
+// handleStats derives stable pseudo-random numbers from the item and date so
+// repeated calls agree, which the stats cache relies on.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accountFromPath(w, r); !ok {
+		return
+	}
+
+	var req avito.ItemStatsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if len(req.ItemIDs) > 200 {
+		writeError(w, http.StatusBadRequest, "too many itemIds")
+		return
+	}
+
+	from, err1 := time.Parse("2006-01-02", req.DateFrom)
+	to, err2 := time.Parse("2006-01-02", req.DateTo)
+	if err1 != nil || err2 != nil || from.After(to) {
+		writeError(w, http.StatusBadRequest, "invalid date range")
+		return
+	}
+
+	var response avito.ItemStatsResponse
+	for _, itemID := range req.ItemIDs {
+		stats := avito.ItemStats{ItemID: itemID}
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			seed := itemID + int64(date.YearDay())
+			stats.Stats = append(stats.Stats, avito.ItemStatsDay{
+				Date:          date.Format("2006-01-02"),
+				UniqViews:     int(seed%50) + 10,
+				UniqContacts:  int(seed % 7),
+				UniqFavorites: int(seed % 5),
+			})
+		}
+		response.Result.Items = append(response.Result.Items, stats)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// accountFromPath authenticates and checks the {user_id} in the path belongs
+// to the token, like Avito does for account-scoped routes.
+func (s *Server) accountFromPath(w http.ResponseWriter, r *http.Request) (*accountState, bool) {
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if r.PathValue("user_id") != strconv.FormatInt(account.fixture.Account.ID, 10) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return nil, false
+	}
+
+	return account, true
+}
+
+func (s *Server) findChat(account *accountState, chatID string) *ChatFixture {
+	for _, chat := range account.chats {
+		if chat.ID == chatID {
+			return chat
+		}
+	}
+	return nil
+}
+
+func chatView(chat *ChatFixture) avito.Chat {
+	view := chat.Chat
+	if len(chat.Messages) > 0 {
+		last := chat.Messages[len(chat.Messages)-1]
+		view.LastMessage = &last
+	}
+	return view
+}
+
+func (s *Server) handleChats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	itemIDs := map[string]bool{}
+	for _, id := range strings.Split(query.Get("item_ids"), ",") {
+		if id != "" {
+			itemIDs[id] = true
+		}
+	}
+	unreadOnly := query.Get("unread_only") == "true"
+
+	var chats []avito.Chat
+	// Newest activity first, as Avito lists them.
+	for i := len(account.chats) - 1; i >= 0; i-- {
+		chat := account.chats[i]
+		if len(itemIDs) > 0 && !itemIDs[strconv.FormatInt(chat.Context.Value.ID, 10)] {
+			continue
+		}
+		if unreadOnly && !hasUnread(chat) {
+			continue
+		}
+		chats = append(chats, chatView(chat))
+	}
+
+	chats = paginate(chats, intParam(query.Get("offset"), 0), intParam(query.Get("limit"), 100))
+	if chats == nil {
+		chats = []avito.Chat{}
+	}
+
+	writeJSON(w, http.StatusOK, avito.ChatsResponse{Chats: chats})
+}
+
+func hasUnread(chat *ChatFixture) bool {
+	for _, message := range chat.Messages {
+		if message.Direction == "in" && !message.IsRead {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	chat := s.findChat(account, r.PathValue("chat_id"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chatView(chat))
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	chat := s.findChat(account, r.PathValue("chat_id"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	messages := make([]avito.Message, 0, len(chat.Messages))
+	for i := len(chat.Messages) - 1; i >= 0; i-- {
+		messages = append(messages, chat.Messages[i])
+	}
+
+	query := r.URL.Query()
+	page := paginate(messages, intParam(query.Get("offset"), 0), intParam(query.Get("limit"), 100))
+	if page == nil {
+		page = []avito.Message{}
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	chat := s.findChat(account, r.PathValue("chat_id"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	var req struct {
+		Message avito.MessageContent `json:"message"`
+		Type    string               `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Type != "text" || req.Message.Text == "" {
+		writeError(w, http.StatusBadRequest, "invalid message")
+		return
+	}
+
+	message := avito.Message{
+		ID:        s.nextID("fake-msg"),
+		AuthorID:  account.fixture.Account.ID,
+		Content:   req.Message,
+		Created:   s.now().Unix(),
+		Direction: "out",
+		Type:      "text",
+	}
+	chat.Messages = append(chat.Messages, message)
+	chat.Updated = message.Created
+
+	writeJSON(w, http.StatusOK, message)
+}
+
+func (s *Server) handleRead(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	chat := s.findChat(account, r.PathValue("chat_id"))
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return
+	}
+
+	now := s.now().Unix()
+	for i := range chat.Messages {
+		if chat.Messages[i].Direction == "in" && !chat.Messages[i].IsRead {
+			chat.Messages[i].IsRead = true
+			chat.Messages[i].Read = now
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+type webhookBody struct {
+	URL string `json:"url"`
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	for _, existing := range account.webhooks {
+		if existing == req.URL {
+			writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+			return
+		}
+	}
+	account.webhooks = append(account.webhooks, req.URL)
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	kept := account.webhooks[:0]
+	for _, existing := range account.webhooks {
+		if existing != req.URL {
+			kept = append(kept, existing)
+		}
+	}
+	account.webhooks = kept
+
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions := []avito.WebhookSubscription{}
+	for _, webhookURL := range account.webhooks {
+		subscriptions = append(subscriptions, avito.WebhookSubscription{URL: webhookURL, Version: "3"})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"subscriptions": subscriptions})
+}
+
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	reviews := make([]avito.Review, 0, len(account.fixture.Reviews))
+	for i := len(account.fixture.Reviews) - 1; i >= 0; i-- {
+		reviews = append(reviews, account.fixture.Reviews[i])
+	}
+
+	page := paginate(reviews, intParam(query.Get("offset"), 0), intParam(query.Get("limit"), 20))
+	if page == nil {
+		page = []avito.Review{}
+	}
+
+	writeJSON(w, http.StatusOK, avito.ReviewsResponse{Total: len(reviews), Reviews: page})
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ReviewID int64  `json:"reviewId"`
+		Message  string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+
+	for i := range account.fixture.Reviews {
+		review := &account.fixture.Reviews[i]
+		if review.ID != req.ReviewID {
+			continue
+		}
+		if !review.CanAnswer {
+			writeError(w, http.StatusConflict, "review already answered")
+			return
+		}
+
+		s.sequence++
+		review.Answer = &avito.ReviewAnswer{ID: s.sequence, Text: req.Message, Status: "moderation", CreatedAt: s.now().Unix()}
+		review.CanAnswer = false
+		writeJSON(w, http.StatusOK, review.Answer)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "review not found")
+}
+
+type failureRequest struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	DelayMS    int    `json:"delay_ms"`
+	RetryAfter int    `json:"retry_after"`
+	Times      int    `json:"times"`
+}
+
+// handleInjectFailure is the HTTP form of Inject for out-of-process tests:
+// {"path": "/core/v1/items", "status": 429, "retry_after": 1, "times": 2}.
+func (s *Server) handleInjectFailure(w http.ResponseWriter, r *http.Request) {
+	var req failureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Status == 0 && req.DelayMS == 0) {
+		writeError(w, http.StatusBadRequest, "status or delay_ms is required")
+		return
+	}
+
+	s.Inject(Failure{
+		Method:     req.Method,
+		Path:       req.Path,
+		Status:     req.Status,
+		Delay:      time.Duration(req.DelayMS) * time.Millisecond,
+		RetryAfter: req.RetryAfter,
+		Times:      req.Times,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleClearFailures(w http.ResponseWriter, r *http.Request) {
+	s.ClearFailures()
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	s.RevokeTokens()
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) handleRequests(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"requests": s.Requests()})
+}
+
+type incomingRequest struct {
+	AccountID int64  `json:"account_id"`
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+}
+
+func (s *Server) handleIncoming(w http.ResponseWriter, r *http.Request) {
+	var req incomingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChatID == "" || req.Text == "" {
+		writeError(w, http.StatusBadRequest, "chat_id and text are required")
+		return
+	}
+
+	message, err := s.Incoming(req.AccountID, req.ChatID, req.Text)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, message)
+}
+
+// Incoming simulates a buyer writing into a chat and notifies the account's
+// webhook subscribers with a v3 message event.
+func (s *Server) Incoming(accountID int64, chatID, text string) (*avito.Message, error) {
+	s.mu.Lock()
+
+	account, ok := s.accounts[accountID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("account %d not found", accountID)
+	}
+
+	chat := s.findChat(account, chatID)
+	if chat == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("chat %s not found", chatID)
+	}
+
+	var authorID int64
+	for _, user := range chat.Users {
+		if user.ID != accountID {
+			authorID = user.ID
+		}
+	}
+
+	message := avito.Message{
+		ID:        s.nextID("fake-msg"),
+		AuthorID:  authorID,
+		Content:   avito.MessageContent{Text: text},
+		Created:   s.now().Unix(),
+		Direction: "in",
+		Type:      "text",
+	}
+	chat.Messages = append(chat.Messages, message)
+	chat.Updated = message.Created
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":        s.nextID("fake-event"),
+		"version":   "v3.0.0",
+		"timestamp": message.Created,
+		"payload": map[string]interface{}{
+			"type": "message",
+			"value": map[string]interface{}{
+				"id":        message.ID,
+				"chat_id":   chat.ID,
+				"user_id":   accountID,
+				"author_id": authorID,
+				"created":   message.Created,
+				"type":      "text",
+				"chat_type": "u2i",
+				"content":   message.Content,
+				"item_id":   chat.Context.Value.ID,
+			},
+		},
+	})
+	webhooks := append([]string(nil), account.webhooks...)
+	s.mu.Unlock()
+
+	for _, webhookURL := range webhooks {
+		s.deliver(webhookURL, payload)
+	}
+
+	return &message, nil
+}
+
+func intParam(value string, fallback int) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return fallback
+	}
+	return parsed
+}
+
+func paginate[T any](values []T, offset, limit int) []T {
+	if offset >= len(values) {
+		return nil
+	}
+	end := offset + limit
+	if limit <= 0 || end > len(values) {
+		end = len(values)
+	}
+	return values[offset:end]
+}
@@ -0,0 +1,281 @@
+// Package avitofake is an in-memory stand-in for the Avito API. It backs the
+// avito-fake binary for local development and can be mounted with httptest in
+// integration tests.
+package avitofake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mini-app-backend/internal/avito"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tokenTTL = 24 * time.Hour
+
+// Failure makes matching requests fail or stall. An empty Path matches every
+// API route; Times limits how many requests are affected, zero meaning all.
+type Failure struct {
+	Method     string        `json:"method,omitempty"`
+	Path       string        `json:"path,omitempty"`
+	Status     int           `json:"status,omitempty"`
+	Delay      time.Duration `json:"delay,omitempty"`
+	RetryAfter int           `json:"retry_after,omitempty"`
+	Times      int           `json:"times,omitempty"`
+}
+
+type failureRule struct {
+	Failure
+	remaining int
+}
+
+// RecordedRequest lets tests assert on what the client actually sent.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+type accountState struct {
+	fixture  AccountFixture
+	chats    []*ChatFixture
+	webhooks []string
+}
+
+type tokenGrant struct {
+	accountID int64
+	expiresAt time.Time
+}
+
+type Server struct {
+	mux *http.ServeMux
+
+	mu            sync.Mutex
+	accounts      map[int64]*accountState
+	clients       map[string]int64
+	tokens        map[string]tokenGrant
+	refreshTokens map[string]int64
+	codes         map[string]int64
+	failures      []*failureRule
+	requests      []RecordedRequest
+	sequence      int64
+
+	deliver func(url string, payload []byte)
+	now     func() time.Time
+}
+
+func New(fixtures *Fixtures) *Server {
+	s := &Server{
+		mux:           http.NewServeMux(),
+		accounts:      make(map[int64]*accountState),
+		clients:       make(map[string]int64),
+		tokens:        make(map[string]tokenGrant),
+		refreshTokens: make(map[string]int64),
+		codes:         make(map[string]int64),
+		now:           time.Now,
+	}
+	s.deliver = s.postWebhook
+
+	if fixtures == nil {
+		fixtures = DefaultFixtures()
+	}
+	for _, fixture := range fixtures.Accounts {
+		// Copies keep mutations (prices, answers, messages) out of the caller's fixtures.
+		fixture.Items = append([]avito.Item(nil), fixture.Items...)
+		fixture.Reviews = append([]avito.Review(nil), fixture.Reviews...)
+		state := &accountState{fixture: fixture}
+		for i := range fixture.Chats {
+			chat := fixture.Chats[i]
+			chat.Messages = append([]avito.Message(nil), chat.Messages...)
+			state.chats = append(state.chats, &chat)
+		}
+		s.accounts[fixture.Account.ID] = state
+		s.clients[fixture.ClientID+"\x00"+fixture.ClientSecret] = fixture.Account.ID
+	}
+
+	s.routes()
+	return s
+}
+
+// NewTestServer starts the fake on a local port; close it with the returned
+// server's Close.
+func NewTestServer(fixtures *Fixtures) (*Server, *httptest.Server) {
+	fake := New(fixtures)
+	return fake, httptest.NewServer(fake)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/_fake/") {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var buf bytes.Buffer
+		buf.ReadFrom(r.Body)
+		body = buf.Bytes()
+		r.Body.Close()
+		r.Body = readCloser{bytes.NewReader(body)}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Body:   string(body),
+	})
+	rule := s.matchFailure(r)
+	s.mu.Unlock()
+
+	if rule != nil {
+		if rule.Delay > 0 {
+			select {
+			case <-time.After(rule.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if rule.Status != 0 {
+			if rule.RetryAfter > 0 {
+				w.Header().Set("Retry-After", fmt.Sprint(rule.RetryAfter))
+			}
+			writeError(w, rule.Status, http.StatusText(rule.Status))
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+type readCloser struct {
+	*bytes.Reader
+}
+
+func (readCloser) Close() error { return nil }
+
+func (s *Server) matchFailure(r *http.Request) *Failure {
+	for i, rule := range s.failures {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+			continue
+		}
+		if rule.Path != "" && !strings.HasPrefix(r.URL.Path, rule.Path) {
+			continue
+		}
+
+		failure := rule.Failure
+		if rule.Times > 0 {
+			rule.remaining--
+			if rule.remaining <= 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		return &failure
+	}
+	return nil
+}
+
+// Inject adds a failure rule. Rules are checked in the order they were added.
+func (s *Server) Inject(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failureRule{Failure: failure, remaining: failure.Times})
+}
+
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = nil
+}
+
+// Requests returns the API requests served so far, admin calls excluded.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.sequence++
+	return fmt.Sprintf("%s-%d", prefix, s.sequence)
+}
+
+func (s *Server) issueToken(accountID int64, withRefresh bool) map[string]interface{} {
+	accessToken := s.nextID("fake-access")
+	s.tokens[accessToken] = tokenGrant{accountID: accountID, expiresAt: s.now().Add(tokenTTL)}
+
+	response := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+	}
+
+	if withRefresh {
+		refreshToken := s.nextID("fake-refresh")
+		s.refreshTokens[refreshToken] = accountID
+		response["refresh_token"] = refreshToken
+		response["scope"] = "messenger:read,messenger:write,items:info,user:read"
+	}
+
+	return response
+}
+
+// authenticate resolves the bearer token to an account.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*accountState, bool) {
+	header := r.Header.Get("Authorization")
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer"))
+
+	grant, ok := s.tokens[token]
+	if !ok || s.now().After(grant.expiresAt) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	return s.accounts[grant.accountID], true
+}
+
+// RevokeTokens expires every issued access token, e.g. to exercise the
+// client's 401 retry.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = make(map[string]tokenGrant)
+}
+
+func (s *Server) postWebhook(url string, payload []byte) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("avito-fake: webhook delivery to %s failed: %v", url, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError uses the nested error shape of Avito's REST endpoints.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"code": status, "message": message},
+	})
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
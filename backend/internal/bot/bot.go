@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -8,9 +9,11 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/export"
+	"mini-app-backend/internal/migrations"
+	"mini-app-backend/internal/telegram"
 	"mini-app-backend/internal/user"
-
-	_ "github.com/lib/pq"
+	"mini-app-backend/internal/workspace"
 )
 
 type Bot struct {
@@ -19,9 +22,14 @@ type Bot struct {
 	DB       *sql.DB
 	Updates  tgbotapi.UpdatesChannel
 	UserRepo *user.SQLRepository
+
+	UserService      *user.UserService
+	WorkspaceService *workspace.WorkspaceService
+	ExportService    *export.Service
 }
 
-func New(cfg *config.Config) (*Bot, error) {
+// New takes the pool opened by database.Open; the caller owns and closes it.
+func New(cfg *config.Config, db *sql.DB) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
 	if err != nil {
 		return nil, err
@@ -30,6 +38,7 @@ func New(cfg *config.Config) (*Bot, error) {
 	bot := &Bot{
 		API:    api,
 		Config: cfg,
+		DB:     db,
 	}
 
 	if err := bot.setup(); err != nil {
@@ -60,31 +69,23 @@ func (b *Bot) setup() error {
 }
 
 func (b *Bot) initDB() error {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		b.Config.PostgresUser, b.Config.PostgresPassword, b.Config.PostgresHost, b.Config.PostgresPort, b.Config.PostgresDB)
+	db := b.DB
 
-	db, err := sql.Open("postgres", connStr)
+	err := migrations.Up(context.Background(), db)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
-	b.DB = db
-
-	err = db.Ping()
-	if err != nil {
-		return fmt.Errorf("failed to ping database: %v", err)
-	}
-
-	log.Println("✅ Bot connected to database")
+	log.Println("✅ Bot database migrated")
 
 	b.UserRepo = user.NewSQLRepository(db)
+	workspaceRepo := workspace.NewSQLRepository(db)
+	exportRepo := export.NewSQLRepository(db)
 
-	err = b.UserRepo.CreateTables()
-	if err != nil {
-		return fmt.Errorf("failed to create tables: %v", err)
-	}
-
-	log.Println("✅ Bot database tables created")
+	b.UserService = user.NewUserService(b.UserRepo, user.NewTransactor(db))
+	b.WorkspaceService = workspace.NewWorkspaceService(workspaceRepo, workspace.NewTransactor(db))
+	// Large exports are queued here and built by the server's export worker.
+	b.ExportService = export.NewService(exportRepo, telegram.NewNotifier(b.Config.TelegramBotToken), b.Config.PublicURL)
 
 	return nil
 }
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/export"
+)
+
+const exportUsage = `📦 <b>Выгрузка переписки</b>
+
+/export &lt;client_id&gt; [дата_с] [дата_по] [csv|jsonl|xlsx] [chat_id]
+
+Даты в формате ГГГГ-ММ-ДД, по умолчанию — последние 30 дней, формат — csv.
+Пример: <code>/export my-client 2026-03-01 2026-03-31 xlsx</code>`
+
+var exportDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// parseExportArguments reads the client first; the rest is recognised by
+// shape, so optional arguments can be given in any order.
+func parseExportArguments(arguments string) (export.Request, bool) {
+	fields := strings.Fields(arguments)
+	if len(fields) == 0 {
+		return export.Request{}, false
+	}
+
+	req := export.Request{ClientID: fields[0]}
+	for _, field := range fields[1:] {
+		switch {
+		case exportDatePattern.MatchString(field) && req.DateFrom == "":
+			req.DateFrom = field
+		case exportDatePattern.MatchString(field) && req.DateTo == "":
+			req.DateTo = field
+		case field == string(export.FormatCSV) || field == string(export.FormatJSONL) || field == string(export.FormatXLSX):
+			req.Format = export.Format(field)
+		case req.ChatID == "":
+			req.ChatID = field
+		default:
+			return export.Request{}, false
+		}
+	}
+
+	return req, true
+}
+
+// handleExportCommand sends small exports as a document right away and queues
+// larger ones; the worker then sends a download link to this chat.
+func (b *Bot) handleExportCommand(message *tgbotapi.Message) {
+	req, ok := parseExportArguments(message.CommandArguments())
+	if !ok {
+		msg := tgbotapi.NewMessage(message.Chat.ID, exportUsage)
+		msg.ParseMode = "HTML"
+		b.API.Send(msg)
+		return
+	}
+
+	userID := message.From.ID
+
+	filter, err := b.ExportService.Prepare(userID, &req)
+	if err != nil {
+		b.sendExportError(message, err)
+		return
+	}
+
+	count, err := b.ExportService.CountRows(filter)
+	if err != nil {
+		b.sendExportError(message, err)
+		return
+	}
+
+	if count == 0 {
+		b.API.Send(tgbotapi.NewMessage(message.Chat.ID, "📭 За выбранный период сообщений нет."))
+		return
+	}
+
+	if count > export.SmallExportRows {
+		if _, err := b.ExportService.CreateJob(userID, req, message.Chat.ID); err != nil {
+			b.sendExportError(message, err)
+			return
+		}
+		b.API.Send(tgbotapi.NewMessage(message.Chat.ID,
+			fmt.Sprintf("⏳ Готовлю выгрузку (%d сообщений). Пришлю ссылку, когда файл будет готов.", count)))
+		return
+	}
+
+	content, rows, err := b.ExportService.Generate(filter, req.Format)
+	if err != nil {
+		b.sendExportError(message, err)
+		return
+	}
+
+	document := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{
+		Name:  export.FileName(&export.Job{ClientID: req.ClientID, DateFrom: req.DateFrom, DateTo: req.DateTo, ChatID: req.ChatID, Format: req.Format}),
+		Bytes: content,
+	})
+	document.Caption = fmt.Sprintf("📦 Переписка %s за %s — %s: %d сообщений", req.ClientID, req.DateFrom, req.DateTo, rows)
+
+	if _, err := b.API.Send(document); err != nil {
+		log.Printf("Failed send export: %v", err)
+	}
+}
+
+func (b *Bot) sendExportError(message *tgbotapi.Message, err error) {
+	text := "❌ Не удалось подготовить выгрузку. Попробуйте позже."
+	switch err {
+	case export.ErrClientNotFound:
+		text = "❌ Клиент не найден среди ваших клиентов Авито."
+	case export.ErrInvalidRange:
+		text = "❌ Неверный период. Укажите даты в формате ГГГГ-ММ-ДД, не больше года."
+	case export.ErrTooManyRows:
+		text = "❌ Слишком много сообщений для одной выгрузки. Сократите период."
+	default:
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed prepare export: %v", err)
+		}
+	}
+	b.API.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
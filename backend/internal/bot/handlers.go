@@ -1,12 +1,17 @@
 package bot
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"log"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/workspace"
 )
 
 func (b *Bot) HandleCommand(message *tgbotapi.Message) {
@@ -19,6 +24,8 @@ func (b *Bot) HandleCommand(message *tgbotapi.Message) {
 		b.handleAboutCommand(message)
 	case "time":
 		b.handleTimeCommand(message)
+	case "export":
+		b.handleExportCommand(message)
 	default:
 		b.handleUnknownCommand(message)
 	}
@@ -50,6 +57,11 @@ func (b *Bot) HandleMessage(message *tgbotapi.Message) {
 }
 
 func (b *Bot) handleStartCommand(message *tgbotapi.Message) {
+	if token, ok := strings.CutPrefix(message.CommandArguments(), workspace.InvitationStartPrefix); ok {
+		b.handleWorkspaceInvitation(message, token)
+		return
+	}
+
 	welcomeText := fmt.Sprintf(`
 👋 Привет, %s!
 ✨ <b>Это первая версия бота %s</b>
@@ -70,6 +82,46 @@ func (b *Bot) handleStartCommand(message *tgbotapi.Message) {
 	}
 }
 
+func (b *Bot) handleWorkspaceInvitation(message *tgbotapi.Message, token string) {
+	_, err := b.UserService.CreateOrUpdateUser(context.Background(), &user.User{
+		ID:           message.From.ID,
+		FirstName:    message.From.FirstName,
+		LastName:     message.From.LastName,
+		Username:     message.From.UserName,
+		LanguageCode: message.From.LanguageCode,
+	})
+	if err != nil {
+		log.Printf("Failed save user for invitation: %v", err)
+		b.API.Send(tgbotapi.NewMessage(message.Chat.ID, "❌ Не удалось принять приглашение. Попробуйте позже."))
+		return
+	}
+
+	joined, err := b.WorkspaceService.AcceptInvitation(context.Background(), token, message.From.ID)
+	if err != nil {
+		text := "❌ Не удалось принять приглашение. Попробуйте позже."
+		if errors.IsNotFound(err) {
+			text = "❌ Приглашение не найдено или срок его действия истёк."
+		} else if err == workspace.ErrInvitationUsed {
+			text = "❌ Это приглашение уже использовано."
+		} else {
+			log.Printf("Failed accept invitation: %v", err)
+		}
+		b.API.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("✅ Вы присоединились к рабочему пространству <b>%s</b>.\nОткройте мини-приложение и выберите его в профиле.", html.EscapeString(joined.Name)))
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = CreateStartKeyboard(b.API.Self.UserName)
+
+	if _, err := b.API.Send(msg); err != nil {
+		log.Printf("Failed send message: %v", err)
+	} else {
+		log.Printf("✅ @%s joined workspace %d", message.From.UserName, joined.ID)
+	}
+}
+
 func (b *Bot) handleHelpCommand(message *tgbotapi.Message) {
 	helpText := `📚 <b>Доступные команды:</b>
 
@@ -77,6 +129,7 @@ func (b *Bot) handleHelpCommand(message *tgbotapi.Message) {
 /help - Показать это сообщение
 /about - О боте
 /time - Текущее время
+/export - Выгрузить переписку с Авито
 
 🤖 <b>Также я понимаю:</b>
 • Приветствия (привет, здравствуйте)
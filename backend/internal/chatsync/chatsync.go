@@ -0,0 +1,491 @@
+package chatsync
+
+import (
+	"context"
+	"encoding/json"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/events"
+	"mini-app-backend/internal/logger"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/utils"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSyncInterval = 2 * time.Minute
+	chatPageSize        = 100
+	messagePageSize     = 100
+	// Caps keep one pass bounded; history beyond them is not mirrored.
+	maxChatPages    = 50
+	maxMessagePages = 20
+	clientBatchSize = 100
+	maxListLimit    = 100
+)
+
+// Chat is the mirrored chat. Data holds the chat exactly as Avito returned it,
+// so the API can keep serving Avito's shape.
+type Chat struct {
+	ID            string          `json:"id" db:"id"`
+	AvitoUserID   int64           `json:"avito_user_id" db:"avito_user_id"`
+	ItemID        int64           `json:"item_id" db:"item_id"`
+	Updated       int64           `json:"updated" db:"updated"`
+	MessageCursor int64           `json:"-" db:"message_cursor"`
+	Data          json.RawMessage `json:"data" db:"data"`
+	SyncedAt      time.Time       `json:"synced_at" db:"synced_at"`
+	// Unread counts mirrored incoming messages not read yet; filled on listing.
+	Unread int `json:"unread" db:"unread"`
+}
+
+type Message struct {
+	ID          string `json:"id" db:"id"`
+	AvitoUserID int64  `json:"avito_user_id" db:"avito_user_id"`
+	ChatID      string `json:"chat_id" db:"chat_id"`
+	AuthorID    int64  `json:"author_id" db:"author_id"`
+	Direction   string `json:"direction" db:"direction"`
+	Type        string `json:"type" db:"type"`
+	Text        string `json:"text" db:"text"`
+	IsRead      bool   `json:"is_read" db:"is_read"`
+	Created     int64  `json:"created" db:"created"`
+}
+
+// State is the per-account sync cursor. ChatsCursor is the newest chat
+// update already mirrored; older chats are skipped on the next pass.
+type State struct {
+	AvitoUserID int64      `json:"avito_user_id" db:"avito_user_id"`
+	ClientID    string     `json:"client_id" db:"client_id"`
+	ChatsCursor int64      `json:"chats_cursor" db:"chats_cursor"`
+	SyncedAt    *time.Time `json:"synced_at" db:"synced_at"`
+	LastError   string     `json:"last_error,omitempty" db:"last_error"`
+}
+
+type ChatFilter struct {
+	ItemIDs    []int64
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+type Freshness struct {
+	SyncedAt   *time.Time `json:"synced_at"`
+	AgeSeconds int64      `json:"age_seconds"`
+	Stale      bool       `json:"stale"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+type SyncResult struct {
+	Chats    int `json:"chats"`
+	Messages int `json:"messages"`
+}
+
+type Repository interface {
+	GetState(avitoUserID int64) (*State, error)
+	SaveState(state *State) error
+	GetChat(avitoUserID int64, chatID string) (*Chat, error)
+	UpsertChat(chat *Chat) error
+	// UpsertMessages returns the messages that were not mirrored before.
+	UpsertMessages(messages []*Message) ([]*Message, error)
+	SetMessageCursor(avitoUserID int64, chatID string, cursor int64) error
+	ListChats(avitoUserID int64, filter ChatFilter) ([]*Chat, error)
+	CountUnread(avitoUserID int64) (int, error)
+}
+
+type Client interface {
+	GetSelf(ctx context.Context) (*avito.Account, error)
+	ListChats(ctx context.Context, userID int64, params avito.ListChatsParams) (*avito.ChatsResponse, error)
+	ListMessages(ctx context.Context, userID int64, chatID string, limit, offset int) ([]avito.Message, error)
+}
+
+type Publisher interface {
+	PublishAccount(avitoUserID int64, eventType events.Type, data interface{})
+}
+
+// MessageEvent is published for each new incoming message.
+type MessageEvent struct {
+	AvitoUserID int64  `json:"avito_user_id"`
+	ChatID      string `json:"chat_id"`
+	ItemID      int64  `json:"item_id"`
+	MessageID   string `json:"message_id"`
+	AuthorID    int64  `json:"author_id"`
+	Text        string `json:"text"`
+	Created     int64  `json:"created"`
+}
+
+type ClientLister interface {
+	GetClientsWithPagination(ctx context.Context, limit, offset int) ([]*user.Client, error)
+}
+
+type Service struct {
+	repo     Repository
+	client   Client
+	events   Publisher
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	running map[int64]bool
+}
+
+// NewService builds the sync service; events may be nil.
+func NewService(repo Repository, client Client, events Publisher, interval time.Duration) *Service {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	return &Service{
+		repo:     repo,
+		client:   client,
+		events:   events,
+		interval: interval,
+		now:      time.Now,
+		running:  make(map[int64]bool),
+	}
+}
+
+// Start mirrors every stored client immediately and then every interval
+// until ctx is done.
+func (s *Service) Start(ctx context.Context, clients ClientLister) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncClients(ctx, clients)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) SyncClients(ctx context.Context, clients ClientLister) {
+	for offset := 0; ; offset += clientBatchSize {
+		batch, err := clients.GetClientsWithPagination(ctx, clientBatchSize, offset)
+		if err != nil {
+			logger.Errorf("Chat sync: failed to list clients: %v", err)
+			return
+		}
+
+		for _, client := range batch {
+			clientCtx := ClientContext(ctx, client)
+			if _, err := s.SyncClient(clientCtx, client.ClientID); err != nil {
+				logger.Errorf("Chat sync: client %s failed: %v", client.ClientID, err)
+			}
+		}
+
+		if len(batch) < clientBatchSize {
+			return
+		}
+	}
+}
+
+// ClientContext carries a stored client's credentials the way the cookies
+// would. Any OAuth token on ctx is masked, since it would take precedence.
+func ClientContext(ctx context.Context, client *user.Client) context.Context {
+	// Secrets stored before encryption was introduced are still plaintext.
+	secret, err := utils.NewEncryptionUtil().Decrypt(client.ClientSecret)
+	if err != nil {
+		secret = client.ClientSecret
+	}
+
+	ctx = context.WithValue(ctx, "avito_access_token", "")
+	ctx = context.WithValue(ctx, "avito_client_id", client.ClientID)
+	return context.WithValue(ctx, "avito_client_secret", secret)
+}
+
+// SyncClient resolves the account behind the credentials in ctx and mirrors it.
+func (s *Service) SyncClient(ctx context.Context, clientID string) (*SyncResult, error) {
+	account, err := s.client.GetSelf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SyncAccount(ctx, clientID, account.ID)
+}
+
+// SyncAccount runs one incremental pass. Concurrent calls for the same
+// account collapse into the one already running.
+func (s *Service) SyncAccount(ctx context.Context, clientID string, avitoUserID int64) (*SyncResult, error) {
+	s.mu.Lock()
+	if s.running[avitoUserID] {
+		s.mu.Unlock()
+		return &SyncResult{}, nil
+	}
+	s.running[avitoUserID] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, avitoUserID)
+		s.mu.Unlock()
+	}()
+
+	state, err := s.repo.GetState(avitoUserID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &State{AvitoUserID: avitoUserID}
+	}
+	if clientID != "" {
+		state.ClientID = clientID
+	}
+
+	// The first import brings the whole history; only later passes announce
+	// new messages.
+	announce := state.SyncedAt != nil
+	result, newest, syncErr := s.syncChats(ctx, avitoUserID, state.ChatsCursor, announce)
+
+	now := s.now()
+	state.LastError = ""
+	if syncErr != nil {
+		state.LastError = syncErr.Error()
+	} else {
+		state.SyncedAt = &now
+		if newest > state.ChatsCursor {
+			state.ChatsCursor = newest
+		}
+	}
+
+	if err := s.repo.SaveState(state); err != nil {
+		return nil, err
+	}
+
+	return result, syncErr
+}
+
+func (s *Service) syncChats(ctx context.Context, avitoUserID, cursor int64, announce bool) (*SyncResult, int64, error) {
+	result := &SyncResult{}
+	newest := cursor
+
+	for page := 0; page < maxChatPages; page++ {
+		response, err := s.client.ListChats(ctx, avitoUserID, avito.ListChatsParams{
+			Limit:  chatPageSize,
+			Offset: page * chatPageSize,
+		})
+		if err != nil {
+			return result, newest, err
+		}
+
+		for _, remote := range response.Chats {
+			// Chats come most recently updated first, so the first one at or
+			// behind the cursor ends the pass.
+			if remote.Updated <= cursor {
+				return result, newest, nil
+			}
+
+			synced, err := s.syncChat(ctx, avitoUserID, remote, announce)
+			if err != nil {
+				return result, newest, err
+			}
+
+			result.Chats++
+			result.Messages += synced
+			if remote.Updated > newest {
+				newest = remote.Updated
+			}
+		}
+
+		if len(response.Chats) < chatPageSize {
+			break
+		}
+	}
+
+	return result, newest, nil
+}
+
+func (s *Service) syncChat(ctx context.Context, avitoUserID int64, remote avito.Chat, announce bool) (int, error) {
+	data, err := json.Marshal(remote)
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := s.repo.GetChat(avitoUserID, remote.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	chat := &Chat{
+		ID:          remote.ID,
+		AvitoUserID: avitoUserID,
+		ItemID:      remote.Context.Value.ID,
+		Updated:     remote.Updated,
+		Data:        data,
+		SyncedAt:    s.now(),
+	}
+	if existing != nil {
+		chat.MessageCursor = existing.MessageCursor
+	}
+
+	if err := s.repo.UpsertChat(chat); err != nil {
+		return 0, err
+	}
+
+	var messages []*Message
+	newest := chat.MessageCursor
+
+	for page := 0; page < maxMessagePages; page++ {
+		remoteMessages, err := s.client.ListMessages(ctx, avitoUserID, remote.ID, messagePageSize, page*messagePageSize)
+		if err != nil {
+			return 0, err
+		}
+
+		reachedCursor := false
+		for _, message := range remoteMessages {
+			// Messages sharing the cursor second are re-read; upserts make that harmless.
+			if message.Created < chat.MessageCursor {
+				reachedCursor = true
+				break
+			}
+			messages = append(messages, &Message{
+				ID:          message.ID,
+				AvitoUserID: avitoUserID,
+				ChatID:      remote.ID,
+				AuthorID:    message.AuthorID,
+				Direction:   message.Direction,
+				Type:        message.Type,
+				Text:        message.Content.Text,
+				IsRead:      message.IsRead,
+				Created:     message.Created,
+			})
+			if message.Created > newest {
+				newest = message.Created
+			}
+		}
+
+		if reachedCursor || len(remoteMessages) < messagePageSize {
+			break
+		}
+	}
+
+	inserted, err := s.repo.UpsertMessages(messages)
+	if err != nil {
+		return 0, err
+	}
+
+	if announce && s.events != nil {
+		for _, message := range inserted {
+			if message.Direction != "in" {
+				continue
+			}
+			s.events.PublishAccount(avitoUserID, events.TypeMessageReceived, MessageEvent{
+				AvitoUserID: avitoUserID,
+				ChatID:      message.ChatID,
+				ItemID:      chat.ItemID,
+				MessageID:   message.ID,
+				AuthorID:    message.AuthorID,
+				Text:        message.Text,
+				Created:     message.Created,
+			})
+		}
+	}
+
+	if err := s.repo.SetMessageCursor(avitoUserID, remote.ID, newest); err != nil {
+		return 0, err
+	}
+
+	return len(messages), nil
+}
+
+// ListChats serves chats from the mirror in Avito's shape, together with how
+// fresh the mirror is.
+func (s *Service) ListChats(avitoUserID int64, filter ChatFilter) ([]json.RawMessage, *Freshness, error) {
+	chats, err := s.ListMirroredChats(avitoUserID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]json.RawMessage, 0, len(chats))
+	for _, chat := range chats {
+		data = append(data, chat.Data)
+	}
+
+	freshness, err := s.Freshness(avitoUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, freshness, nil
+}
+
+// ListMirroredChats returns mirrored chats, most recently updated first.
+func (s *Service) ListMirroredChats(avitoUserID int64, filter ChatFilter) ([]*Chat, error) {
+	if filter.Limit <= 0 || filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.ListChats(avitoUserID, filter)
+}
+
+// CountUnread counts the account's mirrored incoming messages not read yet.
+func (s *Service) CountUnread(avitoUserID int64) (int, error) {
+	return s.repo.CountUnread(avitoUserID)
+}
+
+// EnsureMirror makes sure the account can be served from the mirror: the
+// first call syncs inline, later calls refresh a stale mirror in the
+// background and answer right away.
+func (s *Service) EnsureMirror(ctx context.Context, clientID string, avitoUserID int64) (*Freshness, error) {
+	mirrored, err := s.HasMirror(avitoUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mirrored {
+		if _, err := s.SyncAccount(ctx, clientID, avitoUserID); err != nil {
+			return nil, err
+		}
+	}
+
+	freshness, err := s.Freshness(avitoUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if freshness.Stale {
+		go func() {
+			if _, err := s.SyncAccount(context.WithoutCancel(ctx), clientID, avitoUserID); err != nil {
+				logger.Errorf("Background chat sync for %d failed: %v", avitoUserID, err)
+			}
+		}()
+	}
+
+	return freshness, nil
+}
+
+// Freshness reports when the account was last mirrored. The mirror counts as
+// stale after two missed intervals or if it was never synced.
+func (s *Service) Freshness(avitoUserID int64) (*Freshness, error) {
+	state, err := s.repo.GetState(avitoUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	freshness := &Freshness{Stale: true}
+	if state == nil {
+		return freshness, nil
+	}
+
+	freshness.LastError = state.LastError
+	if state.SyncedAt != nil {
+		age := s.now().Sub(*state.SyncedAt)
+		freshness.SyncedAt = state.SyncedAt
+		freshness.AgeSeconds = int64(age.Seconds())
+		freshness.Stale = age > 2*s.interval
+	}
+
+	return freshness, nil
+}
+
+// HasMirror reports whether the account has completed at least one sync.
+func (s *Service) HasMirror(avitoUserID int64) (bool, error) {
+	state, err := s.repo.GetState(avitoUserID)
+	if err != nil {
+		return false, err
+	}
+	return state != nil && state.SyncedAt != nil, nil
+}
@@ -0,0 +1,232 @@
+package chatsync
+
+import (
+	"context"
+	stderrors "errors"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/events"
+	"sort"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	state    *State
+	chats    map[string]*Chat
+	messages map[string]*Message
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{chats: make(map[string]*Chat), messages: make(map[string]*Message)}
+}
+
+func (m *memoryRepository) GetState(avitoUserID int64) (*State, error) {
+	if m.state == nil {
+		return nil, nil
+	}
+	copied := *m.state
+	return &copied, nil
+}
+
+func (m *memoryRepository) SaveState(state *State) error {
+	copied := *state
+	m.state = &copied
+	return nil
+}
+
+func (m *memoryRepository) GetChat(avitoUserID int64, chatID string) (*Chat, error) {
+	chat, ok := m.chats[chatID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *chat
+	return &copied, nil
+}
+
+func (m *memoryRepository) UpsertChat(chat *Chat) error {
+	copied := *chat
+	m.chats[chat.ID] = &copied
+	return nil
+}
+
+func (m *memoryRepository) UpsertMessages(messages []*Message) ([]*Message, error) {
+	var inserted []*Message
+	for _, message := range messages {
+		if _, exists := m.messages[message.ID]; !exists {
+			inserted = append(inserted, message)
+		}
+		copied := *message
+		m.messages[message.ID] = &copied
+	}
+	return inserted, nil
+}
+
+func (m *memoryRepository) SetMessageCursor(avitoUserID int64, chatID string, cursor int64) error {
+	m.chats[chatID].MessageCursor = cursor
+	return nil
+}
+
+func (m *memoryRepository) ListChats(avitoUserID int64, filter ChatFilter) ([]*Chat, error) {
+	var chats []*Chat
+	for _, chat := range m.chats {
+		chats = append(chats, chat)
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].Updated > chats[j].Updated })
+	return chats, nil
+}
+
+func (m *memoryRepository) CountUnread(avitoUserID int64) (int, error) {
+	count := 0
+	for _, message := range m.messages {
+		if message.Direction == "in" && !message.IsRead {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeClient struct {
+	chats        []avito.Chat
+	messages     map[string][]avito.Message
+	failMessages bool
+	messageCalls int
+}
+
+func (f *fakeClient) GetSelf(ctx context.Context) (*avito.Account, error) {
+	return &avito.Account{ID: 1001}, nil
+}
+
+func (f *fakeClient) ListChats(ctx context.Context, userID int64, params avito.ListChatsParams) (*avito.ChatsResponse, error) {
+	chats := append([]avito.Chat(nil), f.chats...)
+	sort.Slice(chats, func(i, j int) bool { return chats[i].Updated > chats[j].Updated })
+	if params.Offset >= len(chats) {
+		return &avito.ChatsResponse{}, nil
+	}
+	return &avito.ChatsResponse{Chats: chats[params.Offset:]}, nil
+}
+
+func (f *fakeClient) ListMessages(ctx context.Context, userID int64, chatID string, limit, offset int) ([]avito.Message, error) {
+	f.messageCalls++
+	if f.failMessages {
+		return nil, stderrors.New("upstream failed")
+	}
+	return f.messages[chatID], nil
+}
+
+type recordingPublisher struct {
+	events []MessageEvent
+}
+
+func (p *recordingPublisher) PublishAccount(avitoUserID int64, eventType events.Type, data interface{}) {
+	p.events = append(p.events, data.(MessageEvent))
+}
+
+func newTestService(client *fakeClient) (*Service, *memoryRepository, *time.Time) {
+	repo := newMemoryRepository()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewService(repo, client, nil, time.Minute)
+	service.now = func() time.Time { return now }
+	return service, repo, &now
+}
+
+func textMessage(id string, created int64) avito.Message {
+	return avito.Message{ID: id, Created: created, Direction: "in", Type: "text", Content: avito.MessageContent{Text: id}}
+}
+
+func TestSyncAccountResumesFromCursors(t *testing.T) {
+	client := &fakeClient{
+		chats: []avito.Chat{
+			{ID: "chat-1", Updated: 100, Context: avito.ChatContext{Value: avito.ChatItem{ID: 2001}}},
+			{ID: "chat-2", Updated: 200},
+		},
+		messages: map[string][]avito.Message{
+			"chat-1": {textMessage("m2", 100), textMessage("m1", 90)},
+			"chat-2": {textMessage("m3", 200)},
+		},
+	}
+	service, repo, _ := newTestService(client)
+	publisher := &recordingPublisher{}
+	service.events = publisher
+
+	result, err := service.SyncAccount(context.Background(), "client", 1001)
+	if err != nil {
+		t.Fatalf("SyncAccount: %v", err)
+	}
+	if len(publisher.events) != 0 {
+		t.Fatalf("first import announced %d messages", len(publisher.events))
+	}
+	if result.Chats != 2 || result.Messages != 3 {
+		t.Fatalf("first pass = %+v, want 2 chats and 3 messages", result)
+	}
+	if repo.state.ChatsCursor != 200 || repo.chats["chat-1"].MessageCursor != 100 || repo.chats["chat-1"].ItemID != 2001 {
+		t.Fatalf("cursors not stored: state=%+v chat=%+v", repo.state, repo.chats["chat-1"])
+	}
+
+	// Only chat-1 changed; chat-2 must not be revisited and old messages are not re-imported.
+	client.chats[0].Updated = 300
+	client.messages["chat-1"] = append([]avito.Message{textMessage("m4", 300)}, client.messages["chat-1"]...)
+	client.messageCalls = 0
+
+	result, err = service.SyncAccount(context.Background(), "client", 1001)
+	if err != nil {
+		t.Fatalf("SyncAccount: %v", err)
+	}
+	if result.Chats != 1 || client.messageCalls != 1 {
+		t.Fatalf("second pass = %+v with %d message calls, want one chat", result, client.messageCalls)
+	}
+	if result.Messages != 2 {
+		t.Fatalf("second pass stored %d messages, want the new one plus the cursor second", result.Messages)
+	}
+	if repo.state.ChatsCursor != 300 || len(repo.messages) != 4 {
+		t.Fatalf("state=%+v messages=%d", repo.state, len(repo.messages))
+	}
+	if len(publisher.events) != 1 || publisher.events[0].MessageID != "m4" || publisher.events[0].ItemID != 2001 {
+		t.Fatalf("announced %+v, want only m4", publisher.events)
+	}
+}
+
+func TestSyncAccountKeepsCursorOnFailure(t *testing.T) {
+	client := &fakeClient{chats: []avito.Chat{{ID: "chat-1", Updated: 100}}, failMessages: true}
+	service, repo, _ := newTestService(client)
+
+	if _, err := service.SyncAccount(context.Background(), "client", 1001); err == nil {
+		t.Fatal("expected sync error")
+	}
+	if repo.state.ChatsCursor != 0 || repo.state.SyncedAt != nil || repo.state.LastError == "" {
+		t.Fatalf("failed pass must not advance state: %+v", repo.state)
+	}
+
+	freshness, err := service.Freshness(1001)
+	if err != nil {
+		t.Fatalf("Freshness: %v", err)
+	}
+	if !freshness.Stale || freshness.LastError == "" {
+		t.Fatalf("freshness = %+v, want stale with error", freshness)
+	}
+}
+
+func TestFreshnessTurnsStale(t *testing.T) {
+	client := &fakeClient{chats: []avito.Chat{{ID: "chat-1", Updated: 100}}}
+	service, _, now := newTestService(client)
+
+	if _, err := service.SyncAccount(context.Background(), "client", 1001); err != nil {
+		t.Fatalf("SyncAccount: %v", err)
+	}
+
+	chats, freshness, err := service.ListChats(1001, ChatFilter{})
+	if err != nil {
+		t.Fatalf("ListChats: %v", err)
+	}
+	if len(chats) != 1 || freshness.Stale {
+		t.Fatalf("chats=%d freshness=%+v, want one fresh chat", len(chats), freshness)
+	}
+
+	*now = now.Add(3 * time.Minute)
+	freshness, err = service.Freshness(1001)
+	if err != nil {
+		t.Fatalf("Freshness: %v", err)
+	}
+	if !freshness.Stale || freshness.AgeSeconds != 180 {
+		t.Fatalf("freshness = %+v, want stale after 3m", freshness)
+	}
+}
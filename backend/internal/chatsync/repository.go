@@ -0,0 +1,248 @@
+package chatsync
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+func (r *SQLRepository) GetState(avitoUserID int64) (*State, error) {
+	query := `
+		SELECT avito_user_id, client_id, chats_cursor, synced_at, last_error
+		FROM avito_sync_state
+		WHERE avito_user_id = $1
+	`
+
+	state := &State{}
+	var syncedAt sql.NullTime
+	err := r.db.QueryRow(query, avitoUserID).Scan(&state.AvitoUserID, &state.ClientID, &state.ChatsCursor, &syncedAt, &state.LastError)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting sync state: %v", err)
+		return nil, err
+	}
+
+	if syncedAt.Valid {
+		state.SyncedAt = &syncedAt.Time
+	}
+
+	return state, nil
+}
+
+func (r *SQLRepository) SaveState(state *State) error {
+	query := `
+		INSERT INTO avito_sync_state (avito_user_id, client_id, chats_cursor, synced_at, last_error)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (avito_user_id) DO UPDATE SET
+			client_id = EXCLUDED.client_id,
+			chats_cursor = EXCLUDED.chats_cursor,
+			synced_at = EXCLUDED.synced_at,
+			last_error = EXCLUDED.last_error
+	`
+
+	_, err := r.db.Exec(query, state.AvitoUserID, state.ClientID, state.ChatsCursor, state.SyncedAt, state.LastError)
+	if err != nil {
+		log.Printf("Error saving sync state: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetChat(avitoUserID int64, chatID string) (*Chat, error) {
+	query := `
+		SELECT id, avito_user_id, item_id, updated, message_cursor, data, synced_at
+		FROM avito_chats
+		WHERE avito_user_id = $1 AND id = $2
+	`
+
+	// data is scanned as plain bytes: SQLite returns the JSON as text, which
+	// database/sql only converts into a []byte.
+	chat := &Chat{}
+	err := r.db.QueryRow(query, avitoUserID, chatID).Scan(
+		&chat.ID, &chat.AvitoUserID, &chat.ItemID, &chat.Updated, &chat.MessageCursor, (*[]byte)(&chat.Data), &chat.SyncedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting chat: %v", err)
+		return nil, err
+	}
+
+	return chat, nil
+}
+
+func (r *SQLRepository) UpsertChat(chat *Chat) error {
+	query := `
+		INSERT INTO avito_chats (id, avito_user_id, item_id, updated, message_cursor, data, synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (avito_user_id, id) DO UPDATE SET
+			item_id = EXCLUDED.item_id,
+			updated = EXCLUDED.updated,
+			data = EXCLUDED.data,
+			synced_at = EXCLUDED.synced_at
+	`
+
+	_, err := r.db.Exec(query, chat.ID, chat.AvitoUserID, chat.ItemID, chat.Updated, chat.MessageCursor, string(chat.Data), chat.SyncedAt)
+	if err != nil {
+		log.Printf("Error upserting chat: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) UpsertMessages(messages []*Message) ([]*Message, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("Error starting messages transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Only Postgres can tell an insert from an update in one upsert, so new
+	// messages are inserted first and the rest refreshed afterwards.
+	insert, err := tx.Prepare(`
+		INSERT INTO avito_messages (id, avito_user_id, chat_id, author_id, direction, type, text, is_read, created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (avito_user_id, id) DO NOTHING
+	`)
+	if err != nil {
+		log.Printf("Error preparing messages insert: %v", err)
+		return nil, err
+	}
+	defer insert.Close()
+
+	update, err := tx.Prepare(`
+		UPDATE avito_messages SET text = $3, is_read = $4
+		WHERE avito_user_id = $1 AND id = $2
+	`)
+	if err != nil {
+		log.Printf("Error preparing messages update: %v", err)
+		return nil, err
+	}
+	defer update.Close()
+
+	var inserted []*Message
+	for _, message := range messages {
+		result, err := insert.Exec(message.ID, message.AvitoUserID, message.ChatID, message.AuthorID,
+			message.Direction, message.Type, message.Text, message.IsRead, message.Created)
+		if err != nil {
+			log.Printf("Error upserting message: %v", err)
+			return nil, err
+		}
+		isNew, err := result.RowsAffected()
+		if err != nil {
+			log.Printf("Error upserting message: %v", err)
+			return nil, err
+		}
+		if isNew == 1 {
+			inserted = append(inserted, message)
+			continue
+		}
+
+		if _, err := update.Exec(message.AvitoUserID, message.ID, message.Text, message.IsRead); err != nil {
+			log.Printf("Error upserting message: %v", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return inserted, nil
+}
+
+func (r *SQLRepository) SetMessageCursor(avitoUserID int64, chatID string, cursor int64) error {
+	_, err := r.db.Exec(`UPDATE avito_chats SET message_cursor = $3 WHERE avito_user_id = $1 AND id = $2`, avitoUserID, chatID, cursor)
+	if err != nil {
+		log.Printf("Error saving message cursor: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) ListChats(avitoUserID int64, filter ChatFilter) ([]*Chat, error) {
+	conditions := []string{"c.avito_user_id = $1"}
+	args := []interface{}{avitoUserID}
+
+	if len(filter.ItemIDs) > 0 {
+		args = append(args, pq.Array(filter.ItemIDs))
+		conditions = append(conditions, fmt.Sprintf("c.item_id = ANY($%d)", len(args)))
+	}
+	if filter.UnreadOnly {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM avito_messages m
+			WHERE m.avito_user_id = c.avito_user_id AND m.chat_id = c.id AND m.direction = 'in' AND NOT m.is_read
+		)`)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT c.id, c.avito_user_id, c.item_id, c.updated, c.message_cursor, c.data, c.synced_at,
+			(
+				SELECT COUNT(*) FROM avito_messages m
+				WHERE m.avito_user_id = c.avito_user_id AND m.chat_id = c.id AND m.direction = 'in' AND NOT m.is_read
+			)
+		FROM avito_chats c
+		WHERE %s
+		ORDER BY c.updated DESC, c.id
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Error listing chats: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []*Chat
+	for rows.Next() {
+		chat := &Chat{}
+		err := rows.Scan(&chat.ID, &chat.AvitoUserID, &chat.ItemID, &chat.Updated, &chat.MessageCursor, (*[]byte)(&chat.Data), &chat.SyncedAt, &chat.Unread)
+		if err != nil {
+			log.Printf("Error scanning chat: %v", err)
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+
+	return chats, rows.Err()
+}
+
+func (r *SQLRepository) CountUnread(avitoUserID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM avito_messages
+		WHERE avito_user_id = $1 AND direction = 'in' AND NOT is_read
+	`, avitoUserID).Scan(&count)
+	if err != nil {
+		log.Printf("Error counting unread messages: %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
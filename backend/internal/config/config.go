@@ -3,21 +3,45 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	TelegramBotToken  string
+	TelegramBotName   string
 	ServerPort        string
+	DatabaseDriver    string
+	SQLitePath        string
+	SQLiteBusyTimeout time.Duration
 	PostgresHost      string
 	PostgresUser      string
 	PostgresPassword  string
 	PostgresDB        string
 	PostgresPort      string
+	PostgresSSLMode   string
+	PostgresMaxOpenConns int
+	PostgresMaxIdleConns int
+	PostgresConnMaxLifetime time.Duration
+	PostgresStatementTimeout time.Duration
+	PostgresQueryTimeout time.Duration
+	PostgresApplicationName string
+	PostgresConnectTimeout time.Duration
 	AvitoClientId     string
 	AvitoClientSecret string
+	AvitoAPIURL       string
+	AvitoOAuthScopes  string
+	AvitoOAuthURL     string
+	AvitoOAuthReturnURL string
+	AvitoSyncInterval time.Duration
+	AvitoRateLimit int
+	AvitoRateBurst int
+	PublicURL string
 	CookieEncryptionKey string
+	CookieEncryptionKeys string
+	CookieEncryptionActiveKey string
 }
 
 func Load() *Config {
@@ -27,15 +51,37 @@ func Load() *Config {
 
 	return &Config{
 		TelegramBotToken:  getEnv("BOT_TOKEN", ""),
+		TelegramBotName:   getEnv("BOT_NAME", ""),
 		ServerPort:        getEnv("BACKEND_PORT", "8080"),
+		DatabaseDriver:    getEnv("DATABASE_DRIVER", "postgres"),
+		SQLitePath:        getEnv("SQLITE_PATH", "data/mini-app.db"),
+		SQLiteBusyTimeout: getDurationEnv("SQLITE_BUSY_TIMEOUT", 5*time.Second),
 		PostgresHost:      getEnv("POSTGRES_HOST", "localhost"),
 		PostgresUser:      getEnv("POSTGRES_USER", "postgres"),
 		PostgresPassword:  getEnv("POSTGRES_PASSWORD", "password"),
 		PostgresDB:        getEnv("POSTGRES_DB", "miniapp"),
 		PostgresPort:      getEnv("POSTGRES_PORT", "5432"),
+		PostgresSSLMode:   getEnv("POSTGRES_SSLMODE", "disable"),
+		PostgresMaxOpenConns: getIntEnv("POSTGRES_MAX_OPEN_CONNS", 20),
+		PostgresMaxIdleConns: getIntEnv("POSTGRES_MAX_IDLE_CONNS", 10),
+		PostgresConnMaxLifetime: getDurationEnv("POSTGRES_CONN_MAX_LIFETIME", 30*time.Minute),
+		PostgresStatementTimeout: getDurationEnv("POSTGRES_STATEMENT_TIMEOUT", 30*time.Second),
+		PostgresQueryTimeout: getDurationEnv("POSTGRES_QUERY_TIMEOUT", 5*time.Second),
+		PostgresApplicationName: getEnv("POSTGRES_APPLICATION_NAME", "mini-app-backend"),
+		PostgresConnectTimeout: getDurationEnv("POSTGRES_CONNECT_TIMEOUT", 30*time.Second),
 		AvitoClientId:     getEnv("AVITO_CLIENT_ID", ""),
 		AvitoClientSecret: getEnv("AVITO_CLIENT_SECRET", ""),
+		AvitoAPIURL:       getEnv("AVITO_API_URL", "https://api.avito.ru"),
+		AvitoOAuthScopes:  getEnv("AVITO_OAUTH_SCOPES", ""),
+		AvitoOAuthURL:     getEnv("AVITO_OAUTH_URL", ""),
+		AvitoOAuthReturnURL: getEnv("AVITO_OAUTH_RETURN_URL", "/"),
+		AvitoSyncInterval: getDurationEnv("AVITO_SYNC_INTERVAL", 2*time.Minute),
+		AvitoRateLimit: getIntEnv("AVITO_RATE_LIMIT", 5),
+		AvitoRateBurst: getIntEnv("AVITO_RATE_BURST", 10),
+		PublicURL: getEnv("PUBLIC_URL", ""),
 		CookieEncryptionKey: getEnv("COOKIE_ENCRYPTION_KEY", ""),
+		CookieEncryptionKeys: getEnv("COOKIE_ENCRYPTION_KEYS", ""),
+		CookieEncryptionActiveKey: getEnv("COOKIE_ENCRYPTION_ACTIVE_KEY", ""),
 	}
 }
 
@@ -45,3 +91,31 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Некорректное значение %s=%q, используем %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Printf("Некорректное значение %s=%q, используем %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
@@ -0,0 +1,111 @@
+// Package database opens the pool shared by the HTTP server and the bot, so
+// one process keeps a single set of connections and limits. Postgres is the
+// default; SQLite serves single-node installs from one file.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/logger"
+
+	_ "github.com/lib/pq"
+)
+
+// Reconnect backoff while Postgres is still starting, e.g. under docker-compose.
+var (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+// DSN builds the lib/pq connection URL. Unknown parameters such as
+// statement_timeout are sent to Postgres as session settings.
+func DSN(cfg *config.Config) string {
+	params := url.Values{}
+	params.Set("sslmode", cfg.PostgresSSLMode)
+	if cfg.PostgresApplicationName != "" {
+		params.Set("application_name", cfg.PostgresApplicationName)
+	}
+	if cfg.PostgresStatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(cfg.PostgresStatementTimeout.Milliseconds(), 10))
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.PostgresUser, cfg.PostgresPassword),
+		Host:     net.JoinHostPort(cfg.PostgresHost, cfg.PostgresPort),
+		Path:     "/" + cfg.PostgresDB,
+		RawQuery: params.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// Open configures the pool from cfg and waits for the database to accept
+// connections, retrying with backoff for up to PostgresConnectTimeout.
+// DATABASE_DRIVER=sqlite opens the file at SQLitePath instead.
+func Open(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	switch Dialect(cfg.DatabaseDriver) {
+	case Postgres, "":
+	case SQLite:
+		return openSQLite(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown DATABASE_DRIVER %q: use postgres or sqlite", cfg.DatabaseDriver)
+	}
+
+	db, err := sql.Open("postgres", DSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	db.SetMaxOpenConns(cfg.PostgresMaxOpenConns)
+	db.SetMaxIdleConns(cfg.PostgresMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.PostgresConnMaxLifetime)
+	SetQueryTimeout(cfg.PostgresQueryTimeout)
+
+	err = retry(ctx, cfg.PostgresConnectTimeout, func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	logger.Infof("✅ Connected to database %s on %s:%s", cfg.PostgresDB, cfg.PostgresHost, cfg.PostgresPort)
+
+	return db, nil
+}
+
+// retry calls fn until it succeeds or budget runs out, doubling the pause
+// between attempts up to maxBackoff. The last error is returned.
+func retry(ctx context.Context, budget time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		logger.Warnf("Database is not available (attempt %d): %v", attempt, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/config"
+)
+
+func TestDSN(t *testing.T) {
+	cfg := &config.Config{
+		PostgresHost:             "db",
+		PostgresPort:             "5432",
+		PostgresUser:             "app",
+		PostgresPassword:         "p@ss/word",
+		PostgresDB:               "miniapp",
+		PostgresSSLMode:          "require",
+		PostgresStatementTimeout: 15 * time.Second,
+		PostgresApplicationName:  "mini-app-bot",
+	}
+
+	parsed, err := url.Parse(DSN(cfg))
+	if err != nil {
+		t.Fatalf("DSN is not a valid URL: %v", err)
+	}
+
+	password, _ := parsed.User.Password()
+	if parsed.Host != "db:5432" || parsed.Path != "/miniapp" || password != "p@ss/word" {
+		t.Errorf("dsn = %s", parsed)
+	}
+
+	query := parsed.Query()
+	if query.Get("sslmode") != "require" || query.Get("statement_timeout") != "15000" || query.Get("application_name") != "mini-app-bot" {
+		t.Errorf("params = %v", query)
+	}
+}
+
+func TestRetryBacksOffUntilSuccess(t *testing.T) {
+	initialBackoff, maxBackoff = time.Millisecond, 2*time.Millisecond
+
+	attempts := 0
+	err := retry(context.Background(), time.Second, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("err = %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetryGivesUpAfterBudget(t *testing.T) {
+	initialBackoff, maxBackoff = time.Millisecond, 2*time.Millisecond
+
+	refused := errors.New("connection refused")
+	err := retry(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+		return refused
+	})
+	if err != refused {
+		t.Fatalf("err = %v, want the last attempt's error", err)
+	}
+}
+
+func TestTagAddsSanitisedRequestID(t *testing.T) {
+	query := "SELECT 1"
+
+	if got := Tag(context.Background(), query); got != query {
+		t.Errorf("untagged query = %q", got)
+	}
+
+	ctx := WithRequestID(context.Background(), "3f2a-77 */ DROP TABLE users; --")
+	if got := Tag(ctx, query); got != "/* request_id=3f2a-77DROPTABLEusers-- */ SELECT 1" {
+		t.Errorf("tagged query = %q", got)
+	}
+}
+
+func TestWithTimeoutKeepsEarlierDeadline(t *testing.T) {
+	SetQueryTimeout(time.Hour)
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx, cancelQuery := WithTimeout(parent)
+	defer cancelQuery()
+
+	deadline, _ := ctx.Deadline()
+	if time.Until(deadline) > time.Second {
+		t.Errorf("deadline %v ignores the request's own deadline", deadline)
+	}
+}
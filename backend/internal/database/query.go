@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type requestIDKey struct{}
+
+// queryTimeout bounds each repository call; Open sets it from
+// PostgresQueryTimeout. A shorter deadline already on the context wins.
+var queryTimeout atomic.Int64
+
+func init() {
+	queryTimeout.Store(int64(5 * time.Second))
+}
+
+func SetQueryTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		queryTimeout.Store(int64(timeout))
+	}
+}
+
+// WithTimeout derives the context a single repository call runs under, so a
+// cancelled request or a stuck query releases its connection.
+func WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, time.Duration(queryTimeout.Load()))
+}
+
+// WithRequestID attaches the HTTP request ID that Tag writes into queries.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Tag prefixes query with a /* request_id=... */ comment, which Postgres keeps
+// in pg_stat_activity and the slow-query log, tying a statement to its request.
+func Tag(ctx context.Context, query string) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	requestID = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, requestID)
+	if requestID == "" {
+		return query
+	}
+
+	return "/* request_id=" + requestID + " */ " + query
+}
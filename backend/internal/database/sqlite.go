@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/logger"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// sqliteDriverName is the modernc driver behind the translation below.
+const sqliteDriverName = "mini-app-sqlite"
+
+// sqliteTimeFormat is fixed-width UTC, so timestamps stored as text compare
+// and sort like Postgres TIMESTAMP values. Microseconds match its precision.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000"
+
+// anyParam is `col = ANY($n)` with a pq.Array argument, which SQLite reads as
+// a JSON array instead.
+var anyParam = regexp.MustCompile(`=\s*ANY\((\$\d+)\)`)
+
+// sqliteUniqueColumns picks the columns out of "UNIQUE constraint failed:
+// clients.client_id (2067)".
+var sqliteUniqueColumns = regexp.MustCompile(`UNIQUE constraint failed: ([\w.]+(?:, [\w.]+)*)`)
+
+func init() {
+	sql.Register(sqliteDriverName, sqliteDriver{})
+}
+
+// DialectOf tells which database db was opened on, for the few queries that
+// cannot be written once for both.
+func DialectOf(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(sqliteDriver); ok {
+		return SQLite
+	}
+	return Postgres
+}
+
+// BuyerName is a subquery for the name of the chat member other than the
+// account, read from the chat JSON joined as c for the message m.
+func BuyerName(dialect Dialect) string {
+	if dialect == SQLite {
+		return `SELECT u.value->>'name' FROM json_each(c.data, '$.users') u
+				WHERE CAST(u.value->>'id' AS INTEGER) <> m.avito_user_id
+				LIMIT 1`
+	}
+	return `SELECT u->>'name' FROM jsonb_array_elements(c.data->'users') u
+				WHERE (u->>'id')::BIGINT <> m.avito_user_id
+				LIMIT 1`
+}
+
+// SQLiteDSN points modernc at path. WAL lets the server and the bot read while
+// the other writes, busy_timeout makes a writer wait for the file lock instead
+// of failing, and immediate transactions take that lock up front so two
+// processes never deadlock upgrading a read lock.
+func SQLiteDSN(path string, busyTimeout time.Duration) string {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	params.Set("_txlock", "immediate")
+
+	return "file:" + path + "?" + params.Encode()
+}
+
+func openSQLite(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	if dir := filepath.Dir(cfg.SQLitePath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %v", err)
+		}
+	}
+
+	db, err := sql.Open(sqliteDriverName, SQLiteDSN(cfg.SQLitePath, cfg.SQLiteBusyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	db.SetMaxOpenConns(cfg.PostgresMaxOpenConns)
+	db.SetMaxIdleConns(cfg.PostgresMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.PostgresConnMaxLifetime)
+	SetQueryTimeout(cfg.PostgresQueryTimeout)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database %s: %v", cfg.SQLitePath, err)
+	}
+
+	logger.Infof("✅ Opened SQLite database %s", cfg.SQLitePath)
+
+	return db, nil
+}
+
+// sqliteDriver wraps modernc so the repositories' Postgres-flavoured SQL runs
+// unchanged: $n placeholders already work, and the conn below rewrites
+// arguments and `= ANY($n)`.
+type sqliteDriver struct{}
+
+func (sqliteDriver) Open(name string) (driver.Conn, error) {
+	conn, err := (&sqlite.Driver{}).Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn: conn.(sqliteBaseConn)}, nil
+}
+
+type sqliteBaseConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type sqliteConn struct {
+	conn sqliteBaseConn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(rewriteSQLite(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.conn.PrepareContext(ctx, rewriteSQLite(query))
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.conn.ExecContext(ctx, rewriteSQLite(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.conn.QueryContext(ctx, rewriteSQLite(query), args)
+}
+
+func (c *sqliteConn) Begin() (driver.Tx, error) {
+	return c.conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error { return c.conn.Ping(ctx) }
+
+func (c *sqliteConn) ResetSession(ctx context.Context) error { return c.conn.ResetSession(ctx) }
+
+func (c *sqliteConn) IsValid() bool { return c.conn.IsValid() }
+
+func (c *sqliteConn) Close() error { return c.conn.Close() }
+
+// CheckNamedValue stores times as sqliteTimeFormat and pq arrays as JSON;
+// everything else is converted as database/sql would.
+func (c *sqliteConn) CheckNamedValue(arg *driver.NamedValue) error {
+	if array, ok := jsonArray(arg.Value); ok {
+		encoded, err := json.Marshal(array)
+		if err != nil {
+			return err
+		}
+		arg.Value = string(encoded)
+		return nil
+	}
+
+	value, err := driver.DefaultParameterConverter.ConvertValue(arg.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(sqliteTimeFormat)
+	}
+
+	arg.Value = value
+	return nil
+}
+
+func jsonArray(value interface{}) (interface{}, bool) {
+	switch array := value.(type) {
+	case *pq.Int64Array:
+		return []int64(*array), true
+	case *pq.StringArray:
+		return []string(*array), true
+	case *pq.Float64Array:
+		return []float64(*array), true
+	case *pq.BoolArray:
+		return []bool(*array), true
+	case pq.GenericArray:
+		return array.A, true
+	}
+	return nil, false
+}
+
+func rewriteSQLite(query string) string {
+	return anyParam.ReplaceAllString(query, "IN (SELECT value FROM json_each($1))")
+}
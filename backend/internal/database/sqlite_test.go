@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/config"
+
+	"github.com/lib/pq"
+)
+
+func openTestSQLite(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := Open(context.Background(), &config.Config{
+		DatabaseDriver:       string(SQLite),
+		SQLitePath:           path,
+		SQLiteBusyTimeout:    5 * time.Second,
+		PostgresMaxOpenConns: 4,
+		PostgresMaxIdleConns: 4,
+		PostgresQueryTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if DialectOf(db) != SQLite {
+		t.Fatalf("DialectOf = %s, want sqlite", DialectOf(db))
+	}
+	return db
+}
+
+func TestSQLiteTranslatesPostgresArguments(t *testing.T) {
+	db := openTestSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+
+	_, err := db.Exec(`CREATE TABLE events (id INTEGER PRIMARY KEY, code VARCHAR(16) UNIQUE, at TIMESTAMP NOT NULL)`)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	// A later time in another zone must still sort after an earlier UTC one.
+	moscow := time.FixedZone("MSK", 3*60*60)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{base, base.Add(time.Hour).In(moscow), base.Add(2*time.Hour + 500*time.Microsecond)}
+	for i, at := range times {
+		if _, err := db.Exec(`INSERT INTO events (id, code, at) VALUES ($1, $2, $3)`, i+1, fmt.Sprintf("e%d", i+1), at); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	var ids []int64
+	rows, err := db.Query(`SELECT id FROM events WHERE id = ANY($1) AND at > $2 ORDER BY at DESC`, pq.Array([]int64{1, 2, 3}), base)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 2 {
+		t.Errorf("ids = %v, want [3 2]", ids)
+	}
+
+	var at time.Time
+	if err := db.QueryRow(`SELECT at FROM events WHERE code = ANY($1)`, pq.Array([]string{"e3"})).Scan(&at); err != nil {
+		t.Fatalf("scan time: %v", err)
+	}
+	if !at.Equal(times[2]) {
+		t.Errorf("at = %v, want %v", at, times[2])
+	}
+
+	_, err = db.Exec(`INSERT INTO events (id, code, at) VALUES (4, 'e1', $1)`, base)
+	if got := UniqueViolation(err); got != "events.code" {
+		t.Errorf("UniqueViolation = %q, want events.code", got)
+	}
+}
+
+// TestSQLiteSharedBetweenProcesses opens the file twice, as the server and the
+// bot do, and writes through both at once.
+func TestSQLiteSharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	server, bot := openTestSQLite(t, path), openTestSQLite(t, path)
+
+	if _, err := server.Exec(`CREATE TABLE counters (name TEXT PRIMARY KEY, value INTEGER NOT NULL)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := server.Exec(`INSERT INTO counters (name, value) VALUES ('hits', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		for _, db := range []*sql.DB{server, bot} {
+			wg.Add(1)
+			go func(db *sql.DB) {
+				defer wg.Done()
+				ctx := context.Background()
+				transactor := NewTransactor(db, func(tx DBTX) DBTX { return tx })
+				errs <- transactor.WithTx(ctx, func(tx DBTX) error {
+					var value int
+					if err := tx.QueryRowContext(ctx, `SELECT value FROM counters WHERE name = 'hits'`).Scan(&value); err != nil {
+						return err
+					}
+					_, err := tx.ExecContext(ctx, `UPDATE counters SET value = $1 WHERE name = 'hits'`, value+1)
+					return err
+				})
+			}(db)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent write: %v", err)
+		}
+	}
+
+	var value int
+	if err := bot.QueryRow(`SELECT value FROM counters WHERE name = 'hits'`).Scan(&value); err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	if value != 40 {
+		t.Errorf("counter = %d after 40 increments; a write was lost", value)
+	}
+}
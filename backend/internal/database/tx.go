@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DBTX is what repositories query through: the pool or a transaction.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Transactor is a unit of work: WithTx runs fn with repositories bound to one
+// transaction, committing when fn returns nil and rolling back otherwise.
+type Transactor[R any] interface {
+	WithTx(ctx context.Context, fn func(repos R) error) error
+}
+
+// SQLTransactor opens the transaction on db and hands it to bind, which builds
+// the repositories fn works with.
+type SQLTransactor[R any] struct {
+	db   *sql.DB
+	bind func(tx DBTX) R
+}
+
+func NewTransactor[R any](db *sql.DB, bind func(tx DBTX) R) *SQLTransactor[R] {
+	return &SQLTransactor[R]{
+		db:   db,
+		bind: bind,
+	}
+}
+
+func (t *SQLTransactor[R]) WithTx(ctx context.Context, fn func(repos R) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	// A no-op after Commit; also undoes the work if fn panics.
+	defer tx.Rollback()
+
+	if err := fn(t.bind(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// NoTx runs fn directly against Repos. It is for in-memory repositories in
+// tests, which have nothing to roll back.
+type NoTx[R any] struct {
+	Repos R
+}
+
+func (n NoTx[R]) WithTx(ctx context.Context, fn func(repos R) error) error {
+	return fn(n.Repos)
+}
+
+// UniqueViolation returns the unique constraint err violated, or "" when err
+// is something else. Postgres names the index; SQLite only lists the columns,
+// as "table.column".
+func UniqueViolation(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		if match := sqliteUniqueColumns.FindStringSubmatch(sqliteErr.Error()); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
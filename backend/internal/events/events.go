@@ -0,0 +1,210 @@
+package events
+
+import (
+	"encoding/json"
+	"mini-app-backend/internal/logger"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeMessageReceived Type = "message.received"
+	TypeAutoReplySent   Type = "auto_reply.sent"
+	TypeDeliveryFailed  Type = "delivery.failed"
+	TypeTemplateChanged Type = "template.changed"
+)
+
+const (
+	// The log only bridges reconnects; anything older is refetched by the client.
+	logSize   = 200
+	logMaxAge = 15 * time.Minute
+	// subscriberBuffer absorbs bursts; a subscriber that falls further behind
+	// is disconnected and catches up from the log on reconnect.
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      Type            `json:"type"`
+	UserID    int64           `json:"-"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Audience maps an Avito account to the users who see it.
+type Audience interface {
+	UserIDsForAccount(avitoUserID int64) ([]int64, error)
+}
+
+type userLog struct {
+	events []*Event
+	// evictedID is the newest event dropped from the log; resuming from
+	// before it would miss events.
+	evictedID int64
+}
+
+type subscriber struct {
+	events chan *Event
+	closed bool
+}
+
+// Broker is an in-process pub/sub for per-user events with a short replay log.
+type Broker struct {
+	audience Audience
+	now      func() time.Time
+
+	mu          sync.Mutex
+	firstID     int64
+	nextID      int64
+	logs        map[int64]*userLog
+	subscribers map[int64]map[*subscriber]struct{}
+}
+
+// NewBroker creates a broker. Event IDs start from the clock so that they keep
+// growing across restarts and a stale Last-Event-ID is recognised.
+func NewBroker(audience Audience) *Broker {
+	firstID := time.Now().UnixMilli() * 1000
+	return &Broker{
+		audience:    audience,
+		now:         time.Now,
+		firstID:     firstID,
+		nextID:      firstID,
+		logs:        make(map[int64]*userLog),
+		subscribers: make(map[int64]map[*subscriber]struct{}),
+	}
+}
+
+// Publish records an event for the user and delivers it to live subscribers.
+func (b *Broker) Publish(userID int64, eventType Type, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf("Failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := &Event{
+		ID:        b.nextID,
+		Type:      eventType,
+		UserID:    userID,
+		Data:      payload,
+		CreatedAt: b.now(),
+	}
+
+	log := b.logs[userID]
+	if log == nil {
+		log = &userLog{}
+		b.logs[userID] = log
+	}
+	log.events = append(log.events, event)
+	b.prune(log)
+
+	for sub := range b.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			b.drop(userID, sub)
+		}
+	}
+}
+
+// PublishAccount publishes to every user who sees the Avito account.
+func (b *Broker) PublishAccount(avitoUserID int64, eventType Type, data interface{}) {
+	if b.audience == nil {
+		return
+	}
+
+	userIDs, err := b.audience.UserIDsForAccount(avitoUserID)
+	if err != nil {
+		logger.Errorf("Failed to resolve users of account %d: %v", avitoUserID, err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		b.Publish(userID, eventType, data)
+	}
+}
+
+func (b *Broker) prune(log *userLog) {
+	cutoff := b.now().Add(-logMaxAge)
+	drop := 0
+	for drop < len(log.events) && (len(log.events)-drop > logSize || log.events[drop].CreatedAt.Before(cutoff)) {
+		drop++
+	}
+	if drop > 0 {
+		log.evictedID = log.events[drop-1].ID
+		log.events = append([]*Event(nil), log.events[drop:]...)
+	}
+}
+
+// drop must be called with b.mu held.
+func (b *Broker) drop(userID int64, sub *subscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+	delete(b.subscribers[userID], sub)
+	if len(b.subscribers[userID]) == 0 {
+		delete(b.subscribers, userID)
+	}
+}
+
+type Subscription struct {
+	// Replay holds the events after the requested ID, oldest first.
+	Replay []*Event
+	// Reset is set when events after the requested ID are no longer in the
+	// log; the client should reload its state.
+	Reset bool
+	// Events is closed when the broker drops a subscriber that fell behind.
+	Events <-chan *Event
+
+	broker *Broker
+	userID int64
+	sub    *subscriber
+}
+
+// Subscribe starts delivering the user's events. lastEventID 0 means a fresh
+// connection without replay.
+func (b *Broker) Subscribe(userID, lastEventID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscriber{events: make(chan *Event, subscriberBuffer)}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*subscriber]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	subscription := &Subscription{Events: sub.events, broker: b, userID: userID, sub: sub}
+	if lastEventID <= 0 {
+		return subscription
+	}
+
+	log := b.logs[userID]
+	if lastEventID < b.firstID || (log != nil && lastEventID < log.evictedID) {
+		subscription.Reset = true
+	}
+
+	if log != nil {
+		b.prune(log)
+		for _, event := range log.events {
+			if event.ID > lastEventID {
+				subscription.Replay = append(subscription.Replay, event)
+			}
+		}
+	}
+
+	return subscription
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s.userID, s.sub)
+}
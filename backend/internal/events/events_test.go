@@ -0,0 +1,115 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeAudience map[int64][]int64
+
+func (f fakeAudience) UserIDsForAccount(avitoUserID int64) ([]int64, error) {
+	return f[avitoUserID], nil
+}
+
+func receive(t *testing.T, subscription *Subscription) *Event {
+	t.Helper()
+	select {
+	case event, ok := <-subscription.Events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+		return nil
+	}
+}
+
+func TestPublishDeliversPerUser(t *testing.T) {
+	broker := NewBroker(fakeAudience{1001: {7, 8}})
+
+	mine := broker.Subscribe(7, 0)
+	defer mine.Close()
+	other := broker.Subscribe(9, 0)
+	defer other.Close()
+
+	broker.PublishAccount(1001, TypeMessageReceived, map[string]string{"chat_id": "chat-1"})
+
+	event := receive(t, mine)
+	if event.Type != TypeMessageReceived || string(event.Data) != `{"chat_id":"chat-1"}` {
+		t.Fatalf("event = %+v", event)
+	}
+
+	select {
+	case event := <-other.Events:
+		t.Fatalf("user 9 received %+v", event)
+	default:
+	}
+}
+
+func TestSubscribeReplaysAfterLastEventID(t *testing.T) {
+	broker := NewBroker(nil)
+
+	broker.Publish(7, TypeTemplateChanged, 1)
+	broker.Publish(7, TypeTemplateChanged, 2)
+	broker.Publish(7, TypeTemplateChanged, 3)
+
+	first := broker.Subscribe(7, 0)
+	first.Close()
+	if len(first.Replay) != 0 {
+		t.Fatalf("fresh subscription replayed %d events", len(first.Replay))
+	}
+
+	log := broker.logs[7].events
+	resumed := broker.Subscribe(7, log[0].ID)
+	defer resumed.Close()
+	if resumed.Reset || len(resumed.Replay) != 2 || string(resumed.Replay[0].Data) != "2" {
+		t.Fatalf("resume = reset %v, replay %d", resumed.Reset, len(resumed.Replay))
+	}
+
+	// An ID from before this process started cannot be resumed.
+	stale := broker.Subscribe(7, 42)
+	defer stale.Close()
+	if !stale.Reset {
+		t.Fatal("stale Last-Event-ID should reset")
+	}
+}
+
+func TestLogEvictionForcesReset(t *testing.T) {
+	broker := NewBroker(nil)
+	now := time.Now()
+	broker.now = func() time.Time { return now }
+
+	broker.Publish(7, TypeTemplateChanged, "old")
+	oldID := broker.logs[7].events[0].ID
+
+	now = now.Add(logMaxAge + time.Minute)
+	broker.Publish(7, TypeTemplateChanged, "new")
+
+	subscription := broker.Subscribe(7, oldID-1)
+	defer subscription.Close()
+	if !subscription.Reset || len(subscription.Replay) != 1 {
+		t.Fatalf("reset %v, replay %d; want reset with the remaining event", subscription.Reset, len(subscription.Replay))
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	broker := NewBroker(nil)
+	subscription := broker.Subscribe(7, 0)
+	defer subscription.Close()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		broker.Publish(7, TypeMessageReceived, i)
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("received %d events before the drop, want %d", received, subscriberBuffer)
+	}
+	if len(broker.subscribers[7]) != 0 {
+		t.Fatal("dropped subscriber still registered")
+	}
+}
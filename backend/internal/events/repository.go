@@ -0,0 +1,47 @@
+package events
+
+import (
+	"database/sql"
+	"log"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+// UserIDsForAccount returns the users who reach the account through a stored
+// client or an OAuth connection.
+func (r *SQLRepository) UserIDsForAccount(avitoUserID int64) ([]int64, error) {
+	query := `
+		SELECT cl.user_id FROM avito_sync_state s
+		JOIN clients cl ON cl.client_id = s.client_id
+		WHERE s.avito_user_id = $1
+		UNION
+		SELECT user_id FROM avito_connections WHERE avito_user_id = $1
+	`
+
+	rows, err := r.db.Query(query, avitoUserID)
+	if err != nil {
+		log.Printf("Error getting account users: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Error scanning account user: %v", err)
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,500 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/logger"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// MaxRows bounds a single export; larger ranges have to be split.
+	MaxRows = 100000
+	// SmallExportRows is what the bot sends straight away as a document.
+	SmallExportRows = 2000
+	maxRangeDays    = 366
+	defaultDays     = 30
+
+	jobTTL       = 24 * time.Hour
+	pollInterval = 5 * time.Second
+	// A job left running this long belonged to a process that died.
+	staleJobAfter = 10 * time.Minute
+)
+
+// Conversation dates are Moscow calendar days, as in the Avito views.
+var exportLocation = time.FixedZone("MSK", 3*60*60)
+
+var (
+	ErrInvalidFormat  = errors.NewAppError(http.StatusBadRequest, "format must be csv, jsonl or xlsx")
+	ErrInvalidRange   = errors.NewAppError(http.StatusBadRequest, "Invalid date range")
+	ErrClientNotFound = errors.NewAppError(http.StatusNotFound, "Client not found")
+	ErrJobNotFound    = errors.NewAppError(http.StatusNotFound, "Export not found")
+	ErrJobNotReady    = errors.NewAppError(http.StatusConflict, "Export is not ready")
+	ErrTooManyRows    = errors.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Export is limited to %d messages, narrow the date range", MaxRows))
+)
+
+type Request struct {
+	ClientID string `json:"client_id"`
+	Format   Format `json:"format"`
+	DateFrom string `json:"date_from"`
+	DateTo   string `json:"date_to"`
+	ChatID   string `json:"chat_id,omitempty"`
+}
+
+type Job struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       int64      `json:"user_id" db:"user_id"`
+	ClientID     string     `json:"client_id" db:"client_id"`
+	Format       Format     `json:"format" db:"format"`
+	DateFrom     string     `json:"date_from" db:"date_from"`
+	DateTo       string     `json:"date_to" db:"date_to"`
+	ChatID       string     `json:"chat_id,omitempty" db:"chat_id"`
+	Status       Status     `json:"status" db:"status"`
+	Error        string     `json:"error,omitempty" db:"error"`
+	Rows         int        `json:"rows" db:"rows"`
+	Size         int        `json:"size" db:"size"`
+	TokenHash    string     `json:"-" db:"token_hash"`
+	NotifyChatID int64      `json:"-" db:"notify_chat_id"`
+	DownloadURL  string     `json:"download_url,omitempty" db:"-"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Filter selects the mirrored messages of one client. To is exclusive.
+type Filter struct {
+	ClientID string
+	ChatID   string
+	From     time.Time
+	To       time.Time
+}
+
+type Row struct {
+	Created   time.Time
+	ChatID    string
+	ItemID    int64
+	ItemTitle string
+	BuyerName string
+	Direction string
+	AuthorID  int64
+	Type      string
+	Text      string
+}
+
+type Repository interface {
+	ClientBelongsToUser(userID int64, clientID string) (bool, error)
+	CountRows(filter Filter) (int, error)
+	ListRows(filter Filter, limit int) ([]*Row, error)
+	CreateJob(job *Job) error
+	ClaimJob(now, staleBefore time.Time) (*Job, error)
+	CompleteJob(id uuid.UUID, rows int, content []byte, finishedAt time.Time) error
+	FailJob(id uuid.UUID, message string, finishedAt time.Time) error
+	GetJob(id uuid.UUID) (*Job, error)
+	ListJobs(userID int64) ([]*Job, error)
+	SetTokenHash(id uuid.UUID, tokenHash string) error
+	GetFile(id uuid.UUID) ([]byte, error)
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, chatID int64, text string) error
+}
+
+type Service struct {
+	repo      Repository
+	notifier  Notifier
+	publicURL string
+	now       func() time.Time
+}
+
+// NewService builds the export service. publicURL prefixes download links
+// handed out of the app, e.g. by the bot; notifier may be nil.
+func NewService(repo Repository, notifier Notifier, publicURL string) *Service {
+	return &Service{
+		repo:      repo,
+		notifier:  notifier,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		now:       time.Now,
+	}
+}
+
+// Prepare validates a request and resolves it into a filter. The client has
+// to belong to the user.
+func (s *Service) Prepare(userID int64, req *Request) (Filter, error) {
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	req.ChatID = strings.TrimSpace(req.ChatID)
+
+	if req.Format == "" {
+		req.Format = FormatCSV
+	}
+	switch req.Format {
+	case FormatCSV, FormatJSONL, FormatXLSX:
+	default:
+		return Filter{}, ErrInvalidFormat
+	}
+
+	now := s.now().In(exportLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, exportLocation)
+
+	to := today
+	if req.DateTo != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.DateTo, exportLocation)
+		if err != nil {
+			return Filter{}, ErrInvalidRange
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(defaultDays - 1))
+	if req.DateFrom != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.DateFrom, exportLocation)
+		if err != nil {
+			return Filter{}, ErrInvalidRange
+		}
+		from = parsed
+	}
+
+	if from.After(to) || to.Sub(from) > maxRangeDays*24*time.Hour {
+		return Filter{}, ErrInvalidRange
+	}
+
+	req.DateFrom = from.Format(dateLayout)
+	req.DateTo = to.Format(dateLayout)
+
+	if req.ClientID == "" {
+		return Filter{}, ErrClientNotFound
+	}
+
+	owned, err := s.repo.ClientBelongsToUser(userID, req.ClientID)
+	if err != nil {
+		return Filter{}, err
+	}
+	if !owned {
+		return Filter{}, ErrClientNotFound
+	}
+
+	return Filter{
+		ClientID: req.ClientID,
+		ChatID:   req.ChatID,
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+	}, nil
+}
+
+// CountRows tells how many messages a prepared filter would export.
+func (s *Service) CountRows(filter Filter) (int, error) {
+	return s.repo.CountRows(filter)
+}
+
+// CreateJob queues an export for the background worker. When notifyChatID is
+// set, the worker sends the download link there once the file is ready.
+func (s *Service) CreateJob(userID int64, req Request, notifyChatID int64) (*Job, error) {
+	if _, err := s.Prepare(userID, &req); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	job := &Job{
+		ID:           uuid.New(),
+		UserID:       userID,
+		ClientID:     req.ClientID,
+		Format:       req.Format,
+		DateFrom:     req.DateFrom,
+		DateTo:       req.DateTo,
+		ChatID:       req.ChatID,
+		Status:       StatusPending,
+		TokenHash:    hashToken(token),
+		NotifyChatID: notifyChatID,
+		ExpiresAt:    now.Add(jobTTL),
+		CreatedAt:    now,
+	}
+
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	// The token is only known now; later reads link to the cookie-authenticated URL.
+	job.DownloadURL = s.downloadURL(job.ID, token)
+	return job, nil
+}
+
+// Generate renders the messages matching filter in the given format.
+func (s *Service) Generate(filter Filter, format Format) ([]byte, int, error) {
+	rows, err := s.repo.ListRows(filter, MaxRows+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(rows) > MaxRows {
+		return nil, 0, ErrTooManyRows
+	}
+
+	var content []byte
+	switch format {
+	case FormatCSV:
+		content, err = writeCSV(rows)
+	case FormatJSONL:
+		content, err = writeJSONL(rows)
+	case FormatXLSX:
+		content, err = writeXLSX(rows)
+	default:
+		return nil, 0, ErrInvalidFormat
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return content, len(rows), nil
+}
+
+// Start processes queued jobs and drops expired files until ctx is done.
+// Jobs are claimed with SKIP LOCKED, so the server and the bot can both run it.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := s.repo.DeleteExpired(s.now()); err != nil {
+			logger.Errorf("Export cleanup failed: %v", err)
+		} else if deleted > 0 {
+			logger.Infof("Deleted %d expired exports", deleted)
+		}
+
+		for {
+			processed, err := s.ProcessNext(ctx)
+			if err != nil {
+				logger.Errorf("Export worker failed: %v", err)
+			}
+			if !processed || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext runs one queued job. It reports whether there was one.
+func (s *Service) ProcessNext(ctx context.Context) (bool, error) {
+	now := s.now()
+	job, err := s.repo.ClaimJob(now, now.Add(-staleJobAfter))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	content, rows, err := s.generateJob(job)
+	if err != nil {
+		message := "Export failed"
+		if appErr, ok := err.(*errors.AppError); ok {
+			message = appErr.Message
+		} else {
+			logger.Errorf("Export %s failed: %v", job.ID, err)
+		}
+		if err := s.repo.FailJob(job.ID, message, s.now()); err != nil {
+			return true, err
+		}
+		s.notify(ctx, job, "❌ Не удалось подготовить выгрузку переписки: "+message)
+		return true, nil
+	}
+
+	if err := s.repo.CompleteJob(job.ID, rows, content, s.now()); err != nil {
+		return true, err
+	}
+
+	if job.NotifyChatID == 0 {
+		return true, nil
+	}
+
+	// Only the token hash is stored, so the link for the chat gets a new token.
+	token, err := generateToken()
+	if err != nil {
+		return true, err
+	}
+	if err := s.repo.SetTokenHash(job.ID, hashToken(token)); err != nil {
+		return true, err
+	}
+
+	s.notify(ctx, job, fmt.Sprintf("✅ Выгрузка переписки готова: %d сообщений.\nСсылка действует до %s:\n%s",
+		rows, job.ExpiresAt.In(exportLocation).Format("02.01.2006 15:04"), s.downloadURL(job.ID, token)))
+	return true, nil
+}
+
+func (s *Service) generateJob(job *Job) ([]byte, int, error) {
+	from, err := time.ParseInLocation(dateLayout, job.DateFrom, exportLocation)
+	if err != nil {
+		return nil, 0, ErrInvalidRange
+	}
+	to, err := time.ParseInLocation(dateLayout, job.DateTo, exportLocation)
+	if err != nil {
+		return nil, 0, ErrInvalidRange
+	}
+
+	return s.Generate(Filter{
+		ClientID: job.ClientID,
+		ChatID:   job.ChatID,
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+	}, job.Format)
+}
+
+func (s *Service) notify(ctx context.Context, job *Job, text string) {
+	if s.notifier == nil || job.NotifyChatID == 0 {
+		return
+	}
+	if err := s.notifier.Notify(ctx, job.NotifyChatID, text); err != nil {
+		logger.Errorf("Failed to notify about export %s: %v", job.ID, err)
+	}
+}
+
+func (s *Service) GetJob(userID int64, id uuid.UUID) (*Job, error) {
+	job, err := s.repo.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+
+	s.decorate(job)
+	return job, nil
+}
+
+func (s *Service) ListJobs(userID int64) ([]*Job, error) {
+	jobs, err := s.repo.ListJobs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		s.decorate(job)
+	}
+	return jobs, nil
+}
+
+// Download returns a finished export to its owner, or to anyone holding the
+// link token. userID is 0 for requests without a session.
+func (s *Service) Download(userID int64, id uuid.UUID, token string) (*Job, []byte, error) {
+	job, err := s.repo.GetJob(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job == nil || s.now().After(job.ExpiresAt) {
+		return nil, nil, ErrJobNotFound
+	}
+
+	owner := userID != 0 && job.UserID == userID
+	tokenValid := token != "" && subtle.ConstantTimeCompare([]byte(job.TokenHash), []byte(hashToken(token))) == 1
+	if !owner && !tokenValid {
+		return nil, nil, ErrJobNotFound
+	}
+
+	if job.Status != StatusDone {
+		return nil, nil, ErrJobNotReady
+	}
+
+	content, err := s.repo.GetFile(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content == nil {
+		return nil, nil, ErrJobNotFound
+	}
+
+	return job, content, nil
+}
+
+func (s *Service) decorate(job *Job) {
+	if job.Status == StatusDone {
+		job.DownloadURL = s.downloadURL(job.ID, "")
+	}
+}
+
+func (s *Service) downloadURL(id uuid.UUID, token string) string {
+	params := url.Values{}
+	params.Set("id", id.String())
+	if token != "" {
+		params.Set("token", token)
+	}
+	return s.publicURL + "/api/exports/download/?" + params.Encode()
+}
+
+// FileName is the attachment name offered for a job's file.
+func FileName(job *Job) string {
+	name := fmt.Sprintf("conversations-%s-%s-%s", sanitizeFileName(job.ClientID), job.DateFrom, job.DateTo)
+	if job.ChatID != "" {
+		name += "-" + sanitizeFileName(job.ChatID)
+	}
+	return name + "." + string(job.Format)
+}
+
+func ContentType(format Format) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func sanitizeFileName(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
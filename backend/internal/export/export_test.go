@@ -0,0 +1,266 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRepository struct {
+	clients map[string]int64
+	rows    []*Row
+	jobs    map[uuid.UUID]*Job
+	files   map[uuid.UUID][]byte
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		clients: map[string]int64{"client-1": 7},
+		jobs:    make(map[uuid.UUID]*Job),
+		files:   make(map[uuid.UUID][]byte),
+	}
+}
+
+func (m *memoryRepository) ClientBelongsToUser(userID int64, clientID string) (bool, error) {
+	return m.clients[clientID] == userID, nil
+}
+
+func (m *memoryRepository) matching(filter Filter) []*Row {
+	var rows []*Row
+	for _, row := range m.rows {
+		if row.Created.Before(filter.From) || !row.Created.Before(filter.To) {
+			continue
+		}
+		if filter.ChatID != "" && row.ChatID != filter.ChatID {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (m *memoryRepository) CountRows(filter Filter) (int, error) { return len(m.matching(filter)), nil }
+
+func (m *memoryRepository) ListRows(filter Filter, limit int) ([]*Row, error) {
+	rows := m.matching(filter)
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (m *memoryRepository) CreateJob(job *Job) error {
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *memoryRepository) ClaimJob(now, staleBefore time.Time) (*Job, error) {
+	for _, job := range m.jobs {
+		if job.Status == StatusPending {
+			job.Status = StatusRunning
+			job.StartedAt = &now
+			copied := *job
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepository) CompleteJob(id uuid.UUID, rows int, content []byte, finishedAt time.Time) error {
+	m.files[id] = content
+	m.jobs[id].Status = StatusDone
+	m.jobs[id].Rows = rows
+	return nil
+}
+
+func (m *memoryRepository) FailJob(id uuid.UUID, message string, finishedAt time.Time) error {
+	m.jobs[id].Status = StatusFailed
+	m.jobs[id].Error = message
+	return nil
+}
+
+func (m *memoryRepository) SetTokenHash(id uuid.UUID, tokenHash string) error {
+	m.jobs[id].TokenHash = tokenHash
+	return nil
+}
+
+func (m *memoryRepository) GetJob(id uuid.UUID) (*Job, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *memoryRepository) ListJobs(userID int64) ([]*Job, error) { return nil, nil }
+
+func (m *memoryRepository) GetFile(id uuid.UUID) ([]byte, error) { return m.files[id], nil }
+
+func (m *memoryRepository) DeleteExpired(now time.Time) (int64, error) { return 0, nil }
+
+type recordingNotifier struct {
+	chatID int64
+	texts  []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, chatID int64, text string) error {
+	n.chatID = chatID
+	n.texts = append(n.texts, text)
+	return nil
+}
+
+func newTestService() (*Service, *memoryRepository, *recordingNotifier) {
+	repo := newMemoryRepository()
+	day := time.Date(2026, 3, 10, 9, 0, 0, 0, exportLocation)
+	repo.rows = []*Row{
+		{Created: day, ChatID: "chat-1", ItemID: 2001, BuyerName: "Иван", Direction: "in", Type: "text", Text: "Доставка в Казань?\nИ <срочно>"},
+		{Created: day.Add(time.Hour), ChatID: "chat-2", ItemID: 2002, Direction: "out", Type: "text", Text: "Да"},
+	}
+
+	notifier := &recordingNotifier{}
+	service := NewService(repo, notifier, "https://example.com/")
+	service.now = func() time.Time { return time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC) }
+	return service, repo, notifier
+}
+
+func TestPrepare(t *testing.T) {
+	service, _, _ := newTestService()
+
+	req := Request{ClientID: "client-1"}
+	filter, err := service.Prepare(7, &req)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if req.Format != FormatCSV || req.DateFrom != "2026-02-19" || req.DateTo != "2026-03-20" {
+		t.Fatalf("defaults not applied: %+v", req)
+	}
+	if got := filter.To.Sub(filter.From); got != 30*24*time.Hour {
+		t.Fatalf("filter spans %v, want 30 days", got)
+	}
+
+	if _, err := service.Prepare(8, &Request{ClientID: "client-1"}); err != ErrClientNotFound {
+		t.Fatalf("foreign client: err = %v", err)
+	}
+	if _, err := service.Prepare(7, &Request{ClientID: "client-1", Format: "pdf"}); err != ErrInvalidFormat {
+		t.Fatalf("bad format: err = %v", err)
+	}
+	if _, err := service.Prepare(7, &Request{ClientID: "client-1", DateFrom: "2024-01-01", DateTo: "2026-01-01"}); err != ErrInvalidRange {
+		t.Fatalf("long range: err = %v", err)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	service, _, notifier := newTestService()
+
+	job, err := service.CreateJob(7, Request{ClientID: "client-1", Format: FormatJSONL, ChatID: "chat-1"}, 555)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if !strings.HasPrefix(job.DownloadURL, "https://example.com/api/exports/download/?") {
+		t.Fatalf("download url = %q", job.DownloadURL)
+	}
+
+	if _, _, err := service.Download(0, job.ID, tokenFrom(t, job.DownloadURL)); err != ErrJobNotReady {
+		t.Fatalf("download before processing: err = %v", err)
+	}
+
+	processed, err := service.ProcessNext(context.Background())
+	if err != nil || !processed {
+		t.Fatalf("ProcessNext = %v, %v", processed, err)
+	}
+
+	if notifier.chatID != 555 || len(notifier.texts) != 1 || !strings.Contains(notifier.texts[0], "1 сообщений") {
+		t.Fatalf("notification = %d %q", notifier.chatID, notifier.texts)
+	}
+
+	// The chat link replaced the token handed out on creation.
+	if _, _, err := service.Download(0, job.ID, tokenFrom(t, job.DownloadURL)); err != ErrJobNotFound {
+		t.Fatalf("old token: err = %v", err)
+	}
+
+	linkStart := strings.Index(notifier.texts[0], "https://")
+	_, content, err := service.Download(0, job.ID, tokenFrom(t, notifier.texts[0][linkStart:]))
+	if err != nil {
+		t.Fatalf("Download with token: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"text":"Доставка в Казань?\nИ <срочно>"`) {
+		t.Fatalf("jsonl = %s", content)
+	}
+
+	if _, _, err := service.Download(7, job.ID, ""); err != nil {
+		t.Fatalf("owner download: %v", err)
+	}
+	if _, _, err := service.Download(8, job.ID, "wrong"); err != ErrJobNotFound {
+		t.Fatalf("stranger download: err = %v", err)
+	}
+
+	if processed, _ := service.ProcessNext(context.Background()); processed {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func tokenFrom(t *testing.T, link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse %q: %v", link, err)
+	}
+	return parsed.Query().Get("token")
+}
+
+func TestWriters(t *testing.T) {
+	service, repo, _ := newTestService()
+	filter := Filter{ClientID: "client-1", From: time.Date(2026, 3, 1, 0, 0, 0, 0, exportLocation), To: time.Date(2026, 4, 1, 0, 0, 0, 0, exportLocation)}
+
+	content, rows, err := service.Generate(filter, FormatCSV)
+	if err != nil || rows != 2 {
+		t.Fatalf("Generate csv = %d rows, %v", rows, err)
+	}
+	if !bytes.HasPrefix(content, []byte("\uFEFFcreated_at,chat_id")) {
+		t.Fatalf("csv header = %q", content[:40])
+	}
+
+	content, _, err = service.Generate(filter, FormatXLSX)
+	if err != nil {
+		t.Fatalf("Generate xlsx: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("xlsx is not a zip: %v", err)
+	}
+	var sheet string
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, _ := file.Open()
+			data, _ := io.ReadAll(reader)
+			sheet = string(data)
+		}
+	}
+	if !strings.Contains(sheet, `<c r="C2"><v>2001</v></c>`) || !strings.Contains(sheet, "&lt;срочно&gt;") {
+		t.Fatalf("unexpected sheet: %s", sheet)
+	}
+
+	repo.rows = make([]*Row, MaxRows+1)
+	for i := range repo.rows {
+		repo.rows[i] = &Row{Created: filter.From}
+	}
+	if _, _, err := service.Generate(filter, FormatCSV); err != ErrTooManyRows {
+		t.Fatalf("oversized export: err = %v", err)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 8: "I", 25: "Z", 26: "AA", 27: "AB"} {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", index, got, want)
+		}
+	}
+}
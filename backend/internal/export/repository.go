@@ -0,0 +1,306 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mini-app-backend/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type SQLRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db:      db,
+		dialect: database.DialectOf(db),
+	}
+}
+
+const jobColumns = `id, user_id, client_id, format, date_from, date_to, chat_id, status, error, rows, size, token_hash, notify_chat_id, expires_at, created_at, started_at, finished_at`
+
+func scanJob(scan func(dest ...interface{}) error) (*Job, error) {
+	job := &Job{}
+	var startedAt, finishedAt sql.NullTime
+	err := scan(
+		&job.ID,
+		&job.UserID,
+		&job.ClientID,
+		&job.Format,
+		&job.DateFrom,
+		&job.DateTo,
+		&job.ChatID,
+		&job.Status,
+		&job.Error,
+		&job.Rows,
+		&job.Size,
+		&job.TokenHash,
+		&job.NotifyChatID,
+		&job.ExpiresAt,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return job, nil
+}
+
+func (r *SQLRepository) ClientBelongsToUser(userID int64, clientID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM clients WHERE user_id = $1 AND client_id = $2)`, userID, clientID).Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking client owner: %v", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func rowsFilter(filter Filter) (string, []interface{}) {
+	args := []interface{}{filter.ClientID, filter.From.Unix(), filter.To.Unix()}
+	conditions := []string{"s.client_id = $1", "m.created >= $2", "m.created < $3"}
+
+	if filter.ChatID != "" {
+		args = append(args, filter.ChatID)
+		conditions = append(conditions, fmt.Sprintf("m.chat_id = $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *SQLRepository) CountRows(filter Filter) (int, error) {
+	where, args := rowsFilter(filter)
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM avito_messages m
+		JOIN avito_sync_state s ON s.avito_user_id = m.avito_user_id
+		WHERE %s
+	`, where)
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		log.Printf("Error counting export rows: %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *SQLRepository) ListRows(filter Filter, limit int) ([]*Row, error) {
+	where, args := rowsFilter(filter)
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT m.created, m.chat_id, COALESCE(c.item_id, 0),
+			COALESCE(c.data->'context'->'value'->>'title', ''),
+			COALESCE((%s), ''),
+			m.direction, m.author_id, m.type, m.text
+		FROM avito_messages m
+		JOIN avito_sync_state s ON s.avito_user_id = m.avito_user_id
+		LEFT JOIN avito_chats c ON c.avito_user_id = m.avito_user_id AND c.id = m.chat_id
+		WHERE %s
+		ORDER BY m.created, m.chat_id, m.id
+		LIMIT $%d
+	`, database.BuyerName(r.dialect), where, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Error listing export rows: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Row
+	for rows.Next() {
+		row := &Row{}
+		var created int64
+		err := rows.Scan(&created, &row.ChatID, &row.ItemID, &row.ItemTitle, &row.BuyerName,
+			&row.Direction, &row.AuthorID, &row.Type, &row.Text)
+		if err != nil {
+			log.Printf("Error scanning export row: %v", err)
+			return nil, err
+		}
+		row.Created = time.Unix(created, 0)
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *SQLRepository) CreateJob(job *Job) error {
+	query := `
+		INSERT INTO export_jobs (` + jobColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+
+	_, err := r.db.Exec(query,
+		job.ID, job.UserID, job.ClientID, job.Format, job.DateFrom, job.DateTo, job.ChatID,
+		job.Status, job.Error, job.Rows, job.Size, job.TokenHash, job.NotifyChatID,
+		job.ExpiresAt, job.CreatedAt, job.StartedAt, job.FinishedAt,
+	)
+	if err != nil {
+		log.Printf("Error creating export job: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimJob takes the oldest pending job, or a running one whose worker
+// stopped before staleBefore. SKIP LOCKED keeps concurrent workers apart; SQLite
+// has no row locks but runs one writer at a time, which does the same.
+func (r *SQLRepository) ClaimJob(now, staleBefore time.Time) (*Job, error) {
+	lock := "FOR UPDATE SKIP LOCKED"
+	if r.dialect == database.SQLite {
+		lock = ""
+	}
+
+	query := `
+		UPDATE export_jobs SET status = 'running', started_at = $1
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $2)
+			ORDER BY created_at
+			LIMIT 1
+			` + lock + `
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(query, now, staleBefore).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error claiming export job: %v", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *SQLRepository) CompleteJob(id uuid.UUID, rows int, content []byte, finishedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("Error starting export transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO export_files (job_id, content) VALUES ($1, $2)
+		ON CONFLICT (job_id) DO UPDATE SET content = EXCLUDED.content
+	`, id, content)
+	if err != nil {
+		log.Printf("Error saving export file: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE export_jobs SET status = 'done', rows = $2, size = $3, error = '', finished_at = $4
+		WHERE id = $1
+	`, id, rows, len(content), finishedAt)
+	if err != nil {
+		log.Printf("Error completing export job: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLRepository) FailJob(id uuid.UUID, message string, finishedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE export_jobs SET status = 'failed', error = $2, finished_at = $3 WHERE id = $1`, id, message, finishedAt)
+	if err != nil {
+		log.Printf("Error failing export job: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) SetTokenHash(id uuid.UUID, tokenHash string) error {
+	_, err := r.db.Exec(`UPDATE export_jobs SET token_hash = $2 WHERE id = $1`, id, tokenHash)
+	if err != nil {
+		log.Printf("Error updating export token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetJob(id uuid.UUID) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM export_jobs WHERE id = $1`
+
+	job, err := scanJob(r.db.QueryRow(query, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting export job: %v", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *SQLRepository) ListJobs(userID int64) ([]*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM export_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT 50`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		log.Printf("Error listing export jobs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning export job: %v", err)
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *SQLRepository) GetFile(id uuid.UUID) ([]byte, error) {
+	var content []byte
+	err := r.db.QueryRow(`SELECT content FROM export_files WHERE job_id = $1`, id).Scan(&content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting export file: %v", err)
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (r *SQLRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM export_jobs WHERE expires_at < $1`, now)
+	if err != nil {
+		log.Printf("Error deleting expired exports: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var columns = []string{"created_at", "chat_id", "item_id", "item_title", "buyer_name", "direction", "author_id", "type", "text"}
+
+func rowValues(row *Row) []string {
+	return []string{
+		row.Created.In(exportLocation).Format(time.RFC3339),
+		row.ChatID,
+		strconv.FormatInt(row.ItemID, 10),
+		row.ItemTitle,
+		row.BuyerName,
+		row.Direction,
+		strconv.FormatInt(row.AuthorID, 10),
+		row.Type,
+		row.Text,
+	}
+}
+
+// writeCSV starts with a UTF-8 BOM so Excel opens Cyrillic text correctly.
+func writeCSV(rows []*Row) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(rowValues(row)); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+type jsonRow struct {
+	CreatedAt string `json:"created_at"`
+	ChatID    string `json:"chat_id"`
+	ItemID    int64  `json:"item_id"`
+	ItemTitle string `json:"item_title"`
+	BuyerName string `json:"buyer_name"`
+	Direction string `json:"direction"`
+	AuthorID  int64  `json:"author_id"`
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+}
+
+func writeJSONL(rows []*Row) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	for _, row := range rows {
+		err := encoder.Encode(jsonRow{
+			CreatedAt: row.Created.In(exportLocation).Format(time.RFC3339),
+			ChatID:    row.ChatID,
+			ItemID:    row.ItemID,
+			ItemTitle: row.ItemTitle,
+			BuyerName: row.BuyerName,
+			Direction: row.Direction,
+			AuthorID:  row.AuthorID,
+			Type:      row.Type,
+			Text:      row.Text,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Messages" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+)
+
+// writeXLSX produces a single-sheet workbook with inline strings, which every
+// spreadsheet application reads without shared strings or styles.
+func writeXLSX(rows []*Row) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeSheetRow(&sheet, 1, columns, nil)
+	for i, row := range rows {
+		writeSheetRow(&sheet, i+2, rowValues(row), map[int]bool{2: true, 6: true})
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRootRels)},
+		{"xl/workbook.xml", []byte(xlsxWorkbook)},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeSheetRow(sheet *bytes.Buffer, index int, values []string, numeric map[int]bool) {
+	fmt.Fprintf(sheet, `<row r="%d">`, index)
+	for column, value := range values {
+		ref := columnName(column) + strconv.Itoa(index)
+		if numeric[column] {
+			fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+			continue
+		}
+		fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		xml.EscapeText(sheet, []byte(stripInvalidXML(value)))
+		sheet.WriteString(`</t></is></c>`)
+	}
+	sheet.WriteString(`</row>`)
+}
+
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// stripInvalidXML drops control characters XML 1.0 cannot carry.
+func stripInvalidXML(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		if r == 0xFFFE || r == 0xFFFF {
+			return -1
+		}
+		return r
+	}, value)
+}
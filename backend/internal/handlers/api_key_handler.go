@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"mini-app-backend/internal/apikey"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type APIKeyHandler struct {
+	*BaseHandler
+	apiKeyService *apikey.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *apikey.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		BaseHandler:   NewBaseHandler(),
+		apiKeyService: apiKeyService,
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type CreateAPIKeyResponse struct {
+	Success bool           `json:"success"`
+	Key     string         `json:"key,omitempty"`
+	APIKey  *apikey.APIKey `json:"api_key,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type GetAPIKeysResponse struct {
+	Success bool             `json:"success"`
+	APIKeys []*apikey.APIKey `json:"api_keys"`
+	Error   string           `json:"error,omitempty"`
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CreateAPIKey request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	err = h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.LogError(r, nil, "name is required")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "name is required"), http.StatusBadRequest)
+		return
+	}
+
+	scopes, err := apikey.ParseScopes(req.Scopes)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Invalid scopes")
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.IssueKey(userID, req.Name, scopes)
+	if err != nil {
+		h.LogError(r, err, "Error creating API key")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error creating API key", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	response := CreateAPIKeyResponse{
+		Success: true,
+		Key:     plaintext,
+		APIKey:  key,
+	}
+
+	h.LogInfo(r, "Successfully created API key")
+	h.SendJSON(w, r, response, http.StatusCreated)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetAPIKeys request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	keys, err := h.apiKeyService.GetKeysByUserID(userID)
+	if err != nil {
+		h.LogError(r, err, "Error getting API keys")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting API keys", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved API keys")
+	h.SendJSON(w, r, GetAPIKeysResponse{Success: true, APIKeys: keys}, http.StatusOK)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "RevokeAPIKey request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	keyID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		h.LogError(r, err, "Invalid id")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(userID, keyID); err != nil {
+		h.SendAccessError(w, r, err, "Error revoking API key")
+		return
+	}
+
+	h.LogInfo(r, "Successfully revoked API key")
+	h.SendJSON(w, r, map[string]bool{"success": true}, http.StatusOK)
+}
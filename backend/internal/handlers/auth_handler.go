@@ -3,32 +3,38 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"mini-app-backend/internal/auth"
 	"mini-app-backend/internal/config"
 	"mini-app-backend/internal/errors"
 	"mini-app-backend/internal/message"
 	"mini-app-backend/internal/telegram"
 	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/workspace"
 	"net/http"
 	"strconv"
 )
 
 type AuthHandler struct {
 	*BaseHandler
-	userService    *user.UserService
-	messageService *message.MessageService
-	botToken       string
-	db             *sql.DB
-	config         *config.Config
+	authService      *auth.Service
+	userService      *user.UserService
+	messageService   *message.MessageService
+	workspaceService *workspace.WorkspaceService
+	botToken         string
+	db               *sql.DB
+	config           *config.Config
 }
 
-func NewAuthHandler(userService *user.UserService, messageService *message.MessageService, botToken string, db *sql.DB, config *config.Config) *AuthHandler {
+func NewAuthHandler(authService *auth.Service, userService *user.UserService, messageService *message.MessageService, workspaceService *workspace.WorkspaceService, botToken string, db *sql.DB, config *config.Config) *AuthHandler {
 	return &AuthHandler{
-		BaseHandler:    NewBaseHandler(),
-		userService:    userService,
-		messageService: messageService,
-		botToken:       botToken,
-		db:             db,
-		config:         config,
+		BaseHandler:      NewBaseHandler(),
+		authService:      authService,
+		userService:      userService,
+		messageService:   messageService,
+		workspaceService: workspaceService,
+		botToken:         botToken,
+		db:               db,
+		config:           config,
 	}
 }
 
@@ -80,44 +86,18 @@ func (h *AuthHandler) TelegramAuth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existingUser, err := h.userService.GetUserByID(req.User.ID)
+	login, err := h.authService.Login(r.Context(), req.User)
 	if err != nil {
-		h.LogError(r, err, "Error checking user existence")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error checking user existence", err.Error()), http.StatusInternalServerError)
+		h.LogError(r, err, "Error signing in user")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error signing in user", err.Error()), http.StatusInternalServerError)
 		return
 	}
-	
-	var user *user.User
-	if existingUser != nil {
-		user = existingUser
-		h.LogInfo(r, "User already exists, logging in")
-	} else {
-		user, err = h.userService.CreateOrUpdateUser(req.User)
-		if err != nil {
-			h.LogError(r, err, "Error creating user")
-			h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error creating user", err.Error()), http.StatusInternalServerError)
-			return
-		}
-
-		_, err = h.messageService.CreateMessage(
-			h.config.AvitoClientId,
-			h.config.AvitoClientSecret,
-			"Ваше сообщение по умолчанию",
-			"Автоответчик",
-		)
-		if err != nil {
-			h.LogError(r, err, "Error creating default message")
-		}
-	}
 
-	_, err = h.messageService.CreateMessage(
-		h.config.AvitoClientId,
-		h.config.AvitoClientSecret,
-		"Ваше сообщение по умолчанию",
-		"Автоответчик",
-	)
-	if err != nil {
-		h.LogError(r, err, "Error creating default message")
+	user := login.User
+	if login.Created {
+		h.LogInfo(r, "Registered new user")
+	} else {
+		h.LogInfo(r, "User already exists, logging in")
 	}
 
 	token := "token_" + strconv.FormatInt(user.ID, 10)
@@ -150,7 +130,7 @@ func (h *AuthHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
 		h.LogError(r, err, "Error getting user")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting user", err.Error()), http.StatusInternalServerError)
@@ -183,7 +163,7 @@ func (h *AuthHandler) GetUserData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userData, err := h.userService.GetUserDataByUserID(userID)
+	userData, err := h.userService.GetUserDataByUserID(r.Context(), userID)
 	if err != nil {
 		h.LogError(r, err, "Error getting user data")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting user data", err.Error()), http.StatusInternalServerError)
@@ -225,7 +205,7 @@ func (h *AuthHandler) SaveUserData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.userService.SaveUserData(userID, string(dataJSON))
+	_, err = h.userService.SaveUserData(r.Context(), userID, string(dataJSON))
 	if err != nil {
 		h.LogError(r, err, "Error saving user data")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error saving user data", err.Error()), http.StatusInternalServerError)
@@ -256,10 +236,14 @@ func (h *AuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := h.GetUserIDFromCookie(r)
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
 	if err != nil {
-		h.LogError(r, err, "Failed to get user ID from cookie")
-		h.SendError(w, r, err, http.StatusBadRequest)
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	if err := access.Require(workspace.RoleEditor); err != nil {
+		h.SendAccessError(w, r, err, "Not allowed to add clients")
 		return
 	}
 
@@ -277,7 +261,7 @@ func (h *AuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := h.userService.CreateClient(userID, req.ClientID, req.ClientSecret)
+	client, err := h.userService.CreateClient(r.Context(), access.UserID, access.WorkspaceID, req.ClientID, req.ClientSecret)
 	if err != nil {
 		h.LogError(r, err, "Error creating client")
 		
@@ -315,10 +299,9 @@ func (h *AuthHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := h.GetUserIDFromCookie(r)
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
 	if err != nil {
-		h.LogError(r, err, "Failed to get user ID from cookie")
-		h.SendError(w, r, err, http.StatusBadRequest)
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
 		return
 	}
 
@@ -348,14 +331,14 @@ func (h *AuthHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 		offset = parsedOffset
 	}
 
-	clients, err := h.userService.GetClientsByUserIDWithPagination(userID, limit, offset)
+	clients, err := h.userService.GetClientsByWorkspaceIDWithPagination(r.Context(), access.WorkspaceID, limit, offset)
 	if err != nil {
 		h.LogError(r, err, "Error getting clients")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting clients", err.Error()), http.StatusInternalServerError)
 		return
 	}
 
-	totalCount, err := h.userService.GetClientsCountByUserID(userID)
+	totalCount, err := h.userService.GetClientsCountByWorkspaceID(r.Context(), access.WorkspaceID)
 	if err != nil {
 		h.LogError(r, err, "Error getting clients count")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting clients count", err.Error()), http.StatusInternalServerError)
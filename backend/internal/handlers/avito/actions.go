@@ -0,0 +1,204 @@
+package avito
+
+import (
+	avitoapi "mini-app-backend/internal/avito"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/itemactions"
+	"net/http"
+	"strconv"
+)
+
+type PriceUpdateRequest struct {
+	ItemID int64 `json:"item_id"`
+	Price  int64 `json:"price"`
+}
+
+type VASRequest struct {
+	ItemID int64  `json:"item_id"`
+	VASID  string `json:"vas_id"`
+}
+
+type ItemActionRequest struct {
+	ActionID string `json:"action_id"`
+}
+
+type ItemActionResponse struct {
+	Success bool                `json:"success"`
+	Action  *itemactions.Action `json:"action,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type ItemActionsResponse struct {
+	Success bool                  `json:"success"`
+	Actions []*itemactions.Action `json:"actions"`
+}
+
+// ProposePriceUpdate records a pending price change. Nothing reaches Avito
+// until the returned action is confirmed.
+func (h *AvitoHandler) ProposePriceUpdate(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "ProposePriceUpdate request")
+
+	var req PriceUpdateRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	r = withUserCredentials(r)
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	action, err := h.actionService.ProposePrice(userID, avitoUserID, req.ItemID, req.Price)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to propose price update")
+		return
+	}
+
+	h.LogInfo(r, "Price update awaiting confirmation")
+	h.SendJSON(w, r, ItemActionResponse{Success: true, Action: action}, http.StatusAccepted)
+}
+
+func (h *AvitoHandler) ProposeVAS(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "ProposeVAS request")
+
+	var req VASRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	r = withUserCredentials(r)
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	action, err := h.actionService.ProposeVAS(userID, avitoUserID, req.ItemID, req.VASID)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to propose promotion")
+		return
+	}
+
+	h.LogInfo(r, "Promotion awaiting confirmation")
+	h.SendJSON(w, r, ItemActionResponse{Success: true, Action: action}, http.StatusAccepted)
+}
+
+func (h *AvitoHandler) ConfirmItemAction(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "ConfirmItemAction request")
+
+	var req ItemActionRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil || req.ActionID == "" {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "action_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	r = withUserCredentials(r)
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	action, err := h.actionService.Confirm(r.Context(), userID, avitoUserID, req.ActionID)
+	if action != nil {
+		// Even a failed call may have changed the listing on Avito's side.
+		h.itemsCache.DeletePrefix(h.client.AccountKey(r.Context()) + "|")
+	}
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to apply item action")
+		return
+	}
+
+	h.LogInfo(r, "Item action applied")
+	h.SendJSON(w, r, ItemActionResponse{Success: true, Action: action}, http.StatusOK)
+}
+
+func (h *AvitoHandler) CancelItemAction(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CancelItemAction request")
+
+	var req ItemActionRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil || req.ActionID == "" {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "action_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	action, err := h.actionService.Cancel(userID, req.ActionID)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to cancel item action")
+		return
+	}
+
+	h.LogInfo(r, "Item action cancelled")
+	h.SendJSON(w, r, ItemActionResponse{Success: true, Action: action}, http.StatusOK)
+}
+
+// GetItemActions returns the audit trail for the current Avito account,
+// optionally narrowed to one item.
+func (h *AvitoHandler) GetItemActions(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetItemActions request")
+
+	queryParams := r.URL.Query()
+
+	var itemID int64
+	if value := queryParams.Get("item_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			h.SendError(w, r, itemactions.ErrInvalidItem, http.StatusBadRequest)
+			return
+		}
+		itemID = parsed
+	}
+
+	limit, _ := strconv.Atoi(queryParams.Get("limit"))
+
+	r = withUserCredentials(r)
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	actions, err := h.actionService.ListActions(userID, avitoUserID, itemID, limit)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get item actions")
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved item actions")
+	h.SendJSON(w, r, ItemActionsResponse{Success: true, Actions: actions}, http.StatusOK)
+}
+
+// withUserCredentials limits r to the user's own OAuth token or client
+// credentials. Listing changes cost money, so the application's credentials
+// must never stand in for a user who has not connected an account.
+func withUserCredentials(r *http.Request) *http.Request {
+	return r.WithContext(avitoapi.RequireUserCredentials(r.Context()))
+}
+
+// resolveActor identifies who is acting and on which Avito account, using the
+// same per-request credentials as every other Avito call.
+func (h *AvitoHandler) resolveActor(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return 0, 0, false
+	}
+
+	account, err := h.client.GetSelf(r.Context())
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get user info")
+		return 0, 0, false
+	}
+
+	return userID, account.ID, true
+}
@@ -0,0 +1,41 @@
+package avito
+
+import (
+	"mini-app-backend/internal/inbox"
+	"net/http"
+	"strconv"
+)
+
+type GetInboxResponse struct {
+	Success  bool             `json:"success"`
+	Chats    []*inbox.Chat    `json:"chats"`
+	Accounts []*inbox.Account `json:"accounts"`
+}
+
+// GetInbox merges chats of every Avito client the user has stored, so the
+// mini app does not have to switch credential cookies between accounts.
+func (h *AvitoHandler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetInbox request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	limit, _ := strconv.Atoi(queryParams.Get("limit"))
+
+	result, err := h.inboxService.GetInbox(r.Context(), userID, limit, queryParams.Get("unread_only") == "true")
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Error getting inbox")
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved inbox")
+	h.SendJSON(w, r, GetInboxResponse{
+		Success:  true,
+		Chats:    result.Chats,
+		Accounts: result.Accounts,
+	}, http.StatusOK)
+}
@@ -2,64 +2,106 @@ package avito
 
 import (
 	"fmt"
+	avitoapi "mini-app-backend/internal/avito"
 	"mini-app-backend/internal/errors"
-	"mini-app-backend/internal/logger"
-	"mini-app-backend/utils"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-var avitoHandler *AvitoHandler
+const (
+	itemsCacheTTL = 30 * time.Second
+	// maxItemPages bounds ?all=true so a huge catalogue cannot pin a request.
+	maxItemPages = 50
+)
 
-func GetItems(w http.ResponseWriter, r *http.Request) {
-	if avitoHandler == nil {
-		avitoHandler = NewAvitoHandler()
+func parseItemsParams(r *http.Request) (avitoapi.ListItemsParams, bool, error) {
+	queryParams := r.URL.Query()
+	params := avitoapi.ListItemsParams{
+		Category:      queryParams.Get("category"),
+		UpdatedAtFrom: queryParams.Get("updatedAtFrom"),
 	}
-	avitoHandler.GetItems(w, r)
-}
 
-func (h *AvitoHandler) GetItems(w http.ResponseWriter, r *http.Request) {
-	h.LogRequest(r, "GetItems request")
+	if value := queryParams.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return params, false, errors.NewAppError(http.StatusBadRequest, "Invalid page")
+		}
+		params.Page = page
+	}
 
-	queryParams := r.URL.Query()
-	category := queryParams.Get("category")
+	if value := queryParams.Get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > avitoapi.MaxItemsPerPage {
+			return params, false, errors.NewAppError(http.StatusBadRequest, fmt.Sprintf("per_page must be between 1 and %d", avitoapi.MaxItemsPerPage))
+		}
+		params.PerPage = perPage
+	}
 
-	if category == "" {
-		category = "338"
+	if value := queryParams.Get("status"); value != "" {
+		var statuses []string
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if !avitoapi.IsValidItemStatus(status) {
+				return params, false, errors.NewAppErrorWithDetails(http.StatusBadRequest, "Invalid status", status)
+			}
+			statuses = append(statuses, status)
+		}
+		params.Status = strings.Join(statuses, ",")
 	}
 
-	baseURL := "https://api.avito.ru/core/v1/items"
-	reqURL := fmt.Sprintf("%s?category=%s", baseURL, category)
+	if params.UpdatedAtFrom != "" {
+		if _, err := time.Parse("2006-01-02", params.UpdatedAtFrom); err != nil {
+			return params, false, errors.NewAppError(http.StatusBadRequest, "updatedAtFrom must be YYYY-MM-DD")
+		}
+	}
+
+	all := queryParams.Get("all") == "true"
+
+	return params, all, nil
+}
+
+func (h *AvitoHandler) GetItems(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetItems request")
 
-	token, err := utils.GetToken(r.Context())
+	params, all, err := parseItemsParams(r)
 	if err != nil {
-		h.LogError(r, err, "Failed to get token")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get token", err.Error()), http.StatusInternalServerError)
+		h.LogError(r, err, "Invalid items query")
+		h.SendError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	h.LogDebug(r, "Token obtained")
+	cacheKey := fmt.Sprintf("%s|%d|%d|%s|%s|%s|%t", h.client.AccountKey(r.Context()),
+		params.Page, params.PerPage, params.Status, params.Category, params.UpdatedAtFrom, all)
 
-	authHeader := token.TokenType + " " + token.AccessToken
+	if items, ok := h.itemsCache.Get(cacheKey); ok {
+		h.LogDebug(r, "Items served from cache")
+		h.SendJSON(w, r, items, http.StatusOK)
+		return
+	}
 
-	resp, err := h.httpClient.Get(r.Context(), reqURL, map[string]string{
-		"Authorization": authHeader,
-	})
+	var items *avitoapi.ItemsResponse
+	if all {
+		items, err = h.client.ListAllItems(r.Context(), params, maxItemPages)
+	} else {
+		items, err = h.client.ListItems(r.Context(), params)
+	}
 	if err != nil {
-		h.LogError(r, err, "Error making request to external API")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error making request to external API", err.Error()), http.StatusInternalServerError)
+		h.SendAvitoError(w, r, err, "Error getting items")
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		h.LogError(r, nil, "External API returned non-OK status")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(resp.StatusCode, "External API returned non-OK status", string(resp.Body)), resp.StatusCode)
-		return
+	if items.Resources == nil {
+		items.Resources = []avitoapi.Item{}
 	}
 
-	requestID := r.Header.Get("X-Request-ID")
-	logger.WithRequestID(requestID).Info("Successfully2 retrieved items")
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(resp.Body)
+	if items.Truncated {
+		h.LogInfo(r, fmt.Sprintf("Items listing stopped after %d pages; continue from page %d", maxItemPages, items.NextPage))
+	}
+
+	h.itemsCache.Set(cacheKey, items)
+
+	h.LogInfo(r, "Successfully retrieved items")
+	h.SendJSON(w, r, items, http.StatusOK)
 }
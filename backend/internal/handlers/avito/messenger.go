@@ -2,145 +2,226 @@ package avito
 
 import (
 	"encoding/json"
-	"fmt"
+	avitoapi "mini-app-backend/internal/avito"
+	"mini-app-backend/internal/chatsync"
 	"mini-app-backend/internal/errors"
-	"mini-app-backend/utils"
+	"mini-app-backend/internal/events"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func (h *AvitoHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
-	h.LogRequest(r, "GetUserInfo request")
-	if avitoHandler == nil {
-		avitoHandler = NewAvitoHandler()
-	}
-	user_id, err := utils.GetUserInfoAvito(r.Context())
+// accountCacheTTL spares the mirror-backed endpoints a live self lookup on
+// every request; the account behind a credential does not change.
+const accountCacheTTL = 10 * time.Minute
+
+type GetMessegesResponse struct {
+	Chats     []json.RawMessage   `json:"chats"`
+	Freshness *chatsync.Freshness `json:"freshness"`
+}
+
+// GetMesseges serves chats from the Postgres mirror. The first request for an
+// account syncs inline; a stale mirror is served as is and refreshed in the
+// background.
+func (h *AvitoHandler) GetMesseges(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetMesseges request")
+
+	account, err := h.cachedAccount(r)
 	if err != nil {
-		h.LogError(r, err, "Failed to get user info")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get user info", err.Error()), http.StatusInternalServerError)
+		h.SendAvitoError(w, r, err, "Failed to get user info")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	filter := chatsync.ChatFilter{
+		UnreadOnly: queryParams.Get("unread_only") == "true",
+	}
+	filter.Limit, _ = strconv.Atoi(queryParams.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(queryParams.Get("offset"))
+	for _, value := range strings.Split(queryParams.Get("item_ids"), ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			filter.ItemIDs = append(filter.ItemIDs, id)
+		}
+	}
+
+	clientID, _ := r.Context().Value("avito_client_id").(string)
+
+	if _, err := h.chatSync.EnsureMirror(r.Context(), clientID, account.ID); err != nil {
+		h.SendAvitoError(w, r, err, "Error syncing chats")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	
-	jsonData, err := json.Marshal(user_id)
+	chats, freshness, err := h.chatSync.ListChats(account.ID, filter)
 	if err != nil {
-		h.LogError(r, err, "Failed to marshal user info")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to marshal user info", err.Error()), http.StatusInternalServerError)
+		h.SendAvitoError(w, r, err, "Error getting chats")
 		return
 	}
-	
-	w.Write(jsonData)
+
+	h.LogInfo(r, "Successfully retrieved chats")
+	h.SendJSON(w, r, GetMessegesResponse{Chats: chats, Freshness: freshness}, http.StatusOK)
 }
 
-func GetMesseges(w http.ResponseWriter, r *http.Request) {
-	if avitoHandler == nil {
-		avitoHandler = NewAvitoHandler()
+func (h *AvitoHandler) cachedAccount(r *http.Request) (*avitoapi.Account, error) {
+	key := h.client.AccountKey(r.Context())
+	if account, ok := h.accountCache.Get(key); ok {
+		return account, nil
 	}
-	avitoHandler.GetMesseges(w, r)
-}
 
-func GetUserInfo(w http.ResponseWriter, r *http.Request) {
-	if avitoHandler == nil {
-		avitoHandler = NewAvitoHandler()
+	account, err := h.client.GetSelf(r.Context())
+	if err != nil {
+		return nil, err
 	}
-	avitoHandler.GetUserInfo(w, r)
+
+	h.accountCache.Set(key, account)
+	return account, nil
 }
 
-func SendMessege(w http.ResponseWriter, r *http.Request) {
-	if avitoHandler == nil {
-		avitoHandler = NewAvitoHandler()
-	}
-	avitoHandler.SendMessege(w, r)
+type GetChatMessagesResponse struct {
+	Messages []avitoapi.Message `json:"messages"`
 }
 
-func (h *AvitoHandler) GetMesseges(w http.ResponseWriter, r *http.Request) {
-	h.LogRequest(r, "GetMesseges request")
+func (h *AvitoHandler) GetChatMessages(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetChatMessages request")
 
-	user_id, err := utils.GetUserInfoAvito(r.Context())
-	if err != nil {
-		h.LogError(r, err, "Failed to get user info")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get user info", err.Error()), http.StatusInternalServerError)
+	if _, err := h.GetUserIDFromCookie(r); err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
 		return
 	}
+	// Chat history and read state belong to the user's own account, never to
+	// the application's.
+	r = r.WithContext(avitoapi.RequireUserCredentials(r.Context()))
 
-	reqURL := fmt.Sprintf("https://api.avito.ru/messenger/v2/accounts/%d/chats", user_id.Id)
-
-	token, err := utils.GetToken(r.Context())
-	if err != nil {
-		h.LogError(r, err, "Failed to get token")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get token", err.Error()), http.StatusInternalServerError)
+	queryParams := r.URL.Query()
+	chatID := queryParams.Get("chat_id")
+	if chatID == "" {
+		h.LogError(r, nil, "Missing required parameters")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "chat_id is required"), http.StatusBadRequest)
 		return
 	}
 
-	h.LogDebug(r, "Token obtained")
+	limit, _ := strconv.Atoi(queryParams.Get("limit"))
+	offset, _ := strconv.Atoi(queryParams.Get("offset"))
 
-	authHeader := token.TokenType + " " + token.AccessToken
-
-	h.LogDebug(r, fmt.Sprintf(("authToken: %s"), authHeader))
-
-	resp, err := h.httpClient.Get(r.Context(), reqURL, map[string]string{
-		"Authorization": authHeader,
-	})
+	account, err := h.client.GetSelf(r.Context())
 	if err != nil {
-		h.LogError(r, err, "Error making request to external API")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error making request to external API", err.Error()), http.StatusInternalServerError)
+		h.SendAvitoError(w, r, err, "Failed to get user info")
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		h.LogError(r, nil, "External API returned non-OK status")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(resp.StatusCode, "External API returned non-OK status", string(resp.Body)), resp.StatusCode)
+	messages, err := h.client.ListMessages(r.Context(), account.ID, chatID, limit, offset)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Error getting messages")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(resp.Body)
+	h.LogInfo(r, "Successfully retrieved chat messages")
+	h.SendJSON(w, r, GetChatMessagesResponse{Messages: messages}, http.StatusOK)
+}
+
+type DeliveryFailedEvent struct {
+	AvitoUserID int64  `json:"avito_user_id"`
+	ChatID      string `json:"chat_id"`
+	Text        string `json:"text"`
+	Error       string `json:"error"`
+}
+
+type SendMessegeRequest struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
 }
 
 func (h *AvitoHandler) SendMessege(w http.ResponseWriter, r *http.Request) {
 	h.LogRequest(r, "SendMessege request")
 
-	queryParams := r.URL.Query()
-	userId := queryParams.Get("user_id")
-	chatId := queryParams.Get("chat_id")
+	var req SendMessegeRequest
+	err := h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
 
-	if userId == "" || chatId == "" {
+	if req.ChatID == "" || strings.TrimSpace(req.Text) == "" {
 		h.LogError(r, nil, "Missing required parameters")
 		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Missing required parameters"), http.StatusBadRequest)
 		return
 	}
 
-	reqURL := fmt.Sprintf("https://api.avito.ru/core/v1/messenger/chats/%s/messages/%s", userId, chatId)
+	account, err := h.client.GetSelf(r.Context())
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get user info")
+		return
+	}
+
+	message, err := h.client.SendMessage(r.Context(), account.ID, req.ChatID, req.Text)
+	if err != nil {
+		// Other open sessions of the user learn about the failure too.
+		if userID, userErr := h.GetUserIDFromCookie(r); userErr == nil {
+			h.events.Publish(userID, events.TypeDeliveryFailed, DeliveryFailedEvent{
+				AvitoUserID: account.ID,
+				ChatID:      req.ChatID,
+				Text:        req.Text,
+				Error:       "Avito did not accept the message",
+			})
+		}
+		h.SendAvitoError(w, r, err, "Error sending message")
+		return
+	}
+
+	h.recordReply(r, account.ID, req.ChatID)
+
+	h.LogInfo(r, "Successfully sent message")
+	h.SendJSON(w, r, message, http.StatusOK)
+}
+
+func (h *AvitoHandler) MarkChatRead(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "MarkChatRead request")
 
-	token, err := utils.GetToken(r.Context())
+	if _, err := h.GetUserIDFromCookie(r); err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+	// Chat history and read state belong to the user's own account, never to
+	// the application's.
+	r = r.WithContext(avitoapi.RequireUserCredentials(r.Context()))
+
+	chatID := r.URL.Query().Get("chat_id")
+	if chatID == "" {
+		h.LogError(r, nil, "Missing required parameters")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "chat_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.client.GetSelf(r.Context())
 	if err != nil {
-		h.LogError(r, err, "Failed to get token")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get token", err.Error()), http.StatusInternalServerError)
+		h.SendAvitoError(w, r, err, "Failed to get user info")
 		return
 	}
 
-	h.LogDebug(r, "Token obtained")
+	if err := h.client.MarkChatRead(r.Context(), account.ID, chatID); err != nil {
+		h.SendAvitoError(w, r, err, "Error marking chat as read")
+		return
+	}
 
-	authHeader := token.TokenType + " " + token.AccessToken
+	h.LogInfo(r, "Successfully marked chat as read")
+	h.SendJSON(w, r, map[string]bool{"success": true}, http.StatusOK)
+}
 
-	resp, err := h.httpClient.Get(r.Context(), reqURL, map[string]string{
-		"Authorization": authHeader,
-	})
+// recordReply feeds the per-item reply analytics. The message is already sent,
+// so failures here are only logged.
+func (h *AvitoHandler) recordReply(r *http.Request, avitoUserID int64, chatID string) {
+	chat, err := h.client.GetChat(r.Context(), avitoUserID, chatID)
 	if err != nil {
-		h.LogError(r, err, "Error making request to external API")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error making request to external API", err.Error()), http.StatusInternalServerError)
+		h.LogError(r, err, "Failed to resolve chat item for reply analytics")
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		h.LogError(r, nil, "External API returned non-OK status")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(resp.StatusCode, "External API returned non-OK status", string(resp.Body)), resp.StatusCode)
+	if chat.Context.Value.ID == 0 {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(resp.Body)
+	if err := h.statsService.RecordReply(avitoUserID, chat.Context.Value.ID, chatID); err != nil {
+		h.LogError(r, err, "Failed to record reply")
+	}
 }
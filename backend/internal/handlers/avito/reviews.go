@@ -0,0 +1,314 @@
+package avito
+
+import (
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/reviews"
+	"net/http"
+	"strconv"
+)
+
+type ReviewsResponse struct {
+	Success bool              `json:"success"`
+	Reviews []*reviews.Review `json:"reviews"`
+	Total   int               `json:"total"`
+	Stale   bool              `json:"stale,omitempty"`
+}
+
+type ReviewSyncResponse struct {
+	Success bool                `json:"success"`
+	Result  *reviews.SyncResult `json:"result"`
+}
+
+type AnswerReviewRequest struct {
+	ReviewID int64  `json:"review_id"`
+	Text     string `json:"text"`
+}
+
+type ReviewResponse struct {
+	Success bool            `json:"success"`
+	Review  *reviews.Review `json:"review"`
+}
+
+type ReviewTemplateRequest struct {
+	Rating   int    `json:"rating"`
+	Text     string `json:"text"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+type ReviewTemplateResponse struct {
+	Success  bool              `json:"success"`
+	Template *reviews.Template `json:"template,omitempty"`
+}
+
+type ReviewTemplatesResponse struct {
+	Success   bool                `json:"success"`
+	Templates []*reviews.Template `json:"templates"`
+}
+
+type ReviewSettingsRequest struct {
+	AutoAnswer     bool `json:"auto_answer"`
+	NotifyNegative bool `json:"notify_negative"`
+}
+
+type ReviewSettingsResponse struct {
+	Success  bool              `json:"success"`
+	Settings *reviews.Settings `json:"settings"`
+}
+
+func parseReviewFilter(r *http.Request) (reviews.Filter, error) {
+	queryParams := r.URL.Query()
+	var filter reviews.Filter
+
+	if value := queryParams.Get("score"); value != "" {
+		score, err := strconv.Atoi(value)
+		if err != nil || score < 1 || score > 5 {
+			return filter, reviews.ErrInvalidRating
+		}
+		filter.Score = score
+	}
+
+	if value := queryParams.Get("item_id"); value != "" {
+		itemID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || itemID <= 0 {
+			return filter, errors.NewAppError(http.StatusBadRequest, "Invalid item_id")
+		}
+		filter.ItemID = itemID
+	}
+
+	if value := queryParams.Get("answered"); value != "" {
+		answered, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.NewAppError(http.StatusBadRequest, "answered must be true or false")
+		}
+		filter.Answered = &answered
+	}
+
+	filter.Limit, _ = strconv.Atoi(queryParams.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(queryParams.Get("offset"))
+
+	return filter, nil
+}
+
+// GetReviews serves reviews from the local table, refreshing it first when the
+// last sync is old. A failed refresh still returns the local copy.
+func (h *AvitoHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetReviews request")
+
+	filter, err := parseReviewFilter(r)
+	if err != nil {
+		h.LogError(r, err, "Invalid reviews query")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	stale := false
+	if err := h.reviewService.SyncIfStale(r.Context(), userID, avitoUserID); err != nil {
+		h.LogError(r, err, "Failed to sync reviews, serving local copy")
+		stale = true
+	}
+
+	list, total, err := h.reviewService.ListReviews(avitoUserID, filter)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get reviews")
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved reviews")
+	h.SendJSON(w, r, ReviewsResponse{Success: true, Reviews: list, Total: total, Stale: stale}, http.StatusOK)
+}
+
+func (h *AvitoHandler) SyncReviews(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "SyncReviews request")
+
+	userID, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.reviewService.Sync(r.Context(), userID, avitoUserID)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to sync reviews")
+		return
+	}
+
+	h.LogInfo(r, "Reviews synced")
+	h.SendJSON(w, r, ReviewSyncResponse{Success: true, Result: result}, http.StatusOK)
+}
+
+func (h *AvitoHandler) AnswerReview(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "AnswerReview request")
+
+	var req AnswerReviewRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	_, avitoUserID, ok := h.resolveActor(w, r)
+	if !ok {
+		return
+	}
+
+	review, err := h.reviewService.AnswerReview(r.Context(), avitoUserID, req.ReviewID, req.Text)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to answer review")
+		return
+	}
+
+	h.LogInfo(r, "Review answered")
+	h.SendJSON(w, r, ReviewResponse{Success: true, Review: review}, http.StatusOK)
+}
+
+func (h *AvitoHandler) GetReviewTemplates(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetReviewTemplates request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	templates, err := h.reviewService.GetTemplates(userID)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get review templates")
+		return
+	}
+
+	h.SendJSON(w, r, ReviewTemplatesResponse{Success: true, Templates: templates}, http.StatusOK)
+}
+
+func (h *AvitoHandler) CreateReviewTemplate(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CreateReviewTemplate request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	var req ReviewTemplateRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.reviewService.CreateTemplate(userID, req.Rating, req.Text)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to create review template")
+		return
+	}
+
+	h.LogInfo(r, "Review template created")
+	h.SendJSON(w, r, ReviewTemplateResponse{Success: true, Template: template}, http.StatusCreated)
+}
+
+func (h *AvitoHandler) UpdateReviewTemplate(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "UpdateReviewTemplate request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid template ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req ReviewTemplateRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	template := &reviews.Template{ID: id, UserID: userID, Rating: req.Rating, Text: req.Text, IsActive: true}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := h.reviewService.UpdateTemplate(template); err != nil {
+		h.SendAvitoError(w, r, err, "Failed to update review template")
+		return
+	}
+
+	h.LogInfo(r, "Review template updated")
+	h.SendJSON(w, r, ReviewTemplateResponse{Success: true, Template: template}, http.StatusOK)
+}
+
+func (h *AvitoHandler) DeleteReviewTemplate(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "DeleteReviewTemplate request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid template ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reviewService.DeleteTemplate(userID, id); err != nil {
+		h.SendAvitoError(w, r, err, "Failed to delete review template")
+		return
+	}
+
+	h.LogInfo(r, "Review template deleted")
+	h.SendJSON(w, r, ReviewTemplateResponse{Success: true}, http.StatusOK)
+}
+
+func (h *AvitoHandler) GetReviewSettings(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetReviewSettings request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	settings, err := h.reviewService.GetSettings(userID)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get review settings")
+		return
+	}
+
+	h.SendJSON(w, r, ReviewSettingsResponse{Success: true, Settings: settings}, http.StatusOK)
+}
+
+func (h *AvitoHandler) UpdateReviewSettings(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "UpdateReviewSettings request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	var req ReviewSettingsRequest
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	settings := &reviews.Settings{UserID: userID, AutoAnswer: req.AutoAnswer, NotifyNegative: req.NotifyNegative}
+	if err := h.reviewService.SaveSettings(settings); err != nil {
+		h.SendAvitoError(w, r, err, "Failed to save review settings")
+		return
+	}
+
+	h.LogInfo(r, "Review settings saved")
+	h.SendJSON(w, r, ReviewSettingsResponse{Success: true, Settings: settings}, http.StatusOK)
+}
@@ -0,0 +1,83 @@
+package avito
+
+import (
+	"fmt"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/itemstats"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ItemStatsResponse struct {
+	Success  bool                    `json:"success"`
+	DateFrom string                  `json:"date_from"`
+	DateTo   string                  `json:"date_to"`
+	Items    []*itemstats.ItemSeries `json:"items"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+func parseItemIDs(value string) ([]int64, error) {
+	if value == "" {
+		return nil, errors.NewAppError(http.StatusBadRequest, "item_ids is required")
+	}
+
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, errors.NewAppErrorWithDetails(http.StatusBadRequest, "Invalid item ID", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > itemstats.MaxItemIDs {
+		return nil, errors.NewAppError(http.StatusBadRequest, fmt.Sprintf("At most %d item IDs per request", itemstats.MaxItemIDs))
+	}
+
+	return ids, nil
+}
+
+func (h *AvitoHandler) GetItemStats(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetItemStats request")
+
+	queryParams := r.URL.Query()
+
+	itemIDs, err := parseItemIDs(queryParams.Get("item_ids"))
+	if err != nil {
+		h.LogError(r, err, "Invalid item IDs")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	dateFrom, dateTo, err := h.statsService.ParseRange(queryParams.Get("date_from"), queryParams.Get("date_to"))
+	if err != nil {
+		h.LogError(r, err, "Invalid date range")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.client.GetSelf(r.Context())
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Failed to get user info")
+		return
+	}
+
+	items, err := h.statsService.GetItemStats(r.Context(), account.ID, itemIDs, dateFrom, dateTo)
+	if err != nil {
+		h.SendAvitoError(w, r, err, "Error getting item stats")
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved item stats")
+	h.SendJSON(w, r, ItemStatsResponse{
+		Success:  true,
+		DateFrom: dateFrom.Format("2006-01-02"),
+		DateTo:   dateTo.Format("2006-01-02"),
+		Items:    items,
+	}, http.StatusOK)
+}
@@ -1,59 +1,79 @@
 package avito
 
 import (
+	stderrors "errors"
+	avitoapi "mini-app-backend/internal/avito"
+	"mini-app-backend/internal/chatsync"
 	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/events"
 	"mini-app-backend/internal/handlers"
 	"mini-app-backend/internal/httpclient"
-	"mini-app-backend/internal/logger"
-	"mini-app-backend/utils"
+	"mini-app-backend/internal/inbox"
+	"mini-app-backend/internal/itemactions"
+	"mini-app-backend/internal/itemstats"
+	"mini-app-backend/internal/reviews"
 	"net/http"
 )
 
 type AvitoHandler struct {
 	*handlers.BaseHandler
-	httpClient *httpclient.Client
+	client        *avitoapi.Client
+	statsService  *itemstats.Service
+	actionService *itemactions.Service
+	reviewService *reviews.Service
+	chatSync      *chatsync.Service
+	inboxService  *inbox.Service
+	events        *events.Broker
+	itemsCache    *avitoapi.TTLCache[*avitoapi.ItemsResponse]
+	accountCache  *avitoapi.TTLCache[*avitoapi.Account]
 }
 
-func NewAvitoHandler() *AvitoHandler {
+func NewAvitoHandler(client *avitoapi.Client, statsService *itemstats.Service, actionService *itemactions.Service, reviewService *reviews.Service, chatSync *chatsync.Service, inboxService *inbox.Service, broker *events.Broker) *AvitoHandler {
 	return &AvitoHandler{
-		BaseHandler: handlers.NewBaseHandler(),
-		httpClient: httpclient.NewClient(
-			httpclient.WithLogger(logger.GetLogger()),
-		),
+		BaseHandler:   handlers.NewBaseHandler(),
+		client:        client,
+		statsService:  statsService,
+		actionService: actionService,
+		reviewService: reviewService,
+		chatSync:      chatSync,
+		inboxService:  inboxService,
+		events:        broker,
+		itemsCache:    avitoapi.NewTTLCache[*avitoapi.ItemsResponse](itemsCacheTTL),
+		accountCache:  avitoapi.NewTTLCache[*avitoapi.Account](accountCacheTTL),
 	}
 }
 
-func (h *AvitoHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	h.LogRequest(r, "GetUser request")
+// SendAvitoError keeps Avito's own status code for upstream failures so the
+// frontend can tell a rejected token from a broken request.
+func (h *AvitoHandler) SendAvitoError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	h.LogError(r, err, message)
 
-	reqURL := "https://api.avito.ru/core/v1/accounts/self"
-
-	token, err := utils.GetToken(r.Context())
-	if err != nil {
-		h.LogError(r, err, "Failed to get token")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Failed to get token", err.Error()), http.StatusInternalServerError)
-		return
+	var apiErr *avitoapi.APIError
+	switch {
+	case httpclient.IsCircuitOpen(err):
+		h.SendError(w, r, errors.ErrServiceUnavailable, http.StatusServiceUnavailable)
+	case stderrors.As(err, &apiErr):
+		h.SendError(w, r, errors.NewAppErrorWithDetails(apiErr.StatusCode, "External API returned non-OK status", apiErr.Body), apiErr.StatusCode)
+	case stderrors.Is(err, avitoapi.ErrNoCredentials):
+		h.SendError(w, r, errors.NewAppError(http.StatusUnauthorized, "Avito account is not connected"), http.StatusUnauthorized)
+	default:
+		if appErr, ok := err.(*errors.AppError); ok {
+			h.SendError(w, r, appErr, appErr.Code)
+			return
+		}
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, message, err.Error()), http.StatusInternalServerError)
 	}
+}
 
-	h.LogDebug(r, "Token obtained")
-
-	authHeader := token.TokenType + " " + token.AccessToken
+func (h *AvitoHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetUserInfo request")
 
-	resp, err := h.httpClient.Get(r.Context(), reqURL, map[string]string{
-		"Authorization": authHeader,
-	})
+	account, err := h.client.GetSelf(r.Context())
 	if err != nil {
-		h.LogError(r, err, "Error making request to external API")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error making request to external API", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		h.LogError(r, nil, "External API returned non-OK status")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(resp.StatusCode, "External API returned non-OK status", string(resp.Body)), resp.StatusCode)
+		h.SendAvitoError(w, r, err, "Failed to get user info")
 		return
 	}
 
 	h.LogInfo(r, "Successfully retrieved user data")
-	h.SendJSON(w, r, resp.Body, http.StatusOK)
+	h.SendJSON(w, r, account, http.StatusOK)
 }
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"mini-app-backend/internal/avitoauth"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	avitoStateCookieName = "avito_oauth_state"
+	avitoCallbackPath    = "/api/auth/avito/callback/"
+)
+
+type AvitoOAuthHandler struct {
+	*BaseHandler
+	avitoAuthService *avitoauth.Service
+	returnURL        string
+}
+
+func NewAvitoOAuthHandler(avitoAuthService *avitoauth.Service, returnURL string) *AvitoOAuthHandler {
+	return &AvitoOAuthHandler{
+		BaseHandler:      NewBaseHandler(),
+		avitoAuthService: avitoAuthService,
+		returnURL:        returnURL,
+	}
+}
+
+type AvitoConnectResponse struct {
+	Success bool   `json:"success"`
+	URL     string `json:"url"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Connect redirects to Avito's consent screen. With ?redirect=false the URL is
+// returned as JSON instead, for clients that navigate themselves.
+func (h *AvitoOAuthHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "AvitoConnect request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	authURL, state, err := h.avitoAuthService.AuthorizationURL(userID)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error starting Avito authorization")
+		return
+	}
+
+	// Lax, unlike the session cookie, so it comes back on Avito's top-level redirect.
+	http.SetCookie(w, &http.Cookie{
+		Name:     avitoStateCookieName,
+		Value:    avitoauth.StateBinding(state),
+		Path:     avitoCallbackPath,
+		MaxAge:   int(avitoauth.StateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if r.URL.Query().Get("redirect") == "false" {
+		h.SendJSON(w, r, AvitoConnectResponse{Success: true, URL: authURL}, http.StatusOK)
+		return
+	}
+
+	h.LogInfo(r, "Redirecting to Avito authorization")
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback is reached by the browser redirect from Avito, which carries no
+// session cookie; the user is identified by the stored state instead, and
+// only in the browser whose state cookie matches it.
+func (h *AvitoOAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "AvitoCallback request")
+
+	binding, bindingErr := r.Cookie(avitoStateCookieName)
+	http.SetCookie(w, &http.Cookie{
+		Name:     avitoStateCookieName,
+		Path:     avitoCallbackPath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		h.LogError(r, nil, "Avito authorization denied: "+reason)
+		h.redirectWithResult(w, r, "error", reason)
+		return
+	}
+
+	state := query.Get("state")
+	code := query.Get("code")
+	if state == "" || code == "" {
+		h.LogError(r, nil, "Missing code or state")
+		h.redirectWithResult(w, r, "error", "invalid_request")
+		return
+	}
+
+	if bindingErr != nil || !avitoauth.CheckStateBinding(state, binding.Value) {
+		h.LogError(r, nil, "OAuth state was started in another browser")
+		h.redirectWithResult(w, r, "error", "invalid_state")
+		return
+	}
+
+	connection, err := h.avitoAuthService.Exchange(r.Context(), state, code)
+	if err == avitoauth.ErrInvalidState {
+		h.LogError(r, err, "Invalid or expired OAuth state")
+		h.redirectWithResult(w, r, "error", "invalid_state")
+		return
+	}
+	if err != nil {
+		h.LogError(r, err, "Error exchanging Avito authorization code")
+		h.redirectWithResult(w, r, "error", "exchange_failed")
+		return
+	}
+
+	h.LogInfo(r, "Successfully connected Avito account "+strconv.FormatInt(connection.AvitoUserID, 10))
+	h.redirectWithResult(w, r, "connected", "")
+}
+
+func (h *AvitoOAuthHandler) redirectWithResult(w http.ResponseWriter, r *http.Request, result, reason string) {
+	target, err := url.Parse(h.returnURL)
+	if err != nil {
+		target = &url.URL{Path: "/"}
+	}
+
+	params := target.Query()
+	params.Set("avito", result)
+	if reason != "" {
+		params.Set("reason", reason)
+	}
+	target.RawQuery = params.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+type GetAvitoAccountsResponse struct {
+	Success  bool                    `json:"success"`
+	Accounts []*avitoauth.Connection `json:"accounts"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+func (h *AvitoOAuthHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetAvitoAccounts request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := h.avitoAuthService.GetConnectionsByUserID(userID)
+	if err != nil {
+		h.LogError(r, err, "Error getting Avito accounts")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting Avito accounts", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved Avito accounts")
+	h.SendJSON(w, r, GetAvitoAccountsResponse{Success: true, Accounts: accounts}, http.StatusOK)
+}
+
+func (h *AvitoOAuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "DeleteAvitoAccount request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		h.LogError(r, err, "Invalid id")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.avitoAuthService.DeleteConnection(userID, accountID); err != nil {
+		h.SendAccessError(w, r, err, "Error disconnecting Avito account")
+		return
+	}
+
+	h.LogInfo(r, "Successfully disconnected Avito account")
+	h.SendJSON(w, r, map[string]bool{"success": true}, http.StatusOK)
+}
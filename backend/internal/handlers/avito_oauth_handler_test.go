@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/avitoauth"
+	"mini-app-backend/internal/avitofake"
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/utils"
+)
+
+type memAvitoAuthRepo struct {
+	mu          sync.Mutex
+	states      map[string]*avitoauth.State
+	connections []*avitoauth.Connection
+}
+
+func (r *memAvitoAuthRepo) CreateState(state *avitoauth.State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *state
+	r.states[state.State] = &copied
+	return nil
+}
+
+func (r *memAvitoAuthRepo) ConsumeState(state string) (*avitoauth.State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.states[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.states, state)
+	return stored, nil
+}
+
+func (r *memAvitoAuthRepo) UpsertConnection(connection *avitoauth.Connection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	connection.ID = int64(len(r.connections) + 1)
+	copied := *connection
+	r.connections = append(r.connections, &copied)
+	return nil
+}
+
+func (r *memAvitoAuthRepo) GetConnection(userID, id int64) (*avitoauth.Connection, error) {
+	return nil, nil
+}
+
+func (r *memAvitoAuthRepo) GetLatestConnection(userID int64) (*avitoauth.Connection, error) {
+	return nil, nil
+}
+
+func (r *memAvitoAuthRepo) GetConnectionsByUserID(userID int64) ([]*avitoauth.Connection, error) {
+	return nil, nil
+}
+
+func (r *memAvitoAuthRepo) UpdateConnectionTokens(connection *avitoauth.Connection) error {
+	return nil
+}
+
+func (r *memAvitoAuthRepo) DeleteConnection(userID, id int64) (bool, error) {
+	return false, nil
+}
+
+func (r *memAvitoAuthRepo) ListConnections(afterID int64, limit int) ([]*avitoauth.Connection, error) {
+	return nil, nil
+}
+
+func (r *memAvitoAuthRepo) ReplaceConnectionTokens(id int64, oldAccessToken, oldRefreshToken, accessToken, refreshToken string) (bool, error) {
+	return false, nil
+}
+
+func (r *memAvitoAuthRepo) connectionCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.connections)
+}
+
+type avitoOAuthFixture struct {
+	handler *AvitoOAuthHandler
+	repo    *memAvitoAuthRepo
+	fake    *httptest.Server
+}
+
+func newAvitoOAuthFixture(t *testing.T) *avitoOAuthFixture {
+	t.Helper()
+
+	_, fake := avitofake.NewTestServer(nil)
+	t.Cleanup(fake.Close)
+
+	keyring, err := utils.NewKeyring("test", map[string][]byte{"test": []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	cfg := &config.Config{
+		AvitoClientId:     "fake-client-id",
+		AvitoClientSecret: "fake-client-secret",
+		AvitoOAuthURL:     fake.URL + "/oauth",
+	}
+	client := avito.NewClient(avito.WithBaseURL(fake.URL), avito.WithCredentials(cfg.AvitoClientId, cfg.AvitoClientSecret))
+	repo := &memAvitoAuthRepo{states: make(map[string]*avitoauth.State)}
+
+	return &avitoOAuthFixture{
+		handler: NewAvitoOAuthHandler(avitoauth.NewService(repo, keyring, client, cfg), "https://app.example/"),
+		repo:    repo,
+		fake:    fake,
+	}
+}
+
+// connect starts the flow as userID and returns the state and the cookie the
+// browser received.
+func (f *avitoOAuthFixture) connect(t *testing.T, userID int64) (string, *http.Cookie) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	f.handler.Connect(w, newRequest(http.MethodGet, "/api/auth/avito/connect/", nil, userID))
+	if w.Code != http.StatusFound {
+		t.Fatalf("connect status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse authorization URL: %v", err)
+	}
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == avitoStateCookieName {
+			if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+				t.Errorf("state cookie = %+v, want HttpOnly and SameSite=Lax", cookie)
+			}
+			return location.Query().Get("state"), cookie
+		}
+	}
+	t.Fatal("connect did not set the state cookie")
+	return "", nil
+}
+
+// code has the fake Avito approve the consent screen.
+func (f *avitoOAuthFixture) code(t *testing.T, state string) string {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(f.fake.URL + "/oauth?redirect_uri=" + url.QueryEscape("https://app.example/cb") + "&state=" + state)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	return location.Query().Get("code")
+}
+
+// callback finishes the flow and returns the avito/reason the app is sent to.
+func (f *avitoOAuthFixture) callback(t *testing.T, state, code string, cookie *http.Cookie) (string, string) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/auth/avito/callback/?state="+state+"&code="+code, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	f.handler.Callback(w, r)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback redirect: %v", err)
+	}
+	return location.Query().Get("avito"), location.Query().Get("reason")
+}
+
+func TestAvitoOAuthCallbackConnects(t *testing.T) {
+	f := newAvitoOAuthFixture(t)
+
+	state, cookie := f.connect(t, ownerID)
+	if result, reason := f.callback(t, state, f.code(t, state), cookie); result != "connected" {
+		t.Fatalf("callback = %s (%s), want connected", result, reason)
+	}
+	if f.repo.connectionCount() != 1 || f.repo.connections[0].UserID != ownerID {
+		t.Fatalf("connections = %+v", f.repo.connections)
+	}
+}
+
+func TestAvitoOAuthCallbackRejectsStateFromAnotherBrowser(t *testing.T) {
+	f := newAvitoOAuthFixture(t)
+
+	// The attacker starts the flow and sends the URL to the victim, whose
+	// browser has no state cookie, or one from its own flow.
+	attackerState, _ := f.connect(t, strangerID)
+	_, victimCookie := f.connect(t, ownerID)
+
+	for name, cookie := range map[string]*http.Cookie{"no cookie": nil, "other flow's cookie": victimCookie} {
+		if result, reason := f.callback(t, attackerState, f.code(t, attackerState), cookie); result != "error" || reason != "invalid_state" {
+			t.Errorf("%s: callback = %s (%s), want error (invalid_state)", name, result, reason)
+		}
+	}
+	if n := f.repo.connectionCount(); n != 0 {
+		t.Fatalf("%d accounts connected with a foreign state", n)
+	}
+}
+
+func TestAvitoOAuthCallbackRejectsExpiredState(t *testing.T) {
+	f := newAvitoOAuthFixture(t)
+
+	state, cookie := f.connect(t, ownerID)
+	f.repo.mu.Lock()
+	f.repo.states[state].ExpiresAt = time.Now().Add(-time.Second)
+	f.repo.mu.Unlock()
+
+	if result, reason := f.callback(t, state, f.code(t, state), cookie); result != "error" || reason != "invalid_state" {
+		t.Fatalf("callback = %s (%s), want error (invalid_state)", result, reason)
+	}
+	if n := f.repo.connectionCount(); n != 0 {
+		t.Fatalf("%d accounts connected with an expired state", n)
+	}
+}
+
+func TestAvitoOAuthCallbackRejectsReplayedState(t *testing.T) {
+	f := newAvitoOAuthFixture(t)
+
+	state, cookie := f.connect(t, ownerID)
+	if result, reason := f.callback(t, state, f.code(t, state), cookie); result != "connected" {
+		t.Fatalf("first callback = %s (%s), want connected", result, reason)
+	}
+	if result, reason := f.callback(t, state, f.code(t, state), cookie); result != "error" || reason != "invalid_state" {
+		t.Fatalf("replayed callback = %s (%s), want error (invalid_state)", result, reason)
+	}
+	if n := f.repo.connectionCount(); n != 1 {
+		t.Fatalf("%d connections after a replay, want 1", n)
+	}
+}
@@ -75,8 +75,9 @@ func (h *BaseHandler) SetUserCookie(w http.ResponseWriter, userID int64) {
 	
 	encryptedUserID, err := encryptionUtil.Encrypt(userIDStr)
 	if err != nil {
+		// UserCookie only accepts encrypted sessions, so a plain one would be useless.
 		h.logger.Errorf("Error encrypting user ID: %v", err)
-		encryptedUserID = userIDStr
+		return
 	}
 	
 	h.logger.Infof("Encrypted user ID: %s", encryptedUserID)
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"fmt"
+	"mini-app-backend/internal/events"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	reconnectDelay    = 3 * time.Second
+)
+
+type EventsHandler struct {
+	*BaseHandler
+	broker *events.Broker
+}
+
+func NewEventsHandler(broker *events.Broker) *EventsHandler {
+	return &EventsHandler{
+		BaseHandler: NewBaseHandler(),
+		broker:      broker,
+	}
+}
+
+// Stream sends the user's events as Server-Sent Events. Reconnecting clients
+// resume with Last-Event-ID, or ?last_event_id= where EventSource cannot set
+// headers; a "reset" event tells them the gap can no longer be replayed.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "Events stream request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	controller := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+		h.LogError(r, err, "Failed to clear write deadline")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		h.LogError(r, err, "Streaming is not supported")
+		return
+	}
+
+	subscription := h.broker.Subscribe(userID, lastID)
+	defer subscription.Close()
+
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	if subscription.Reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range subscription.Replay {
+		writeEvent(w, event)
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Dropped for falling behind; the client resumes from the log.
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event *events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/events"
+	"mini-app-backend/internal/middleware"
+)
+
+func readUntil(t *testing.T, reader *bufio.Reader, want string) string {
+	t.Helper()
+	var seen strings.Builder
+	for !strings.Contains(seen.String(), want) {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended before %q; got %q: %v", want, seen.String(), err)
+		}
+		seen.WriteString(line)
+	}
+	return seen.String()
+}
+
+func TestEventsStreamThroughLoggingMiddleware(t *testing.T) {
+	broker := events.NewBroker(nil)
+	handler := NewEventsHandler(broker)
+
+	withUser := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Stream(w, r.WithContext(context.WithValue(r.Context(), "user_id", int64(7))))
+	})
+	server := httptest.NewServer(middleware.Logging(withUser))
+	defer server.Close()
+
+	broker.Publish(7, events.TypeTemplateChanged, map[string]int{"id": 1})
+	missed := broker.Subscribe(7, 0)
+	missed.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Resuming from just before the first event replays it.
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events/", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events/: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readUntil(t, reader, "event: reset")
+	readUntil(t, reader, `data: {"id":1}`)
+
+	// A live event has to arrive without the response being closed, which
+	// only works if the logging wrapper flushes.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.Publish(7, events.TypeMessageReceived, map[string]string{"chat_id": "chat-1"})
+	}()
+	readUntil(t, reader, "event: message.received")
+}
+
+func TestEventsStreamRequiresSession(t *testing.T) {
+	handler := NewEventsHandler(events.NewBroker(nil))
+
+	recorder := httptest.NewRecorder()
+	handler.Stream(recorder, httptest.NewRequest(http.MethodGet, "/api/events/", nil))
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", recorder.Code)
+	}
+}
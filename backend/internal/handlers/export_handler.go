@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/export"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type ExportHandler struct {
+	*BaseHandler
+	exportService *export.Service
+}
+
+func NewExportHandler(exportService *export.Service) *ExportHandler {
+	return &ExportHandler{
+		BaseHandler:   NewBaseHandler(),
+		exportService: exportService,
+	}
+}
+
+type ExportJobResponse struct {
+	Success bool        `json:"success"`
+	Job     *export.Job `json:"job"`
+}
+
+type ExportJobsResponse struct {
+	Success bool          `json:"success"`
+	Jobs    []*export.Job `json:"jobs"`
+}
+
+// CreateExport queues a conversation export. The returned download_url carries
+// a token and works without a session until the export expires.
+func (h *ExportHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CreateExport request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	var req export.Request
+	if err := h.DecodeJSONBody(r, &req); err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.exportService.CreateJob(userID, req, 0)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error creating export")
+		return
+	}
+
+	h.LogInfo(r, "Successfully queued export")
+	h.SendJSON(w, r, ExportJobResponse{Success: true, Job: job}, http.StatusAccepted)
+}
+
+// GetExports returns one export with ?id= or the user's recent exports.
+func (h *ExportHandler) GetExports(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetExports request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	if idParam := r.URL.Query().Get("id"); idParam != "" {
+		id, err := uuid.Parse(idParam)
+		if err != nil {
+			h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid export id"), http.StatusBadRequest)
+			return
+		}
+
+		job, err := h.exportService.GetJob(userID, id)
+		if err != nil {
+			h.SendAccessError(w, r, err, "Error getting export")
+			return
+		}
+
+		h.SendJSON(w, r, ExportJobResponse{Success: true, Job: job}, http.StatusOK)
+		return
+	}
+
+	jobs, err := h.exportService.ListJobs(userID)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error getting exports")
+		return
+	}
+	if jobs == nil {
+		jobs = []*export.Job{}
+	}
+
+	h.LogInfo(r, "Successfully retrieved exports")
+	h.SendJSON(w, r, ExportJobsResponse{Success: true, Jobs: jobs}, http.StatusOK)
+}
+
+// DownloadExport serves the file to its owner or to a holder of the link token.
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "DownloadExport request")
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid export id"), http.StatusBadRequest)
+		return
+	}
+
+	// A missing session is fine here: bot links authenticate with the token.
+	userID, _ := h.GetUserIDFromCookie(r)
+
+	job, content, err := h.exportService.Download(userID, id, r.URL.Query().Get("token"))
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error downloading export")
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(job.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName(job)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+
+	h.LogInfo(r, "Successfully downloaded export")
+}
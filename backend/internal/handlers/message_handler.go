@@ -2,23 +2,29 @@ package handlers
 
 import (
 	"database/sql"
+	"mini-app-backend/internal/authz"
 	"mini-app-backend/internal/errors"
 	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/workspace"
 	"net/http"
 	"strings"
 )
 
 type MessageHandler struct {
 	*BaseHandler
-	messageService *message.MessageService
-	db             *sql.DB
+	messageService   *message.MessageService
+	workspaceService *workspace.WorkspaceService
+	authorizer       *authz.Authorizer
+	db               *sql.DB
 }
 
-func NewMessageHandler(messageService *message.MessageService, db *sql.DB) *MessageHandler {
+func NewMessageHandler(messageService *message.MessageService, workspaceService *workspace.WorkspaceService, authorizer *authz.Authorizer, db *sql.DB) *MessageHandler {
 	return &MessageHandler{
-		BaseHandler:    NewBaseHandler(),
-		messageService: messageService,
-		db:             db,
+		BaseHandler:      NewBaseHandler(),
+		messageService:   messageService,
+		workspaceService: workspaceService,
+		authorizer:       authorizer,
+		db:               db,
 	}
 }
 
@@ -64,8 +70,14 @@ func (h *MessageHandler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	var req CreateMessageRequest
-	err := h.DecodeJSONBody(r, &req)
+	err = h.DecodeJSONBody(r, &req)
 	if err != nil {
 		h.LogError(r, err, "Invalid request body")
 		h.SendError(w, r, err, http.StatusBadRequest)
@@ -78,7 +90,12 @@ func (h *MessageHandler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	message, err := h.messageService.CreateMessage(req.ClientID, req.ClientSecret, req.Message, req.Name)
+	if _, err := h.authorizer.AuthorizeClient(r.Context(), access, req.ClientID, workspace.RoleEditor); err != nil {
+		h.SendAccessError(w, r, err, "Error checking client access")
+		return
+	}
+
+	message, err := h.messageService.CreateMessage(r.Context(), access.WorkspaceID, req.ClientID, req.ClientSecret, req.Message, req.Name)
 	if err != nil {
 		h.LogError(r, err, "error creating message")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "error creating message", err.Error()), http.StatusInternalServerError)
@@ -103,6 +120,12 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	clientID := r.URL.Query().Get("client_id")
 	if clientID == "" {
 		h.LogError(r, nil, "client_id is required")
@@ -110,7 +133,12 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := h.messageService.GetMessagesByClientID(clientID)
+	if _, err := h.authorizer.AuthorizeClient(r.Context(), access, clientID, workspace.RoleViewer); err != nil {
+		h.SendAccessError(w, r, err, "Error checking client access")
+		return
+	}
+
+	messages, err := h.messageService.GetMessagesByClientID(r.Context(), clientID)
 	if err != nil {
 		h.LogError(r, err, "Error getting messages")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting messages", err.Error()), http.StatusInternalServerError)
@@ -135,6 +163,12 @@ func (h *MessageHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	messageID := r.URL.Query().Get("message_id")
 	if messageID == "" {
 		h.LogError(r, nil, "invalid message_id")
@@ -142,16 +176,9 @@ func (h *MessageHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	message, err := h.messageService.GetMessageByID(messageID)
+	message, err := h.authorizer.AuthorizeMessage(r.Context(), access, messageID, workspace.RoleViewer)
 	if err != nil {
-		h.LogError(r, err, "Error getting message")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting message", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	if message == nil {
-		h.LogError(r, nil, "Message not found")
-		h.SendError(w, r, errors.NewAppError(http.StatusNotFound, "Message not found"), http.StatusNotFound)
+		h.SendAccessError(w, r, err, "Error getting message")
 		return
 	}
 
@@ -173,12 +200,18 @@ func (h *MessageHandler) GetMessageByCredentials(w http.ResponseWriter, r *http.
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	var req struct {
 		ClientID     string `json:"client_id"`
 		ClientSecret string `json:"client_secret"`
 	}
 
-	err := h.DecodeJSONBody(r, &req)
+	err = h.DecodeJSONBody(r, &req)
 	if err != nil {
 		h.LogError(r, err, "Invalid request body")
 		h.SendError(w, r, err, http.StatusBadRequest)
@@ -191,7 +224,12 @@ func (h *MessageHandler) GetMessageByCredentials(w http.ResponseWriter, r *http.
 		return
 	}
 
-	message, err := h.messageService.GetMessageByClientCredentials(req.ClientID, req.ClientSecret)
+	if _, err := h.authorizer.AuthorizeClient(r.Context(), access, req.ClientID, workspace.RoleViewer); err != nil {
+		h.SendAccessError(w, r, err, "Error checking client access")
+		return
+	}
+
+	message, err := h.messageService.GetMessageByClientCredentials(r.Context(), req.ClientID, req.ClientSecret)
 	if err != nil {
 		h.LogError(r, err, "Error getting message")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting message", err.Error()), http.StatusInternalServerError)
@@ -222,6 +260,12 @@ func (h *MessageHandler) UpdateMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	messageID := r.URL.Query().Get("message_id")
 	if messageID == "" {
 		h.LogError(r, nil, "invalid message_id")
@@ -230,23 +274,16 @@ func (h *MessageHandler) UpdateMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateMessageRequest
-	err := h.DecodeJSONBody(r, &req)
+	err = h.DecodeJSONBody(r, &req)
 	if err != nil {
 		h.LogError(r, err, "invalid request body")
 		h.SendError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	existingMessage, err := h.messageService.GetMessageByID(messageID)
+	existingMessage, err := h.authorizer.AuthorizeMessage(r.Context(), access, messageID, workspace.RoleEditor)
 	if err != nil {
-		h.LogError(r, err, "Error getting message")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting message", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	if existingMessage == nil {
-		h.LogError(r, nil, "Message not found")
-		h.SendError(w, r, errors.NewAppError(http.StatusNotFound, "Message not found"), http.StatusNotFound)
+		h.SendAccessError(w, r, err, "Error getting message")
 		return
 	}
 
@@ -266,7 +303,7 @@ func (h *MessageHandler) UpdateMessage(w http.ResponseWriter, r *http.Request) {
 		isActive = *req.IsActive
 	}
 
-	updatedMessage, err := h.messageService.UpdateMessage(messageID, messageText, name, isActive)
+	updatedMessage, err := h.messageService.UpdateMessage(r.Context(), messageID, messageText, name, isActive)
 	if err != nil {
 		h.LogError(r, err, "Error updating message")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error updating message", err.Error()), http.StatusInternalServerError)
@@ -291,6 +328,12 @@ func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
 	messageID := r.URL.Query().Get("message_id")
 	if messageID == "" {
 		h.LogError(r, nil, "invalid message_id")
@@ -298,20 +341,12 @@ func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existingMessage, err := h.messageService.GetMessageByID(messageID)
-	if err != nil {
-		h.LogError(r, err, "Error getting message")
-		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting message", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	if existingMessage == nil {
-		h.LogError(r, nil, "Message not found")
-		h.SendError(w, r, errors.NewAppError(http.StatusNotFound, "Message not found"), http.StatusNotFound)
+	if _, err := h.authorizer.AuthorizeMessage(r.Context(), access, messageID, workspace.RoleEditor); err != nil {
+		h.SendAccessError(w, r, err, "Error getting message")
 		return
 	}
 
-	err = h.messageService.DeleteMessage(messageID)
+	err = h.messageService.DeleteMessage(r.Context(), messageID)
 	if err != nil {
 		h.LogError(r, err, "Error deleting message")
 		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error deleting message", err.Error()), http.StatusInternalServerError)
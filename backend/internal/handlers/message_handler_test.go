@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/authz"
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/middleware"
+	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/utils"
+	"mini-app-backend/internal/workspace"
+)
+
+type memWorkspaceRepo struct {
+	mu          sync.Mutex
+	workspaces  []*workspace.Workspace
+	members     []*workspace.Member
+	invitations map[string]*workspace.Invitation
+}
+
+func newMemWorkspaceRepo() *memWorkspaceRepo {
+	return &memWorkspaceRepo{invitations: make(map[string]*workspace.Invitation)}
+}
+
+func (r *memWorkspaceRepo) CreateWorkspace(w *workspace.Workspace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.ID = int64(len(r.workspaces) + 1)
+	copied := *w
+	r.workspaces = append(r.workspaces, &copied)
+	return nil
+}
+
+func (r *memWorkspaceRepo) GetWorkspaceByID(id int64) (*workspace.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.workspaces {
+		if w.ID == id {
+			copied := *w
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memWorkspaceRepo) GetPersonalWorkspace(userID int64) (*workspace.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.workspaces {
+		if w.OwnerID == userID && w.IsPersonal {
+			copied := *w
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memWorkspaceRepo) GetMembershipsByUserID(userID int64) ([]*workspace.Membership, error) {
+	return nil, nil
+}
+
+func (r *memWorkspaceRepo) AddMember(m *workspace.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *m
+	r.members = append(r.members, &copied)
+	return nil
+}
+
+func (r *memWorkspaceRepo) GetMember(workspaceID, userID int64) (*workspace.Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memWorkspaceRepo) GetMembers(workspaceID int64) ([]*workspace.Member, error) {
+	return nil, nil
+}
+
+func (r *memWorkspaceRepo) UpdateMemberRole(workspaceID, userID int64, role workspace.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			m.Role = role
+		}
+	}
+	return nil
+}
+
+func (r *memWorkspaceRepo) RemoveMember(workspaceID, userID int64) error { return nil }
+
+func (r *memWorkspaceRepo) CountOwners(workspaceID int64) (int, error) { return 1, nil }
+
+func (r *memWorkspaceRepo) LockWorkspace(workspaceID int64) error { return nil }
+
+func (r *memWorkspaceRepo) CreateInvitation(inv *workspace.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *inv
+	r.invitations[inv.Token] = &copied
+	return nil
+}
+
+func (r *memWorkspaceRepo) GetInvitationByToken(token string) (*workspace.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv, ok := r.invitations[token]
+	if !ok {
+		return nil, nil
+	}
+	copied := *inv
+	return &copied, nil
+}
+
+func (r *memWorkspaceRepo) AcceptInvitation(token string, userID int64, acceptedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv, ok := r.invitations[token]
+	if !ok || inv.AcceptedBy != nil || !acceptedAt.Before(inv.ExpiresAt) {
+		return false, nil
+	}
+	inv.AcceptedBy = &userID
+	inv.AcceptedAt = &acceptedAt
+	return true, nil
+}
+
+const (
+	ownerID    int64 = 1
+	strangerID int64 = 2
+	viewerID   int64 = 3
+)
+
+type messageHandlerFixture struct {
+	handler    *MessageHandler
+	messages   *message.MemoryRepository
+	workspaces *workspace.WorkspaceService
+	own        *message.Message
+	foreign    *message.Message
+}
+
+func newMessageHandlerFixture(t *testing.T) *messageHandlerFixture {
+	t.Helper()
+
+	userRepo := user.NewMemoryRepository()
+	messageRepo := message.NewMemoryRepository()
+
+	userService := user.NewUserService(userRepo, database.NoTx[user.UserRepository]{Repos: userRepo})
+	messageService := message.NewMessageService(messageRepo)
+	workspaceRepo := newMemWorkspaceRepo()
+	workspaceService := workspace.NewWorkspaceService(workspaceRepo, database.NoTx[workspace.WorkspaceRepository]{Repos: workspaceRepo})
+
+	ownWorkspace, err := workspaceService.EnsurePersonalWorkspace(context.Background(), ownerID, "owner")
+	if err != nil {
+		t.Fatalf("create own workspace: %v", err)
+	}
+	foreignWorkspace, err := workspaceService.EnsurePersonalWorkspace(context.Background(), strangerID, "stranger")
+	if err != nil {
+		t.Fatalf("create foreign workspace: %v", err)
+	}
+
+	for _, id := range []int64{ownerID, strangerID} {
+		if _, err := userService.CreateOrUpdateUser(context.Background(), &user.User{ID: id, FirstName: "user"}); err != nil {
+			t.Fatalf("create user %d: %v", id, err)
+		}
+	}
+
+	if _, err := userService.CreateClient(context.Background(), ownerID, ownWorkspace.ID, "own-client", "own-secret"); err != nil {
+		t.Fatalf("create own client: %v", err)
+	}
+	if _, err := userService.CreateClient(context.Background(), strangerID, foreignWorkspace.ID, "foreign-client", "foreign-secret"); err != nil {
+		t.Fatalf("create foreign client: %v", err)
+	}
+
+	own, err := messageService.CreateMessage(context.Background(), ownWorkspace.ID, "own-client", "own-secret", "hello", "own")
+	if err != nil {
+		t.Fatalf("create own message: %v", err)
+	}
+	foreign, err := messageService.CreateMessage(context.Background(), foreignWorkspace.ID, "foreign-client", "foreign-secret", "hi", "foreign")
+	if err != nil {
+		t.Fatalf("create foreign message: %v", err)
+	}
+
+	access, err := workspaceService.ResolveAccess(context.Background(), ownerID, ownWorkspace.ID)
+	if err != nil {
+		t.Fatalf("resolve owner access: %v", err)
+	}
+	invitation, err := workspaceService.CreateInvitation(access, workspace.RoleViewer)
+	if err != nil {
+		t.Fatalf("create invitation: %v", err)
+	}
+	if _, err := workspaceService.AcceptInvitation(context.Background(), invitation.Token, viewerID); err != nil {
+		t.Fatalf("accept invitation: %v", err)
+	}
+
+	return &messageHandlerFixture{
+		handler:    NewMessageHandler(messageService, workspaceService, authz.NewAuthorizer(userService, messageService), nil),
+		messages:   messageRepo,
+		workspaces: workspaceService,
+		own:        own,
+		foreign:    foreign,
+	}
+}
+
+func newRequest(method, target string, body interface{}, userID int64, workspaceID ...int64) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	r := httptest.NewRequest(method, target, &buf)
+	r.Header.Set("Content-Type", "application/json")
+	if userID != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), "user_id", userID))
+	}
+	if len(workspaceID) > 0 {
+		r.Header.Set(workspaceHeaderName, strconv.FormatInt(workspaceID[0], 10))
+	}
+	return r
+}
+
+func TestMessageHandlerOwnership(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target func(f *messageHandlerFixture) string
+		body   interface{}
+		serve  func(h *MessageHandler) http.HandlerFunc
+		userID int64
+		want   int
+	}{
+		{
+			name:   "get own message",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.own.ID },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessage },
+			userID: ownerID,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "get foreign message",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.foreign.ID },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessage },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "get message without session",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.own.ID },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessage },
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "list own client messages",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/messages/?client_id=own-client" },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessages },
+			userID: ownerID,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "list foreign client messages",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/messages/?client_id=foreign-client" },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessages },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "list unknown client messages",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/messages/?client_id=missing" },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessages },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "update foreign message",
+			method: http.MethodPut,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.foreign.ID },
+			body:   UpdateMessageRequest{Message: "pwned"},
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.UpdateMessage },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "update own message",
+			method: http.MethodPut,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.own.ID },
+			body:   UpdateMessageRequest{Message: "updated"},
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.UpdateMessage },
+			userID: ownerID,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "delete foreign message",
+			method: http.MethodDelete,
+			target: func(f *messageHandlerFixture) string { return "/api/message/?message_id=" + f.foreign.ID },
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.DeleteMessage },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "create message for foreign client",
+			method: http.MethodPost,
+			target: func(f *messageHandlerFixture) string { return "/api/message/" },
+			body:   CreateMessageRequest{ClientID: "foreign-client", ClientSecret: "foreign-secret", Message: "x", Name: "x"},
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.CreateMessage },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "create message for own client",
+			method: http.MethodPost,
+			target: func(f *messageHandlerFixture) string { return "/api/message/" },
+			body:   CreateMessageRequest{ClientID: "own-client", ClientSecret: "own-secret", Message: "x", Name: "x"},
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.CreateMessage },
+			userID: ownerID,
+			want:   http.StatusCreated,
+		},
+		{
+			name:   "get foreign message by credentials",
+			method: http.MethodGet,
+			target: func(f *messageHandlerFixture) string { return "/api/message/credentials/" },
+			body:   map[string]string{"client_id": "foreign-client", "client_secret": "foreign-secret"},
+			serve:  func(h *MessageHandler) http.HandlerFunc { return h.GetMessageByCredentials },
+			userID: ownerID,
+			want:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMessageHandlerFixture(t)
+
+			w := httptest.NewRecorder()
+			tt.serve(f.handler)(w, newRequest(tt.method, tt.target(f), tt.body, tt.userID))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMessageHandlerForeignMutationsLeaveDataIntact(t *testing.T) {
+	f := newMessageHandlerFixture(t)
+
+	w := httptest.NewRecorder()
+	f.handler.DeleteMessage(w, newRequest(http.MethodDelete, "/api/message/?message_id="+f.foreign.ID, nil, ownerID))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("delete status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	f.handler.UpdateMessage(w, newRequest(http.MethodPut, "/api/message/?message_id="+f.foreign.ID, UpdateMessageRequest{Message: "pwned"}, ownerID))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("update status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	stored, _ := f.messages.GetMessageByID(context.Background(), f.foreign.ID)
+	if stored == nil {
+		t.Fatal("foreign message was deleted")
+	}
+	if stored.Message != "hi" {
+		t.Fatalf("foreign message was modified: %q", stored.Message)
+	}
+}
+
+func TestMessageHandlerSharedWorkspaceRoles(t *testing.T) {
+	f := newMessageHandlerFixture(t)
+
+	access, err := f.workspaces.ResolveAccess(context.Background(), ownerID, 0)
+	if err != nil {
+		t.Fatalf("resolve owner access: %v", err)
+	}
+	shared := access.WorkspaceID
+
+	w := httptest.NewRecorder()
+	f.handler.GetMessage(w, newRequest(http.MethodGet, "/api/message/?message_id="+f.own.ID, nil, viewerID, shared))
+	if w.Code != http.StatusOK {
+		t.Fatalf("viewer read status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	f.handler.UpdateMessage(w, newRequest(http.MethodPut, "/api/message/?message_id="+f.own.ID, UpdateMessageRequest{Message: "changed"}, viewerID, shared))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("viewer update status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w = httptest.NewRecorder()
+	f.handler.GetMessage(w, newRequest(http.MethodGet, "/api/message/?message_id="+f.own.ID, nil, viewerID))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("viewer personal workspace read status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	f.handler.GetMessage(w, newRequest(http.MethodGet, "/api/message/?message_id="+f.own.ID, nil, strangerID, shared))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("non-member status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestUserCookieRejectsPlainUserID(t *testing.T) {
+	keyring, err := utils.NewKeyring("test", map[string][]byte{"test": []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	utils.SetKeyring(keyring)
+	t.Cleanup(func() { utils.SetKeyring(nil) })
+
+	f := newMessageHandlerFixture(t)
+	handler := middleware.UserCookie(http.HandlerFunc(f.handler.GetMessages))
+
+	get := func(cookie string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/messages/?client_id=foreign-client", nil)
+		r.AddCookie(&http.Cookie{Name: "user_id", Value: cookie})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get(strconv.FormatInt(strangerID, 10)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("plain user_id cookie status = %d, want %d, body: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+
+	sealed, err := keyring.Encrypt(strconv.FormatInt(strangerID, 10))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if w := get(sealed); w.Code != http.StatusOK {
+		t.Fatalf("encrypted cookie status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"mini-app-backend/internal/search"
+	"net/http"
+)
+
+type SearchHandler struct {
+	*BaseHandler
+	searchService *search.Service
+}
+
+func NewSearchHandler(searchService *search.Service) *SearchHandler {
+	return &SearchHandler{
+		BaseHandler:   NewBaseHandler(),
+		searchService: searchService,
+	}
+}
+
+type SearchMessagesResponse struct {
+	Success    bool          `json:"success"`
+	Hits       []*search.Hit `json:"hits"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// SearchMessages runs a full-text search over mirrored Avito conversations.
+// Snippets are HTML-escaped with matches wrapped in <mark>.
+func (h *SearchHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "SearchMessages request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.SendAccessError(w, r, err, "User not authenticated")
+		return
+	}
+
+	params := r.URL.Query()
+	query, err := search.ParseQuery(
+		userID,
+		params.Get("q"),
+		params.Get("client_id"),
+		params.Get("item_id"),
+		params.Get("direction"),
+		params.Get("date_from"),
+		params.Get("date_to"),
+		params.Get("cursor"),
+		params.Get("limit"),
+	)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Invalid search parameters")
+		return
+	}
+
+	result, err := h.searchService.Search(query)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error searching messages")
+		return
+	}
+
+	h.LogInfo(r, "Successfully searched messages")
+	h.SendJSON(w, r, SearchMessagesResponse{
+		Success:    true,
+		Hits:       result.Hits,
+		NextCursor: result.NextCursor,
+	}, http.StatusOK)
+}
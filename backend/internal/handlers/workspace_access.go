@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/workspace"
+	"net/http"
+	"strconv"
+)
+
+const (
+	workspaceCookieName = "workspace_id"
+	workspaceHeaderName = "X-Workspace-ID"
+)
+
+// GetActiveWorkspaceID returns the workspace selected by the X-Workspace-ID
+// header or the workspace_id cookie, or 0 when the personal workspace should be used.
+func (h *BaseHandler) GetActiveWorkspaceID(r *http.Request) (int64, error) {
+	value := r.Header.Get(workspaceHeaderName)
+	if value == "" {
+		if cookie, err := r.Cookie(workspaceCookieName); err == nil {
+			value = cookie.Value
+		}
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	workspaceID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		return 0, errors.NewAppError(http.StatusBadRequest, "Invalid workspace ID")
+	}
+
+	return workspaceID, nil
+}
+
+// ResolveWorkspaceAccess authenticates the caller and resolves their membership in the active workspace.
+func (h *BaseHandler) ResolveWorkspaceAccess(r *http.Request, workspaceService *workspace.WorkspaceService) (*workspace.Access, error) {
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID, err := h.GetActiveWorkspaceID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return workspaceService.ResolveAccess(r.Context(), userID, workspaceID)
+}
+
+func (h *BaseHandler) SetWorkspaceCookie(w http.ResponseWriter, workspaceID int64) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     workspaceCookieName,
+		Value:    strconv.FormatInt(workspaceID, 10),
+		Path:     "/",
+		MaxAge:   86400 * 30,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// SendAccessError passes application errors (401, 403, 404) through and wraps anything else as a 500.
+func (h *BaseHandler) SendAccessError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	h.LogError(r, err, message)
+
+	if appErr, ok := err.(*errors.AppError); ok {
+		h.SendError(w, r, appErr, appErr.Code)
+		return
+	}
+
+	h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, message, err.Error()), http.StatusInternalServerError)
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/workspace"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WorkspaceHandler struct {
+	*BaseHandler
+	workspaceService *workspace.WorkspaceService
+	botName          string
+}
+
+func NewWorkspaceHandler(workspaceService *workspace.WorkspaceService, botName string) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		BaseHandler:      NewBaseHandler(),
+		workspaceService: workspaceService,
+		botName:          botName,
+	}
+}
+
+type GetWorkspacesResponse struct {
+	Success           bool                    `json:"success"`
+	Workspaces        []*workspace.Membership `json:"workspaces"`
+	ActiveWorkspaceID int64                   `json:"active_workspace_id"`
+	Error             string                  `json:"error,omitempty"`
+}
+
+func (h *WorkspaceHandler) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetWorkspaces request")
+
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	memberships, err := h.workspaceService.GetMembershipsByUserID(access.UserID)
+	if err != nil {
+		h.LogError(r, err, "Error getting workspaces")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting workspaces", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	response := GetWorkspacesResponse{
+		Success:           true,
+		Workspaces:        memberships,
+		ActiveWorkspaceID: access.WorkspaceID,
+	}
+
+	h.LogInfo(r, "Successfully retrieved workspaces")
+	h.SendJSON(w, r, response, http.StatusOK)
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type WorkspaceResponse struct {
+	Success   bool                 `json:"success"`
+	Workspace *workspace.Workspace `json:"workspace,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CreateWorkspace request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req CreateWorkspaceRequest
+	err = h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.LogError(r, nil, "name is required")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "name is required"), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.workspaceService.CreateWorkspace(r.Context(), userID, req.Name)
+	if err != nil {
+		h.LogError(r, err, "Error creating workspace")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error creating workspace", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	h.SetWorkspaceCookie(w, created.ID)
+
+	h.LogInfo(r, "Successfully created workspace")
+	h.SendJSON(w, r, WorkspaceResponse{Success: true, Workspace: created}, http.StatusCreated)
+}
+
+type SetActiveWorkspaceRequest struct {
+	WorkspaceID int64 `json:"workspace_id"`
+}
+
+func (h *WorkspaceHandler) SetActiveWorkspace(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "SetActiveWorkspace request")
+
+	userID, err := h.GetUserIDFromCookie(r)
+	if err != nil {
+		h.LogError(r, err, "Failed to get user ID from cookie")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req SetActiveWorkspaceRequest
+	err = h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.WorkspaceID <= 0 {
+		h.LogError(r, nil, "workspace_id is required")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "workspace_id is required"), http.StatusBadRequest)
+		return
+	}
+
+	access, err := h.workspaceService.ResolveAccess(r.Context(), userID, req.WorkspaceID)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	h.SetWorkspaceCookie(w, access.WorkspaceID)
+
+	h.LogInfo(r, "Successfully switched workspace")
+	h.SendJSON(w, r, map[string]interface{}{"success": true, "workspace_id": access.WorkspaceID, "role": access.Role}, http.StatusOK)
+}
+
+type GetMembersResponse struct {
+	Success bool                `json:"success"`
+	Members []*workspace.Member `json:"members"`
+	Error   string              `json:"error,omitempty"`
+}
+
+func (h *WorkspaceHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "GetMembers request")
+
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	members, err := h.workspaceService.GetMembers(access)
+	if err != nil {
+		h.LogError(r, err, "Error getting members")
+		h.SendError(w, r, errors.NewAppErrorWithDetails(http.StatusInternalServerError, "Error getting members", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	h.LogInfo(r, "Successfully retrieved members")
+	h.SendJSON(w, r, GetMembersResponse{Success: true, Members: members}, http.StatusOK)
+}
+
+type UpdateMemberRequest struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (h *WorkspaceHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "UpdateMember request")
+
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	var req UpdateMemberRequest
+	err = h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	role, err := workspace.ParseRole(req.Role)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Invalid role")
+		return
+	}
+
+	if err := h.workspaceService.UpdateMemberRole(r.Context(), access, req.UserID, role); err != nil {
+		h.SendAccessError(w, r, err, "Error updating member")
+		return
+	}
+
+	h.LogInfo(r, "Successfully updated member")
+	h.SendJSON(w, r, map[string]bool{"success": true}, http.StatusOK)
+}
+
+func (h *WorkspaceHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "RemoveMember request")
+
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	memberID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil {
+		h.LogError(r, err, "Invalid user_id")
+		h.SendError(w, r, errors.NewAppError(http.StatusBadRequest, "Invalid user_id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(r.Context(), access, memberID); err != nil {
+		h.SendAccessError(w, r, err, "Error removing member")
+		return
+	}
+
+	h.LogInfo(r, "Successfully removed member")
+	h.SendJSON(w, r, map[string]bool{"success": true}, http.StatusOK)
+}
+
+type CreateInvitationRequest struct {
+	Role string `json:"role"`
+}
+
+type CreateInvitationResponse struct {
+	Success   bool      `json:"success"`
+	Link      string    `json:"link"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (h *WorkspaceHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	h.LogRequest(r, "CreateInvitation request")
+
+	access, err := h.ResolveWorkspaceAccess(r, h.workspaceService)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Failed to resolve workspace")
+		return
+	}
+
+	var req CreateInvitationRequest
+	err = h.DecodeJSONBody(r, &req)
+	if err != nil {
+		h.LogError(r, err, "Invalid request body")
+		h.SendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = string(workspace.RoleEditor)
+	}
+
+	role, err := workspace.ParseRole(req.Role)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Invalid role")
+		return
+	}
+
+	if h.botName == "" {
+		h.LogError(r, nil, "BOT_NAME is not configured")
+		h.SendError(w, r, errors.ErrServiceUnavailable, http.StatusServiceUnavailable)
+		return
+	}
+
+	invitation, err := h.workspaceService.CreateInvitation(access, role)
+	if err != nil {
+		h.SendAccessError(w, r, err, "Error creating invitation")
+		return
+	}
+
+	response := CreateInvitationResponse{
+		Success:   true,
+		Link:      workspace.InvitationLink(h.botName, invitation.Token),
+		Role:      string(invitation.Role),
+		ExpiresAt: invitation.ExpiresAt,
+	}
+
+	h.LogInfo(r, "Successfully created invitation")
+	h.SendJSON(w, r, response, http.StatusCreated)
+}
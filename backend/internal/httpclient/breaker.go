@@ -0,0 +1,228 @@
+package httpclient
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerConfig opens a circuit when at least MinRequests attempts were made
+// within Window and the share of failures reached FailureRate. After
+// OpenTimeout up to HalfOpenRequests probes are let through; one success
+// closes the circuit, one failure opens it again.
+type BreakerConfig struct {
+	Window           time.Duration
+	MinRequests      int
+	FailureRate      float64
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+	// Group maps a request to its endpoint group. Each host and group pair has
+	// its own circuit. Defaults to the first path segment.
+	Group func(req *http.Request) string
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:           time.Minute,
+		MinRequests:      10,
+		FailureRate:      0.5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+		Group:            firstPathSegment,
+	}
+}
+
+func WithCircuitBreaker(config BreakerConfig) Option {
+	return func(c *Client) {
+		if config.Group == nil {
+			config.Group = firstPathSegment
+		}
+		if config.HalfOpenRequests < 1 {
+			config.HalfOpenRequests = 1
+		}
+		c.breakers = &breakerSet{
+			config:   config,
+			circuits: make(map[string]*circuit),
+		}
+	}
+}
+
+// CircuitOpenError is returned without contacting the upstream while its circuit is open.
+type CircuitOpenError struct {
+	Circuit string
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s until %s", e.Circuit, e.RetryAt.Format(time.RFC3339))
+}
+
+func IsCircuitOpen(err error) bool {
+	var circuitErr *CircuitOpenError
+	return stderrors.As(err, &circuitErr)
+}
+
+// BreakerStatus describes one circuit for health reporting.
+type BreakerStatus struct {
+	Circuit  string       `json:"circuit"`
+	State    BreakerState `json:"state"`
+	Requests int          `json:"requests"`
+	Failures int          `json:"failures"`
+	OpenedAt *time.Time   `json:"opened_at,omitempty"`
+}
+
+func firstPathSegment(req *http.Request) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	return segment
+}
+
+type breakerSet struct {
+	config BreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+}
+
+func (b *breakerSet) key(req *http.Request) string {
+	group := b.config.Group(req)
+	if group == "" {
+		return req.URL.Host
+	}
+	return req.URL.Host + "/" + group
+}
+
+// allow reserves an attempt on the request's circuit.
+func (b *breakerSet) allow(key string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{state: BreakerClosed, windowStart: now}
+		b.circuits[key] = c
+	}
+
+	switch c.state {
+	case BreakerOpen:
+		retryAt := c.openedAt.Add(b.config.OpenTimeout)
+		if now.Before(retryAt) {
+			return &CircuitOpenError{Circuit: key, RetryAt: retryAt}
+		}
+		c.state = BreakerHalfOpen
+		c.probes = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if c.probes >= b.config.HalfOpenRequests {
+			return &CircuitOpenError{Circuit: key, RetryAt: now.Add(b.config.OpenTimeout)}
+		}
+		c.probes++
+	default:
+		if now.Sub(c.windowStart) >= b.config.Window {
+			c.windowStart = now
+			c.requests = 0
+			c.failures = 0
+		}
+	}
+
+	return nil
+}
+
+func (b *breakerSet) record(key string, failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return
+	}
+
+	switch c.state {
+	case BreakerHalfOpen:
+		if failed {
+			c.state = BreakerOpen
+			c.openedAt = now
+		} else {
+			c.state = BreakerClosed
+			c.windowStart = now
+			c.requests = 0
+			c.failures = 0
+		}
+		c.probes = 0
+	case BreakerClosed:
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= b.config.MinRequests && float64(c.failures)/float64(c.requests) >= b.config.FailureRate {
+			c.state = BreakerOpen
+			c.openedAt = now
+		}
+	}
+}
+
+// release gives back a reserved attempt whose outcome says nothing about the
+// upstream, such as one cancelled by the caller.
+func (b *breakerSet) release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok && c.state == BreakerHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+func (b *breakerSet) statuses() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		status := BreakerStatus{
+			Circuit:  key,
+			State:    c.state,
+			Requests: c.requests,
+			Failures: c.failures,
+		}
+		if c.state != BreakerClosed {
+			openedAt := c.openedAt
+			status.OpenedAt = &openedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Circuit < statuses[j].Circuit
+	})
+
+	return statuses
+}
+
+// isBreakerFailure counts transport errors and 5xx; 4xx including 429 mean
+// the upstream is alive and answering.
+func isBreakerFailure(resp *Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"mini-app-backend/internal/logger"
 	"mini-app-backend/internal/middleware"
@@ -24,6 +25,10 @@ type Client struct {
 	logger      logger.Logger
 	authToken   string
 	authHeaders map[string]string
+	retryPolicy RetryPolicy
+	limiter     *hostLimiter
+	breakers    *breakerSet
+	sleep       func(ctx context.Context, d time.Duration) error
 }
 
 type Option func(*Client)
@@ -67,6 +72,8 @@ func NewClient(opts ...Option) *Client {
 		authHeaders: map[string]string{
 			"Content-Type": "application/json",
 		},
+		retryPolicy: RetryPolicy{MaxAttempts: 1},
+		sleep:       sleepContext,
 	}
 
 	for _, opt := range opts {
@@ -91,12 +98,75 @@ func (c *Client) DoRequest(ctx context.Context, method, url string, body io.Read
 		}
 	}
 
-	requestLogger.Debugf("Making %s request to %s", method, reqURL)
+	// Buffer the body once so every attempt can replay it.
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			requestLogger.Errorf("Error reading request body: %v", err)
+			return nil, err
+		}
+	}
+
+	maxAttempts := c.retryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		requestLogger.Debugf("Making %s request to %s (attempt %d/%d)", method, reqURL, attempt, maxAttempts)
+
+		resp, err := c.attempt(ctx, method, reqURL, payload, headers)
+		if err != nil {
+			requestLogger.Errorf("Attempt %d/%d: error making %s request to %s: %v", attempt, maxAttempts, method, reqURL, err)
+		} else {
+			requestLogger.Debugf("Attempt %d/%d: response status: %d, body: %s", attempt, maxAttempts, resp.StatusCode, string(resp.Body))
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil || IsCircuitOpen(err) {
+			return resp, err
+		}
+
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		if !c.retryPolicy.shouldRetry(ctx, method, statusCode, err) {
+			return resp, err
+		}
+
+		delay := c.retryPolicy.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Headers, time.Now()); ok {
+				if c.retryPolicy.MaxRetryAfter > 0 && after > c.retryPolicy.MaxRetryAfter {
+					requestLogger.Warnf("Retry-After %s exceeds limit, giving up on %s %s", after, method, reqURL)
+					return resp, err
+				}
+				delay = after
+			}
+		}
+
+		requestLogger.Infof("Retrying %s %s in %s (attempt %d/%d)", method, reqURL, delay, attempt+1, maxAttempts)
+
+		if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+			if err == nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, reqURL string, payload []byte, headers map[string]string) (*Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
-		requestLogger.Errorf("Error creating request: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	for key, value := range c.authHeaders {
@@ -111,28 +181,56 @@ func (c *Client) DoRequest(ctx context.Context, method, url string, body io.Read
 		req.Header.Set(key, value)
 	}
 
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.breakers == nil {
+		return c.send(req)
+	}
+
+	key := c.breakers.key(req)
+	if err := c.breakers.allow(key, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req)
+	if ctx.Err() != nil {
+		c.breakers.release(key)
+	} else {
+		c.breakers.record(key, isBreakerFailure(resp, err), time.Now())
+	}
+
+	return resp, err
+}
+
+func (c *Client) send(req *http.Request) (*Response, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
-		requestLogger.Errorf("Error making request: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		requestLogger.Errorf("Error reading response body: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error reading response body: %v", err)
 	}
 
-	requestLogger.Debugf("Response status: %d, body: %s", resp.StatusCode, string(respBody))
-
-	response := &Response{
+	return &Response{
 		StatusCode: resp.StatusCode,
 		Body:       respBody,
 		Headers:    resp.Header,
-	}
+	}, nil
+}
 
-	return response, nil
+// BreakerStatuses reports the circuits seen so far, or nil without a circuit breaker.
+func (c *Client) BreakerStatuses() []BreakerStatus {
+	if c.breakers == nil {
+		return nil
+	}
+	return c.breakers.statuses()
 }
 
 func (c *Client) Get(ctx context.Context, url string, headers map[string]string) (*Response, error) {
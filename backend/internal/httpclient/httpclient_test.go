@@ -0,0 +1,253 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRetryingClient(t *testing.T, handler http.HandlerFunc, policy RetryPolicy) (*Client, *[]time.Duration) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	var delays []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	return client, &delays
+}
+
+func TestRetriesIdempotentRequestsOn5xx(t *testing.T) {
+	var attempts int
+	client, delays := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}, DefaultRetryPolicy())
+
+	resp, err := client.Get(context.Background(), "/items", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts != 3 || len(*delays) != 2 {
+		t.Errorf("status = %d, attempts = %d, delays = %v", resp.StatusCode, attempts, *delays)
+	}
+	for i, d := range *delays {
+		if d > DefaultRetryPolicy().InitialBackoff<<i {
+			t.Errorf("delay %d = %s exceeds backoff ceiling", i, d)
+		}
+	}
+}
+
+func TestDoesNotRetryPostOn5xx(t *testing.T) {
+	var attempts int
+	client, _ := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, DefaultRetryPolicy())
+
+	resp, err := client.Post(context.Background(), "/send", strings.NewReader("{}"), nil)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+		t.Errorf("status = %d, attempts = %d", resp.StatusCode, attempts)
+	}
+}
+
+func TestWithoutRetriesSendsPutOnce(t *testing.T) {
+	var attempts int
+	client, _ := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}, DefaultRetryPolicy())
+
+	resp, err := client.Put(WithoutRetries(context.Background()), "/vas", strings.NewReader("{}"), nil)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout || attempts != 2 {
+		t.Errorf("status = %d, attempts = %d; want the 429 retried and the 504 returned", resp.StatusCode, attempts)
+	}
+}
+
+func TestRetriesPostOn429WithReplayedBodyAndRetryAfter(t *testing.T) {
+	var bodies []string
+	client, delays := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}, DefaultRetryPolicy())
+
+	resp, err := client.Post(context.Background(), "/send", strings.NewReader(`{"text":"hi"}`), nil)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] != `{"text":"hi"}` {
+		t.Errorf("bodies = %q", bodies)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 2*time.Second {
+		t.Errorf("delays = %v, want [2s]", *delays)
+	}
+}
+
+func TestGivesUpWhenRetryAfterTooLong(t *testing.T) {
+	var attempts int
+	client, _ := newRetryingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, DefaultRetryPolicy())
+
+	resp, err := client.Get(context.Background(), "/items", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || attempts != 1 {
+		t.Errorf("status = %d, attempts = %d", resp.StatusCode, attempts)
+	}
+}
+
+func TestNoRetriesByDefault(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	if _, err := client.Get(context.Background(), "/", nil); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestRetryAfterParsing(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("Retry-After", now.Add(5*time.Second).Format(http.TimeFormat))
+	if d, ok := retryAfter(header, now); !ok || d != 5*time.Second {
+		t.Errorf("date: %s, %v", d, ok)
+	}
+
+	header.Set("Retry-After", "garbage")
+	if _, ok := retryAfter(header, now); ok {
+		t.Error("garbage must not parse")
+	}
+}
+
+func TestHostLimiterReserve(t *testing.T) {
+	client := NewClient(WithRateLimit(10, 2))
+	now := time.Now()
+
+	if d := client.limiter.reserve("a", now); d != 0 {
+		t.Errorf("first = %s", d)
+	}
+	if d := client.limiter.reserve("a", now); d != 0 {
+		t.Errorf("burst = %s", d)
+	}
+	if d := client.limiter.reserve("a", now); d != 100*time.Millisecond {
+		t.Errorf("third = %s, want 100ms", d)
+	}
+	if d := client.limiter.reserve("b", now); d != 0 {
+		t.Errorf("other host = %s", d)
+	}
+	if d := client.limiter.reserve("a", now.Add(time.Second)); d != 0 {
+		t.Errorf("after refill = %s", d)
+	}
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	failing := true
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := DefaultBreakerConfig()
+	config.MinRequests = 4
+	config.OpenTimeout = 50 * time.Millisecond
+	client := NewClient(WithBaseURL(server.URL), WithCircuitBreaker(config))
+
+	for i := 0; i < 4; i++ {
+		client.Get(context.Background(), "/messenger/v2/chats", nil)
+	}
+
+	if _, err := client.Get(context.Background(), "/messenger/v2/chats", nil); !IsCircuitOpen(err) {
+		t.Fatalf("err = %v, want open circuit", err)
+	}
+	if attempts != 4 {
+		t.Errorf("attempts = %d, open circuit must not reach the server", attempts)
+	}
+
+	if resp, err := client.Get(context.Background(), "/core/v1/items", nil); err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("other endpoint group must keep its own circuit: %v", err)
+	}
+
+	statuses := client.BreakerStatuses()
+	if len(statuses) != 2 || statuses[1].State != BreakerOpen {
+		t.Errorf("statuses = %+v", statuses)
+	}
+
+	time.Sleep(config.OpenTimeout)
+	failing = false
+
+	if resp, err := client.Get(context.Background(), "/messenger/v2/chats", nil); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("half-open probe: %v", err)
+	}
+	if statuses := client.BreakerStatuses(); statuses[1].State != BreakerClosed {
+		t.Errorf("successful probe must close the circuit: %+v", statuses)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	config := DefaultBreakerConfig()
+	config.MinRequests = 2
+	client := NewClient(WithBaseURL(server.URL), WithCircuitBreaker(config))
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.Get(context.Background(), "/core", nil); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+}
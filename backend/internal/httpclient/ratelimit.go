@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hostLimiter is a token bucket per host shared by all requests of a Client.
+type hostLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// WithRateLimit limits outgoing requests to ratePerSecond per host, allowing
+// bursts of up to burst requests. Requests wait for a token rather than fail.
+func WithRateLimit(ratePerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if ratePerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = &hostLimiter{
+			rate:    ratePerSecond,
+			burst:   float64(burst),
+			buckets: make(map[string]*bucket),
+		}
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait for it.
+func (l *hostLimiter) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	return sleepContext(ctx, l.reserve(host, time.Now()))
+}
@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed attempts are retried. Network errors and
+// 5xx responses are retried only for idempotent methods; 429 is retried for
+// every method because the server rejected the request without processing it.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxRetryAfter  time.Duration
+	RetryMethods   map[string]bool
+	RetryStatuses  map[int]bool
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		MaxRetryAfter:  30 * time.Second,
+		RetryMethods: map[string]bool{
+			http.MethodGet:     true,
+			http.MethodHead:    true,
+			http.MethodOptions: true,
+			http.MethodPut:     true,
+			http.MethodDelete:  true,
+		},
+		RetryStatuses: map[int]bool{
+			http.StatusInternalServerError: true,
+			http.StatusBadGateway:          true,
+			http.StatusServiceUnavailable:  true,
+			http.StatusGatewayTimeout:      true,
+		},
+	}
+}
+
+type noRetryKey struct{}
+
+// WithoutRetries marks ctx so requests made with it are treated as not
+// idempotent whatever their method: a network error or 5xx is returned as is,
+// because the server may already have acted on the request. Only 429, which
+// rejects the request unprocessed, is retried.
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+func retriesDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noRetryKey{}).(bool)
+	return disabled
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// shouldRetry reports whether the attempt may be repeated. statusCode is 0 on network errors.
+func (p RetryPolicy) shouldRetry(ctx context.Context, method string, statusCode int, err error) bool {
+	if err == nil && statusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !p.RetryMethods[method] || retriesDisabled(ctx) {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return p.RetryStatuses[statusCode]
+}
+
+// backoff returns the delay before the given retry (1-based) using full jitter.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.InitialBackoff << (retry - 1)
+	if ceiling <= 0 || ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryAfter parses Retry-After as seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		delay := at.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,211 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/chatsync"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/httpclient"
+	"mini-app-backend/internal/logger"
+	"mini-app-backend/internal/user"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+	// maxClients bounds the accounts merged into one inbox.
+	maxClients = 50
+	// parallelAccounts caps concurrent Avito lookups for a single request.
+	parallelAccounts = 4
+	accountCacheTTL  = 10 * time.Minute
+)
+
+// Account is the badge shown next to each chat and the per-account summary.
+type Account struct {
+	ClientID    string              `json:"client_id"`
+	AvitoUserID int64               `json:"avito_user_id,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Unread      int                 `json:"unread"`
+	Freshness   *chatsync.Freshness `json:"freshness,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+type Chat struct {
+	Account *Account        `json:"account"`
+	Unread  int             `json:"unread"`
+	Updated int64           `json:"updated"`
+	Chat    json.RawMessage `json:"chat"`
+}
+
+type Inbox struct {
+	Chats    []*Chat    `json:"chats"`
+	Accounts []*Account `json:"accounts"`
+}
+
+type ClientLister interface {
+	GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*user.Client, error)
+}
+
+type AccountResolver interface {
+	GetSelf(ctx context.Context) (*avito.Account, error)
+	AccountKey(ctx context.Context) string
+}
+
+type Mirror interface {
+	EnsureMirror(ctx context.Context, clientID string, avitoUserID int64) (*chatsync.Freshness, error)
+	ListMirroredChats(avitoUserID int64, filter chatsync.ChatFilter) ([]*chatsync.Chat, error)
+	CountUnread(avitoUserID int64) (int, error)
+}
+
+type Service struct {
+	clients  ClientLister
+	resolver AccountResolver
+	mirror   Mirror
+	accounts *avito.TTLCache[*avito.Account]
+}
+
+func NewService(clients ClientLister, resolver AccountResolver, mirror Mirror) *Service {
+	return &Service{
+		clients:  clients,
+		resolver: resolver,
+		mirror:   mirror,
+		accounts: avito.NewTTLCache[*avito.Account](accountCacheTTL),
+	}
+}
+
+type accountChats struct {
+	account *Account
+	chats   []*chatsync.Chat
+}
+
+// GetInbox merges the mirrored chats of all the user's clients, most recent
+// activity first. An account that fails is reported in its summary and left
+// out of the chats; it never fails the whole inbox.
+func (s *Service) GetInbox(ctx context.Context, userID int64, limit int, unreadOnly bool) (*Inbox, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	clients, err := s.clients.GetClientsByUserIDWithPagination(ctx, userID, maxClients, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]accountChats, len(clients))
+	semaphore := make(chan struct{}, parallelAccounts)
+	var wg sync.WaitGroup
+
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *user.Client) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = s.loadAccount(ctx, client, limit, unreadOnly)
+		}(i, client)
+	}
+	wg.Wait()
+
+	inbox := &Inbox{Chats: []*Chat{}, Accounts: make([]*Account, 0, len(results))}
+	for _, result := range results {
+		inbox.Accounts = append(inbox.Accounts, result.account)
+		for _, chat := range result.chats {
+			inbox.Chats = append(inbox.Chats, &Chat{
+				Account: result.account,
+				Unread:  chat.Unread,
+				Updated: chat.Updated,
+				Chat:    chat.Data,
+			})
+		}
+	}
+
+	sort.SliceStable(inbox.Chats, func(i, j int) bool {
+		return inbox.Chats[i].Updated > inbox.Chats[j].Updated
+	})
+	if len(inbox.Chats) > limit {
+		inbox.Chats = inbox.Chats[:limit]
+	}
+
+	return inbox, nil
+}
+
+func (s *Service) loadAccount(ctx context.Context, client *user.Client, limit int, unreadOnly bool) accountChats {
+	account := &Account{ClientID: client.ClientID}
+	clientCtx := chatsync.ClientContext(ctx, client)
+
+	self, err := s.resolveAccount(clientCtx)
+	if err != nil {
+		account.Error = accountError(client.ClientID, err)
+		return accountChats{account: account}
+	}
+	account.AvitoUserID = self.ID
+	account.Name = self.Name
+
+	freshness, err := s.mirror.EnsureMirror(clientCtx, client.ClientID, self.ID)
+	if err != nil {
+		account.Error = accountError(client.ClientID, err)
+		return accountChats{account: account}
+	}
+	account.Freshness = freshness
+
+	// Each account contributes at most limit chats, which is all the merged
+	// page can take from it.
+	chats, err := s.mirror.ListMirroredChats(self.ID, chatsync.ChatFilter{Limit: limit, UnreadOnly: unreadOnly})
+	if err != nil {
+		account.Error = accountError(client.ClientID, err)
+		return accountChats{account: account}
+	}
+
+	account.Unread, err = s.mirror.CountUnread(self.ID)
+	if err != nil {
+		account.Error = accountError(client.ClientID, err)
+		return accountChats{account: account}
+	}
+
+	return accountChats{account: account, chats: chats}
+}
+
+func (s *Service) resolveAccount(ctx context.Context) (*avito.Account, error) {
+	key := s.resolver.AccountKey(ctx)
+	if account, ok := s.accounts.Get(key); ok {
+		return account, nil
+	}
+
+	account, err := s.resolver.GetSelf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.accounts.Set(key, account)
+	return account, nil
+}
+
+// accountError turns a failure into a message safe to show next to the
+// account; details only go to the log.
+func accountError(clientID string, err error) string {
+	logger.Errorf("Inbox: client %s failed: %v", clientID, err)
+
+	var appErr *errors.AppError
+	switch {
+	case httpclient.IsCircuitOpen(err):
+		return "Avito is temporarily unavailable"
+	case avito.StatusCode(err) == http.StatusUnauthorized || avito.StatusCode(err) == http.StatusForbidden:
+		return "Avito rejected the client credentials"
+	case stderrors.Is(err, avito.ErrNoCredentials):
+		return "Client credentials are missing"
+	case stderrors.As(err, &appErr):
+		return appErr.Message
+	default:
+		return "Failed to load chats"
+	}
+}
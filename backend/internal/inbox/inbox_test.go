@@ -0,0 +1,141 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/chatsync"
+	"mini-app-backend/internal/user"
+	"sync"
+	"testing"
+)
+
+type fakeClients struct {
+	clients []*user.Client
+}
+
+func (f *fakeClients) GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*user.Client, error) {
+	return f.clients, nil
+}
+
+type fakeResolver struct {
+	mu       sync.Mutex
+	accounts map[string]*avito.Account
+	calls    int
+}
+
+func (f *fakeResolver) GetSelf(ctx context.Context) (*avito.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+
+	if token, _ := ctx.Value("avito_access_token").(string); token != "" {
+		return nil, avito.ErrNoCredentials
+	}
+	clientID, _ := ctx.Value("avito_client_id").(string)
+	account, ok := f.accounts[clientID]
+	if !ok {
+		return nil, &avito.APIError{StatusCode: 401}
+	}
+	return account, nil
+}
+
+func (f *fakeResolver) AccountKey(ctx context.Context) string {
+	clientID, _ := ctx.Value("avito_client_id").(string)
+	return clientID
+}
+
+type fakeMirror struct {
+	chats map[int64][]*chatsync.Chat
+}
+
+func (f *fakeMirror) EnsureMirror(ctx context.Context, clientID string, avitoUserID int64) (*chatsync.Freshness, error) {
+	return &chatsync.Freshness{}, nil
+}
+
+func (f *fakeMirror) ListMirroredChats(avitoUserID int64, filter chatsync.ChatFilter) ([]*chatsync.Chat, error) {
+	chats := f.chats[avitoUserID]
+	if len(chats) > filter.Limit {
+		chats = chats[:filter.Limit]
+	}
+	return chats, nil
+}
+
+func (f *fakeMirror) CountUnread(avitoUserID int64) (int, error) {
+	count := 0
+	for _, chat := range f.chats[avitoUserID] {
+		count += chat.Unread
+	}
+	return count, nil
+}
+
+func mirroredChat(id string, updated int64, unread int) *chatsync.Chat {
+	data, _ := json.Marshal(map[string]interface{}{"id": id, "updated": updated})
+	return &chatsync.Chat{ID: id, Updated: updated, Unread: unread, Data: data}
+}
+
+func TestGetInboxMergesAccounts(t *testing.T) {
+	resolver := &fakeResolver{accounts: map[string]*avito.Account{
+		"client-a": {ID: 1, Name: "Shop A"},
+		"client-b": {ID: 2, Name: "Shop B"},
+	}}
+	mirror := &fakeMirror{chats: map[int64][]*chatsync.Chat{
+		1: {mirroredChat("a-2", 400, 2), mirroredChat("a-1", 100, 0)},
+		2: {mirroredChat("b-1", 300, 1)},
+	}}
+	clients := &fakeClients{clients: []*user.Client{
+		{ClientID: "client-a", ClientSecret: "secret"},
+		{ClientID: "client-b", ClientSecret: "secret"},
+		{ClientID: "client-revoked", ClientSecret: "secret"},
+	}}
+	service := NewService(clients, resolver, mirror)
+
+	// An OAuth token on the request must not leak into the stored clients.
+	ctx := context.WithValue(context.Background(), "avito_access_token", "oauth-token")
+
+	result, err := service.GetInbox(ctx, 7, 10, false)
+	if err != nil {
+		t.Fatalf("GetInbox: %v", err)
+	}
+
+	var order []string
+	for _, chat := range result.Chats {
+		var data struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(chat.Chat, &data)
+		order = append(order, data.ID+"@"+chat.Account.ClientID)
+	}
+	want := []string{"a-2@client-a", "b-1@client-b", "a-1@client-a"}
+	if len(order) != len(want) {
+		t.Fatalf("chats = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("chats = %v, want %v", order, want)
+		}
+	}
+
+	if len(result.Accounts) != 3 {
+		t.Fatalf("accounts = %d, want 3", len(result.Accounts))
+	}
+	if result.Accounts[0].Unread != 2 || result.Accounts[0].Name != "Shop A" || result.Accounts[0].Error != "" {
+		t.Fatalf("account a = %+v", result.Accounts[0])
+	}
+	if result.Accounts[2].Error != "Avito rejected the client credentials" {
+		t.Fatalf("revoked account = %+v", result.Accounts[2])
+	}
+
+	limited, err := service.GetInbox(ctx, 7, 2, false)
+	if err != nil {
+		t.Fatalf("GetInbox: %v", err)
+	}
+	if len(limited.Chats) != 2 {
+		t.Fatalf("limited chats = %d, want 2", len(limited.Chats))
+	}
+
+	// Resolved accounts are cached; only the failing client is looked up again.
+	if resolver.calls != 4 {
+		t.Fatalf("GetSelf calls = %d, want 4", resolver.calls)
+	}
+}
@@ -0,0 +1,273 @@
+package itemactions
+
+import (
+	"context"
+	stderrors "errors"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/httpclient"
+	"mini-app-backend/internal/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindPrice = "price"
+	KindVAS   = "vas"
+
+	StatusPending   = "pending"
+	StatusApplying  = "applying"
+	StatusApplied   = "applied"
+	StatusFailed    = "failed"
+	StatusUnknown   = "unknown"
+	StatusCancelled = "cancelled"
+
+	// confirmationTTL is how long a proposed change waits for confirmation.
+	confirmationTTL = 10 * time.Minute
+	// applyTimeout bounds the Avito call, which outlives the request that
+	// confirmed it.
+	applyTimeout = 30 * time.Second
+	// MaxPrice guards against a stray extra digit reaching a live listing.
+	MaxPrice = 1_000_000_000
+)
+
+var (
+	ErrInvalidItem       = errors.NewAppError(http.StatusBadRequest, "Invalid item ID")
+	ErrInvalidPrice      = errors.NewAppError(http.StatusBadRequest, "Price must be a positive whole number of rubles")
+	ErrInvalidVAS        = errors.NewAppError(http.StatusBadRequest, "Unknown promotion")
+	ErrActionNotFound    = errors.NewAppError(http.StatusNotFound, "Action not found")
+	ErrActionNotPending  = errors.NewAppError(http.StatusConflict, "Action is no longer pending")
+	ErrActionExpired     = errors.NewAppError(http.StatusGone, "Confirmation expired, request the change again")
+	ErrAccountMismatch   = errors.NewAppError(http.StatusForbidden, "Action belongs to another Avito account")
+	ErrUnauthorizedActor = errors.NewAppError(http.StatusUnauthorized, "User not authenticated")
+)
+
+// Action is a listing change. It is created pending, applied only after the
+// user confirms it and kept afterwards as the audit trail. While the Avito call
+// runs it is applying. A call that got no definite answer ends unknown rather
+// than failed, since Avito may have made the change anyway.
+type Action struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      int64      `json:"user_id" db:"user_id"`
+	AvitoUserID int64      `json:"avito_user_id" db:"avito_user_id"`
+	ItemID      int64      `json:"item_id" db:"item_id"`
+	Kind        string     `json:"kind" db:"kind"`
+	Value       string     `json:"value" db:"value"`
+	Status      string     `json:"status" db:"status"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+type Repository interface {
+	CreateAction(action *Action) error
+	GetAction(userID int64, id string) (*Action, error)
+	// ClaimAction moves a pending action to a new status exactly once.
+	ClaimAction(userID int64, id, status string, resolvedAt time.Time) (bool, error)
+	// ResolveAction records the outcome of an applying action.
+	ResolveAction(id, status, errorMessage string, resolvedAt time.Time) error
+	ListActions(userID, avitoUserID, itemID int64, limit int) ([]*Action, error)
+}
+
+type Applier interface {
+	UpdateItemPrice(ctx context.Context, itemID, price int64) (*avito.UpdatePriceResult, error)
+	ApplyVAS(ctx context.Context, userID, itemID int64, vasID string) (*avito.VASResult, error)
+}
+
+type Service struct {
+	repo    Repository
+	applier Applier
+	now     func() time.Time
+}
+
+func NewService(repo Repository, applier Applier) *Service {
+	return &Service{
+		repo:    repo,
+		applier: applier,
+		now:     time.Now,
+	}
+}
+
+func (s *Service) ProposePrice(userID, avitoUserID, itemID, price int64) (*Action, error) {
+	if price <= 0 || price > MaxPrice {
+		return nil, ErrInvalidPrice
+	}
+	return s.propose(userID, avitoUserID, itemID, KindPrice, strconv.FormatInt(price, 10))
+}
+
+func (s *Service) ProposeVAS(userID, avitoUserID, itemID int64, vasID string) (*Action, error) {
+	if !avito.IsValidVAS(vasID) {
+		return nil, ErrInvalidVAS
+	}
+	return s.propose(userID, avitoUserID, itemID, KindVAS, vasID)
+}
+
+func (s *Service) propose(userID, avitoUserID, itemID int64, kind, value string) (*Action, error) {
+	if userID == 0 {
+		return nil, ErrUnauthorizedActor
+	}
+	if itemID <= 0 {
+		return nil, ErrInvalidItem
+	}
+
+	now := s.now()
+	action := &Action{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		AvitoUserID: avitoUserID,
+		ItemID:      itemID,
+		Kind:        kind,
+		Value:       value,
+		Status:      StatusPending,
+		ExpiresAt:   now.Add(confirmationTTL),
+		CreatedAt:   now,
+	}
+
+	if err := s.repo.CreateAction(action); err != nil {
+		return nil, err
+	}
+
+	return action, nil
+}
+
+// Confirm applies a pending action against the Avito account resolved for the
+// current request, which must be the one the action was proposed for.
+func (s *Service) Confirm(ctx context.Context, userID, avitoUserID int64, id string) (*Action, error) {
+	action, err := s.pendingAction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if action.AvitoUserID != avitoUserID {
+		return nil, ErrAccountMismatch
+	}
+
+	now := s.now()
+	if now.After(action.ExpiresAt) {
+		if _, err := s.repo.ClaimAction(userID, id, StatusCancelled, now); err != nil {
+			logger.Errorf("Error cancelling expired item action %s: %v", id, err)
+		}
+		return nil, ErrActionExpired
+	}
+
+	claimed, err := s.repo.ClaimAction(userID, id, StatusApplying, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrActionNotPending
+	}
+
+	// A client that disconnects must not cut the call short once it is sent:
+	// the audit trail would then say failed for a change Avito made.
+	applyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), applyTimeout)
+	applyErr := s.apply(applyCtx, action)
+	cancel()
+
+	resolvedAt := s.now()
+	action.Status = StatusApplied
+	action.ResolvedAt = &resolvedAt
+	if applyErr != nil {
+		action.Status = StatusFailed
+		if outcomeUnknown(applyErr) {
+			action.Status = StatusUnknown
+		}
+		action.Error = applyErr.Error()
+	}
+
+	if err := s.repo.ResolveAction(id, action.Status, action.Error, resolvedAt); err != nil {
+		return nil, err
+	}
+
+	if applyErr != nil {
+		return action, applyErr
+	}
+	return action, nil
+}
+
+func (s *Service) apply(ctx context.Context, action *Action) error {
+	switch action.Kind {
+	case KindPrice:
+		price, err := strconv.ParseInt(action.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+		_, err = s.applier.UpdateItemPrice(ctx, action.ItemID, price)
+		return err
+	case KindVAS:
+		_, err := s.applier.ApplyVAS(ctx, action.AvitoUserID, action.ItemID, action.Value)
+		return err
+	}
+	return ErrActionNotFound
+}
+
+// outcomeUnknown reports whether a failed apply may still have changed the
+// listing. Only a 4xx answer, or an error raised before anything was sent,
+// proves that it did not.
+func outcomeUnknown(err error) bool {
+	if status := avito.StatusCode(err); status != 0 {
+		return status >= http.StatusInternalServerError
+	}
+
+	var numErr *strconv.NumError
+	switch {
+	case err == ErrActionNotFound, stderrors.As(err, &numErr):
+		return false
+	case httpclient.IsCircuitOpen(err), stderrors.Is(err, avito.ErrNoCredentials):
+		return false
+	}
+	return true
+}
+
+func (s *Service) Cancel(userID int64, id string) (*Action, error) {
+	action, err := s.pendingAction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	claimed, err := s.repo.ClaimAction(userID, id, StatusCancelled, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrActionNotPending
+	}
+
+	action.Status = StatusCancelled
+	action.ResolvedAt = &now
+	return action, nil
+}
+
+func (s *Service) pendingAction(userID int64, id string) (*Action, error) {
+	if userID == 0 {
+		return nil, ErrUnauthorizedActor
+	}
+
+	action, err := s.repo.GetAction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if action == nil {
+		return nil, ErrActionNotFound
+	}
+	if action.Status != StatusPending {
+		return nil, ErrActionNotPending
+	}
+
+	return action, nil
+}
+
+func (s *Service) ListActions(userID, avitoUserID, itemID int64, limit int) ([]*Action, error) {
+	if userID == 0 {
+		return nil, ErrUnauthorizedActor
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.ListActions(userID, avitoUserID, itemID, limit)
+}
@@ -0,0 +1,215 @@
+package itemactions
+
+import (
+	"context"
+	stderrors "errors"
+	"mini-app-backend/internal/avito"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	actions map[string]*Action
+}
+
+func (m *memoryRepository) CreateAction(action *Action) error {
+	copied := *action
+	m.actions[action.ID] = &copied
+	return nil
+}
+
+func (m *memoryRepository) GetAction(userID int64, id string) (*Action, error) {
+	action, ok := m.actions[id]
+	if !ok || action.UserID != userID {
+		return nil, nil
+	}
+	copied := *action
+	return &copied, nil
+}
+
+func (m *memoryRepository) ClaimAction(userID int64, id, status string, resolvedAt time.Time) (bool, error) {
+	action, ok := m.actions[id]
+	if !ok || action.UserID != userID || action.Status != StatusPending {
+		return false, nil
+	}
+	action.Status = status
+	action.ResolvedAt = &resolvedAt
+	return true, nil
+}
+
+func (m *memoryRepository) ResolveAction(id, status, errorMessage string, resolvedAt time.Time) error {
+	if m.actions[id].Status != StatusApplying {
+		return nil
+	}
+	m.actions[id].Status = status
+	m.actions[id].Error = errorMessage
+	m.actions[id].ResolvedAt = &resolvedAt
+	return nil
+}
+
+func (m *memoryRepository) ListActions(userID, avitoUserID, itemID int64, limit int) ([]*Action, error) {
+	return nil, nil
+}
+
+type fakeApplier struct {
+	prices map[int64]int64
+	err    error
+	// onApply runs before every call, while the action is being applied.
+	onApply func()
+	// ctxErr is the state of the context the last call was made with.
+	ctxErr error
+}
+
+func (f *fakeApplier) UpdateItemPrice(ctx context.Context, itemID, price int64) (*avito.UpdatePriceResult, error) {
+	f.ctxErr = ctx.Err()
+	if f.onApply != nil {
+		f.onApply()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.prices[itemID] = price
+	return &avito.UpdatePriceResult{Success: true}, nil
+}
+
+func (f *fakeApplier) ApplyVAS(ctx context.Context, userID, itemID int64, vasID string) (*avito.VASResult, error) {
+	return &avito.VASResult{}, f.err
+}
+
+func newTestService() (*Service, *memoryRepository, *fakeApplier) {
+	repo := &memoryRepository{actions: make(map[string]*Action)}
+	applier := &fakeApplier{prices: make(map[int64]int64)}
+	return NewService(repo, applier), repo, applier
+}
+
+func TestConfirmAppliesOnce(t *testing.T) {
+	service, repo, applier := newTestService()
+
+	action, err := service.ProposePrice(1, 42, 7, 1500)
+	if err != nil {
+		t.Fatalf("ProposePrice: %v", err)
+	}
+	if len(applier.prices) != 0 {
+		t.Fatal("price applied before confirmation")
+	}
+
+	if _, err := service.Confirm(context.Background(), 1, 42, action.ID); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if applier.prices[7] != 1500 || repo.actions[action.ID].Status != StatusApplied {
+		t.Errorf("prices = %v, status = %s", applier.prices, repo.actions[action.ID].Status)
+	}
+
+	if _, err := service.Confirm(context.Background(), 1, 42, action.ID); err != ErrActionNotPending {
+		t.Errorf("second confirm err = %v, want ErrActionNotPending", err)
+	}
+}
+
+func TestConfirmMarksActionApplyingDuringTheCall(t *testing.T) {
+	service, repo, applier := newTestService()
+
+	action, _ := service.ProposePrice(1, 42, 7, 1500)
+
+	var during string
+	applier.onApply = func() {
+		during = repo.actions[action.ID].Status
+		// A concurrent confirmation must not get in while the call runs.
+		if _, err := service.Confirm(context.Background(), 1, 42, action.ID); err != ErrActionNotPending {
+			t.Errorf("concurrent confirm err = %v, want ErrActionNotPending", err)
+		}
+	}
+
+	if _, err := service.Confirm(context.Background(), 1, 42, action.ID); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if during != StatusApplying {
+		t.Errorf("status during the Avito call = %s, want %s", during, StatusApplying)
+	}
+	if stored := repo.actions[action.ID]; stored.Status != StatusApplied || stored.ResolvedAt == nil {
+		t.Errorf("stored action = %+v", stored)
+	}
+}
+
+func TestConfirmRejects(t *testing.T) {
+	service, repo, applier := newTestService()
+
+	other, _ := service.ProposePrice(1, 42, 7, 1500)
+	if _, err := service.Confirm(context.Background(), 1, 99, other.ID); err != ErrAccountMismatch {
+		t.Errorf("account mismatch err = %v", err)
+	}
+	if _, err := service.Confirm(context.Background(), 2, 42, other.ID); err != ErrActionNotFound {
+		t.Errorf("foreign user err = %v", err)
+	}
+
+	service.now = func() time.Time { return time.Now().Add(confirmationTTL + time.Minute) }
+	if _, err := service.Confirm(context.Background(), 1, 42, other.ID); err != ErrActionExpired {
+		t.Errorf("expired err = %v", err)
+	}
+	if repo.actions[other.ID].Status != StatusCancelled {
+		t.Errorf("expired status = %s", repo.actions[other.ID].Status)
+	}
+
+	service.now = time.Now
+	applier.err = &avito.APIError{StatusCode: 400, Message: "boom"}
+	failing, _ := service.ProposeVAS(1, 42, 7, avito.VASHighlight)
+	if _, err := service.Confirm(context.Background(), 1, 42, failing.ID); err == nil {
+		t.Error("expected apply error")
+	}
+	if stored := repo.actions[failing.ID]; stored.Status != StatusFailed || stored.Error != "avito: 400 boom" {
+		t.Errorf("failed action = %+v", stored)
+	}
+}
+
+func TestConfirmRecordsUnknownOutcome(t *testing.T) {
+	for name, err := range map[string]error{
+		"network error": stderrors.New("connection reset by peer"),
+		"server error":  &avito.APIError{StatusCode: 502},
+		"timeout":       context.DeadlineExceeded,
+	} {
+		service, repo, applier := newTestService()
+		applier.err = err
+
+		action, _ := service.ProposePrice(1, 42, 7, 1500)
+		if _, confirmErr := service.Confirm(context.Background(), 1, 42, action.ID); confirmErr != err {
+			t.Errorf("%s: Confirm err = %v", name, confirmErr)
+		}
+		if stored := repo.actions[action.ID]; stored.Status != StatusUnknown {
+			t.Errorf("%s: status = %s, want %s", name, stored.Status, StatusUnknown)
+		}
+	}
+}
+
+func TestConfirmOutlivesTheRequest(t *testing.T) {
+	service, repo, applier := newTestService()
+
+	action, _ := service.ProposePrice(1, 42, 7, 1500)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.Confirm(ctx, 1, 42, action.ID); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if applier.ctxErr != nil {
+		t.Errorf("apply ran with a cancelled context: %v", applier.ctxErr)
+	}
+	if repo.actions[action.ID].Status != StatusApplied {
+		t.Errorf("status = %s", repo.actions[action.ID].Status)
+	}
+}
+
+func TestProposeValidation(t *testing.T) {
+	service, _, _ := newTestService()
+
+	if _, err := service.ProposePrice(1, 42, 7, 0); err != ErrInvalidPrice {
+		t.Errorf("zero price err = %v", err)
+	}
+	if _, err := service.ProposePrice(1, 42, 7, MaxPrice+1); err != ErrInvalidPrice {
+		t.Errorf("huge price err = %v", err)
+	}
+	if _, err := service.ProposeVAS(1, 42, 7, "gold"); err != ErrInvalidVAS {
+		t.Errorf("unknown vas err = %v", err)
+	}
+	if _, err := service.ProposePrice(0, 42, 7, 100); err != ErrUnauthorizedActor {
+		t.Errorf("anonymous err = %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package itemactions
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+const actionColumns = `id, user_id, avito_user_id, item_id, kind, value, status, error, expires_at, created_at, resolved_at`
+
+func scanAction(scan func(dest ...interface{}) error) (*Action, error) {
+	action := &Action{}
+	var resolvedAt sql.NullTime
+	err := scan(
+		&action.ID,
+		&action.UserID,
+		&action.AvitoUserID,
+		&action.ItemID,
+		&action.Kind,
+		&action.Value,
+		&action.Status,
+		&action.Error,
+		&action.ExpiresAt,
+		&action.CreatedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		action.ResolvedAt = &resolvedAt.Time
+	}
+
+	return action, nil
+}
+
+func (r *SQLRepository) CreateAction(action *Action) error {
+	query := `
+		INSERT INTO avito_item_actions (id, user_id, avito_user_id, item_id, kind, value, status, error, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '', $8, $9)
+	`
+
+	_, err := r.db.Exec(query, action.ID, action.UserID, action.AvitoUserID, action.ItemID,
+		action.Kind, action.Value, action.Status, action.ExpiresAt, action.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating item action: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetAction(userID int64, id string) (*Action, error) {
+	query := `SELECT ` + actionColumns + ` FROM avito_item_actions WHERE id = $1 AND user_id = $2`
+
+	action, err := scanAction(r.db.QueryRow(query, id, userID).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting item action: %v", err)
+		return nil, err
+	}
+
+	return action, nil
+}
+
+func (r *SQLRepository) ClaimAction(userID int64, id, status string, resolvedAt time.Time) (bool, error) {
+	query := `
+		UPDATE avito_item_actions
+		SET status = $3, resolved_at = $4
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+	`
+
+	result, err := r.db.Exec(query, id, userID, status, resolvedAt)
+	if err != nil {
+		log.Printf("Error claiming item action: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *SQLRepository) ResolveAction(id, status, errorMessage string, resolvedAt time.Time) error {
+	query := `
+		UPDATE avito_item_actions
+		SET status = $2, error = $3, resolved_at = $4
+		WHERE id = $1 AND status = 'applying'
+	`
+
+	_, err := r.db.Exec(query, id, status, errorMessage, resolvedAt)
+	if err != nil {
+		log.Printf("Error resolving item action: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) ListActions(userID, avitoUserID, itemID int64, limit int) ([]*Action, error) {
+	query := `
+		SELECT ` + actionColumns + `
+		FROM avito_item_actions
+		WHERE user_id = $1 AND avito_user_id = $2 AND ($3 = 0 OR item_id = $3)
+		ORDER BY created_at DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(query, userID, avitoUserID, itemID, limit)
+	if err != nil {
+		log.Printf("Error listing item actions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []*Action{}
+	for rows.Next() {
+		action, err := scanAction(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning item action: %v", err)
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
@@ -0,0 +1,274 @@
+package itemstats
+
+import (
+	"context"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// Avito accepts at most 200 items per stats call and keeps 270 days of history.
+	maxItemsPerRequest = 200
+	maxHistoryDays     = 270
+	// MaxItemIDs bounds a single dashboard request.
+	MaxItemIDs = 1000
+)
+
+// Avito reports statistics by Moscow calendar days.
+var statsLocation = time.FixedZone("MSK", 3*60*60)
+
+var (
+	ErrInvalidRange = errors.NewAppError(http.StatusBadRequest, "Invalid date range")
+	ErrRangeTooOld  = errors.NewAppError(http.StatusBadRequest, "Avito keeps statistics for the last 270 days only")
+	ErrTooManyItems = errors.NewAppError(http.StatusBadRequest, "Too many item IDs")
+)
+
+// DailyStats is one item's activity on one day. Replies come from the local
+// reply log, the other counters from Avito.
+type DailyStats struct {
+	ItemID    int64  `json:"-" db:"item_id"`
+	Date      string `json:"date" db:"date"`
+	Views     int    `json:"views" db:"uniq_views"`
+	Contacts  int    `json:"contacts" db:"uniq_contacts"`
+	Favorites int    `json:"favorites" db:"uniq_favorites"`
+	Replies   int    `json:"replies" db:"replies"`
+}
+
+type Totals struct {
+	Views     int `json:"views"`
+	Contacts  int `json:"contacts"`
+	Favorites int `json:"favorites"`
+	Replies   int `json:"replies"`
+}
+
+type ItemSeries struct {
+	ItemID int64         `json:"item_id"`
+	Days   []*DailyStats `json:"days"`
+	Totals Totals        `json:"totals"`
+}
+
+type ReplyEvent struct {
+	AvitoUserID int64     `json:"avito_user_id" db:"avito_user_id"`
+	ItemID      int64     `json:"item_id" db:"item_id"`
+	ChatID      string    `json:"chat_id" db:"chat_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type Repository interface {
+	GetCachedDays(avitoUserID int64, itemIDs []int64, from, to string) ([]*DailyStats, error)
+	SaveDays(avitoUserID int64, days []*DailyStats) error
+	RecordReply(event *ReplyEvent) error
+	CountReplies(avitoUserID int64, itemIDs []int64, from, to time.Time) ([]*DailyStats, error)
+}
+
+type Fetcher interface {
+	GetItemStats(ctx context.Context, userID int64, req avito.ItemStatsRequest) (*avito.ItemStatsResponse, error)
+}
+
+type Service struct {
+	repo    Repository
+	fetcher Fetcher
+	now     func() time.Time
+}
+
+func NewService(repo Repository, fetcher Fetcher) *Service {
+	return &Service{
+		repo:    repo,
+		fetcher: fetcher,
+		now:     time.Now,
+	}
+}
+
+// ParseRange validates a YYYY-MM-DD range against Avito's retention window.
+func (s *Service) ParseRange(from, to string) (time.Time, time.Time, error) {
+	today := s.today()
+
+	dateTo := today
+	if to != "" {
+		parsed, err := time.ParseInLocation(dateLayout, to, statsLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidRange
+		}
+		dateTo = parsed
+	}
+
+	dateFrom := dateTo.AddDate(0, 0, -6)
+	if from != "" {
+		parsed, err := time.ParseInLocation(dateLayout, from, statsLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidRange
+		}
+		dateFrom = parsed
+	}
+
+	if dateFrom.After(dateTo) || dateTo.After(today) {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+
+	if dateFrom.Before(today.AddDate(0, 0, -maxHistoryDays)) {
+		return time.Time{}, time.Time{}, ErrRangeTooOld
+	}
+
+	return dateFrom, dateTo, nil
+}
+
+func (s *Service) today() time.Time {
+	now := s.now().In(statsLocation)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, statsLocation)
+}
+
+// GetItemStats returns a daily series per item. Finished days are served from
+// Postgres once fetched; only missing days and today go to Avito.
+func (s *Service) GetItemStats(ctx context.Context, avitoUserID int64, itemIDs []int64, from, to time.Time) ([]*ItemSeries, error) {
+	if len(itemIDs) > MaxItemIDs {
+		return nil, ErrTooManyItems
+	}
+
+	today := s.today()
+	dates := dateRange(from, to)
+
+	cached, err := s.repo.GetCachedDays(avitoUserID, itemIDs, from.Format(dateLayout), to.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[int64]map[string]*DailyStats, len(itemIDs))
+	for _, id := range itemIDs {
+		days[id] = make(map[string]*DailyStats, len(dates))
+	}
+	for _, day := range cached {
+		if byDate, ok := days[day.ItemID]; ok {
+			byDate[day.Date] = day
+		}
+	}
+
+	// Items are fetched from their earliest missing day; a shared window per
+	// call keeps the number of requests at one per batch.
+	var missing []int64
+	fetchFrom := to
+	for _, id := range itemIDs {
+		for _, date := range dates {
+			if _, ok := days[id][date.Format(dateLayout)]; !ok {
+				missing = append(missing, id)
+				if date.Before(fetchFrom) {
+					fetchFrom = date
+				}
+				break
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := s.fetch(ctx, avitoUserID, missing, fetchFrom, to)
+		if err != nil {
+			return nil, err
+		}
+
+		var historical []*DailyStats
+		for _, id := range missing {
+			for _, date := range dateRange(fetchFrom, to) {
+				key := date.Format(dateLayout)
+				day, ok := fetched[id][key]
+				if !ok {
+					day = &DailyStats{ItemID: id, Date: key}
+				}
+				days[id][key] = day
+				if date.Before(today) {
+					historical = append(historical, day)
+				}
+			}
+		}
+
+		if err := s.repo.SaveDays(avitoUserID, historical); err != nil {
+			return nil, err
+		}
+	}
+
+	replies, err := s.repo.CountReplies(avitoUserID, itemIDs, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if day, ok := days[reply.ItemID][reply.Date]; ok {
+			day.Replies = reply.Replies
+		}
+	}
+
+	series := make([]*ItemSeries, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		item := &ItemSeries{ItemID: id, Days: make([]*DailyStats, 0, len(dates))}
+		for _, date := range dates {
+			day := days[id][date.Format(dateLayout)]
+			if day == nil {
+				day = &DailyStats{ItemID: id, Date: date.Format(dateLayout)}
+			}
+			item.Days = append(item.Days, day)
+			item.Totals.Views += day.Views
+			item.Totals.Contacts += day.Contacts
+			item.Totals.Favorites += day.Favorites
+			item.Totals.Replies += day.Replies
+		}
+		series = append(series, item)
+	}
+
+	return series, nil
+}
+
+func (s *Service) fetch(ctx context.Context, avitoUserID int64, itemIDs []int64, from, to time.Time) (map[int64]map[string]*DailyStats, error) {
+	result := make(map[int64]map[string]*DailyStats, len(itemIDs))
+
+	for start := 0; start < len(itemIDs); start += maxItemsPerRequest {
+		end := start + maxItemsPerRequest
+		if end > len(itemIDs) {
+			end = len(itemIDs)
+		}
+
+		response, err := s.fetcher.GetItemStats(ctx, avitoUserID, avito.ItemStatsRequest{
+			DateFrom:       from.Format(dateLayout),
+			DateTo:         to.Format(dateLayout),
+			Fields:         []string{avito.StatsFieldUniqViews, avito.StatsFieldUniqContacts, avito.StatsFieldUniqFavorites},
+			ItemIDs:        itemIDs[start:end],
+			PeriodGrouping: "day",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Result.Items {
+			byDate := make(map[string]*DailyStats, len(item.Stats))
+			for _, stat := range item.Stats {
+				byDate[stat.Date] = &DailyStats{
+					ItemID:    item.ItemID,
+					Date:      stat.Date,
+					Views:     stat.UniqViews,
+					Contacts:  stat.UniqContacts,
+					Favorites: stat.UniqFavorites,
+				}
+			}
+			result[item.ItemID] = byDate
+		}
+	}
+
+	return result, nil
+}
+
+// RecordReply logs a reply sent through the app for the per-item analytics.
+func (s *Service) RecordReply(avitoUserID, itemID int64, chatID string) error {
+	return s.repo.RecordReply(&ReplyEvent{
+		AvitoUserID: avitoUserID,
+		ItemID:      itemID,
+		ChatID:      chatID,
+		CreatedAt:   s.now(),
+	})
+}
+
+func dateRange(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
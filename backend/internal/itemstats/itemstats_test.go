@@ -0,0 +1,145 @@
+package itemstats
+
+import (
+	"context"
+	"mini-app-backend/internal/avito"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	days    map[int64]map[string]*DailyStats
+	replies []*DailyStats
+	saved   int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{days: make(map[int64]map[string]*DailyStats)}
+}
+
+func (m *memoryRepository) GetCachedDays(avitoUserID int64, itemIDs []int64, from, to string) ([]*DailyStats, error) {
+	var days []*DailyStats
+	for _, id := range itemIDs {
+		for date, day := range m.days[id] {
+			if date >= from && date <= to {
+				days = append(days, day)
+			}
+		}
+	}
+	return days, nil
+}
+
+func (m *memoryRepository) SaveDays(avitoUserID int64, days []*DailyStats) error {
+	for _, day := range days {
+		if m.days[day.ItemID] == nil {
+			m.days[day.ItemID] = make(map[string]*DailyStats)
+		}
+		copied := *day
+		m.days[day.ItemID][day.Date] = &copied
+		m.saved++
+	}
+	return nil
+}
+
+func (m *memoryRepository) RecordReply(event *ReplyEvent) error { return nil }
+
+func (m *memoryRepository) CountReplies(avitoUserID int64, itemIDs []int64, from, to time.Time) ([]*DailyStats, error) {
+	return m.replies, nil
+}
+
+type fakeFetcher struct {
+	requests []avito.ItemStatsRequest
+}
+
+func (f *fakeFetcher) GetItemStats(ctx context.Context, userID int64, req avito.ItemStatsRequest) (*avito.ItemStatsResponse, error) {
+	f.requests = append(f.requests, req)
+
+	response := &avito.ItemStatsResponse{}
+	from, _ := time.Parse(dateLayout, req.DateFrom)
+	to, _ := time.Parse(dateLayout, req.DateTo)
+	for _, id := range req.ItemIDs {
+		item := avito.ItemStats{ItemID: id}
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			item.Stats = append(item.Stats, avito.ItemStatsDay{Date: date.Format(dateLayout), UniqViews: 10, UniqContacts: 1})
+		}
+		response.Result.Items = append(response.Result.Items, item)
+	}
+	return response, nil
+}
+
+func newTestService(repo Repository, fetcher Fetcher) *Service {
+	service := NewService(repo, fetcher)
+	service.now = func() time.Time { return time.Date(2026, 3, 10, 12, 0, 0, 0, statsLocation) }
+	return service
+}
+
+func TestGetItemStatsBatchesAndCachesHistory(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.replies = []*DailyStats{{ItemID: 1, Date: "2026-03-09", Replies: 3}}
+	fetcher := &fakeFetcher{}
+	service := newTestService(repo, fetcher)
+
+	itemIDs := make([]int64, 250)
+	for i := range itemIDs {
+		itemIDs[i] = int64(i + 1)
+	}
+
+	from, to, err := service.ParseRange("2026-03-08", "2026-03-10")
+	if err != nil {
+		t.Fatalf("ParseRange: %v", err)
+	}
+
+	series, err := service.GetItemStats(context.Background(), 42, itemIDs, from, to)
+	if err != nil {
+		t.Fatalf("GetItemStats: %v", err)
+	}
+
+	if len(fetcher.requests) != 2 || len(fetcher.requests[0].ItemIDs) != 200 || len(fetcher.requests[1].ItemIDs) != 50 {
+		t.Fatalf("requests = %d, want batches of 200 and 50", len(fetcher.requests))
+	}
+	// Only the two finished days are cached; today stays live.
+	if repo.saved != 500 {
+		t.Errorf("saved days = %d, want 500", repo.saved)
+	}
+
+	first := series[0]
+	if len(first.Days) != 3 || first.Totals.Views != 30 || first.Totals.Replies != 3 {
+		t.Errorf("series = %+v", first.Totals)
+	}
+
+	fetcher.requests = nil
+	if _, err := service.GetItemStats(context.Background(), 42, itemIDs, from, to); err != nil {
+		t.Fatalf("GetItemStats: %v", err)
+	}
+	for _, req := range fetcher.requests {
+		if req.DateFrom != "2026-03-10" {
+			t.Errorf("cached history refetched from %s", req.DateFrom)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	service := newTestService(newMemoryRepository(), &fakeFetcher{})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr error
+	}{
+		{"default week", "", "", nil},
+		{"reversed", "2026-03-09", "2026-03-01", ErrInvalidRange},
+		{"future", "2026-03-01", "2026-03-11", ErrInvalidRange},
+		{"malformed", "03/01/2026", "", ErrInvalidRange},
+		{"too old", "2025-01-01", "2026-03-01", ErrRangeTooOld},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.ParseRange(tt.from, tt.to)
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package itemstats
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"mini-app-backend/internal/database"
+
+	"github.com/lib/pq"
+)
+
+type SQLRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db:      db,
+		dialect: database.DialectOf(db),
+	}
+}
+
+// dayOf formats a DATE column as YYYY-MM-DD.
+func (r *SQLRepository) dayOf(column string) string {
+	if r.dialect == database.SQLite {
+		return "strftime('%Y-%m-%d', " + column + ")"
+	}
+	return "TO_CHAR(" + column + ", 'YYYY-MM-DD')"
+}
+
+// moscowDayOf formats a UTC TIMESTAMP column as its Moscow calendar day.
+// Moscow has no daylight saving time, so SQLite can shift by a fixed offset.
+func (r *SQLRepository) moscowDayOf(column string) string {
+	if r.dialect == database.SQLite {
+		return "strftime('%Y-%m-%d', " + column + ", '+3 hours')"
+	}
+	return "TO_CHAR(" + column + " AT TIME ZONE 'UTC' AT TIME ZONE 'Europe/Moscow', 'YYYY-MM-DD')"
+}
+
+func (r *SQLRepository) GetCachedDays(avitoUserID int64, itemIDs []int64, from, to string) ([]*DailyStats, error) {
+	query := `
+		SELECT item_id, ` + r.dayOf("date") + `, uniq_views, uniq_contacts, uniq_favorites
+		FROM avito_item_stats_daily
+		WHERE avito_user_id = $1 AND item_id = ANY($2) AND date BETWEEN $3 AND $4
+	`
+
+	rows, err := r.db.Query(query, avitoUserID, pq.Array(itemIDs), from, to)
+	if err != nil {
+		log.Printf("Error getting cached item stats: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*DailyStats
+	for rows.Next() {
+		day := &DailyStats{}
+		if err := rows.Scan(&day.ItemID, &day.Date, &day.Views, &day.Contacts, &day.Favorites); err != nil {
+			log.Printf("Error scanning cached item stats: %v", err)
+			return nil, err
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+func (r *SQLRepository) SaveDays(avitoUserID int64, days []*DailyStats) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("Error starting item stats transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO avito_item_stats_daily (avito_user_id, item_id, date, uniq_views, uniq_contacts, uniq_favorites, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (avito_user_id, item_id, date) DO UPDATE SET
+			uniq_views = EXCLUDED.uniq_views,
+			uniq_contacts = EXCLUDED.uniq_contacts,
+			uniq_favorites = EXCLUDED.uniq_favorites,
+			fetched_at = EXCLUDED.fetched_at
+	`)
+	if err != nil {
+		log.Printf("Error preparing item stats insert: %v", err)
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, day := range days {
+		_, err := stmt.Exec(avitoUserID, day.ItemID, day.Date, day.Views, day.Contacts, day.Favorites, now)
+		if err != nil {
+			log.Printf("Error saving item stats: %v", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLRepository) RecordReply(event *ReplyEvent) error {
+	query := `
+		INSERT INTO avito_item_replies (avito_user_id, item_id, chat_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(query, event.AvitoUserID, event.ItemID, event.ChatID, event.CreatedAt.UTC())
+	if err != nil {
+		log.Printf("Error recording item reply: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// CountReplies groups replies by Moscow calendar day to line up with Avito's
+// own daily buckets.
+func (r *SQLRepository) CountReplies(avitoUserID int64, itemIDs []int64, from, to time.Time) ([]*DailyStats, error) {
+	query := `
+		SELECT item_id, ` + r.moscowDayOf("created_at") + ` AS day, COUNT(*)
+		FROM avito_item_replies
+		WHERE avito_user_id = $1 AND item_id = ANY($2) AND created_at >= $3 AND created_at < $4
+		GROUP BY item_id, day
+	`
+
+	rows, err := r.db.Query(query, avitoUserID, pq.Array(itemIDs), from.UTC(), to.UTC())
+	if err != nil {
+		log.Printf("Error counting item replies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*DailyStats
+	for rows.Next() {
+		day := &DailyStats{}
+		if err := rows.Scan(&day.ItemID, &day.Date, &day.Replies); err != nil {
+			log.Printf("Error scanning item replies: %v", err)
+			return nil, err
+		}
+		counts = append(counts, day)
+	}
+
+	return counts, rows.Err()
+}
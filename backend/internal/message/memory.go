@@ -0,0 +1,122 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is a MessageRepository kept in process memory for tests and
+// local runs without Postgres. It follows SQLMessageRepository: lookups return
+// nil when nothing matches, lists are newest first and ids, being UUIDs,
+// ignore case.
+type MemoryRepository struct {
+	mu       sync.Mutex
+	messages map[string]*Message
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		messages: make(map[string]*Message),
+	}
+}
+
+func (r *MemoryRepository) CreateMessage(ctx context.Context, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := strings.ToLower(message.ID)
+	if _, ok := r.messages[key]; ok {
+		return fmt.Errorf("message %s already exists", message.ID)
+	}
+
+	copied := *message
+	r.messages[key] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, ok := r.messages[strings.ToLower(id)]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *message
+	return &copied, nil
+}
+
+// find returns copies of the matching messages, newest first.
+func (r *MemoryRepository) find(match func(*Message) bool) []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var messages []*Message
+	for _, message := range r.messages {
+		if match(message) {
+			copied := *message
+			messages = append(messages, &copied)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+	return messages
+}
+
+func (r *MemoryRepository) GetMessagesByClientID(ctx context.Context, clientID string) ([]*Message, error) {
+	return r.find(func(m *Message) bool { return m.ClientID == clientID }), nil
+}
+
+func (r *MemoryRepository) GetMessageByClientCredentials(ctx context.Context, clientID, clientSecret string) (*Message, error) {
+	messages := r.find(func(m *Message) bool { return m.ClientID == clientID && m.ClientSecret == clientSecret })
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return messages[0], nil
+}
+
+// UpdateMessage keeps the workspace, like the SQL UPDATE does.
+func (r *MemoryRepository) UpdateMessage(ctx context.Context, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.messages[strings.ToLower(message.ID)]
+	if !ok {
+		return nil
+	}
+
+	existing.ClientID = message.ClientID
+	existing.ClientSecret = message.ClientSecret
+	existing.Message = message.Message
+	existing.Name = message.Name
+	existing.IsActive = message.IsActive
+	existing.UpdatedAt = message.UpdatedAt
+	return nil
+}
+
+func (r *MemoryRepository) DeleteMessage(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.messages, strings.ToLower(id))
+	return nil
+}
+
+func (r *MemoryRepository) CountMessagesByClientID(ctx context.Context, clientID string) (int, error) {
+	return len(r.find(func(m *Message) bool { return m.ClientID == clientID })), nil
+}
+
+func (r *MemoryRepository) CountMessagesByClientIDInTimeRange(ctx context.Context, clientID string, duration time.Duration) (int, error) {
+	since := time.Now().Add(-duration)
+	return len(r.find(func(m *Message) bool { return m.ClientID == clientID && m.CreatedAt.After(since) })), nil
+}
@@ -1,6 +1,7 @@
 package message
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +11,7 @@ type Message struct {
 	ID           string    `json:"id" db:"id"`
 	ClientID     string    `json:"client_id" db:"client_id"`
 	ClientSecret string    `json:"client_secret" db:"client_secret"`
+	WorkspaceID  int64     `json:"workspace_id" db:"workspace_id"`
 	Message      string    `json:"message" db:"message"`
 	Name         string    `json:"name" db:"name"`
 	IsActive     bool      `json:"is_active" db:"is_active"`
@@ -18,14 +20,14 @@ type Message struct {
 }
 
 type MessageRepository interface {
-	CreateMessage(message *Message) error
-	GetMessageByID(id string) (*Message, error)
-	GetMessagesByClientID(clientID string) ([]*Message, error)
-	GetMessageByClientCredentials(clientID, clientSecret string) (*Message, error)
-	UpdateMessage(message *Message) error
-	DeleteMessage(id string) error
-	CountMessagesByClientID(clientID string) (int, error)
-	CountMessagesByClientIDInTimeRange(clientID string, duration time.Duration) (int, error)
+	CreateMessage(ctx context.Context, message *Message) error
+	GetMessageByID(ctx context.Context, id string) (*Message, error)
+	GetMessagesByClientID(ctx context.Context, clientID string) ([]*Message, error)
+	GetMessageByClientCredentials(ctx context.Context, clientID, clientSecret string) (*Message, error)
+	UpdateMessage(ctx context.Context, message *Message) error
+	DeleteMessage(ctx context.Context, id string) error
+	CountMessagesByClientID(ctx context.Context, clientID string) (int, error)
+	CountMessagesByClientIDInTimeRange(ctx context.Context, clientID string, duration time.Duration) (int, error)
 }
 
 type MessageService struct {
@@ -38,11 +40,12 @@ func NewMessageService(repo MessageRepository) *MessageService {
 	}
 }
 
-func (s *MessageService) CreateMessage(clientID, clientSecret, message, name string) (*Message, error) {
+func (s *MessageService) CreateMessage(ctx context.Context, workspaceID int64, clientID, clientSecret, message, name string) (*Message, error) {
 	msg := &Message{
 		ID:           uuid.New().String(),
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
+		WorkspaceID:  workspaceID,
 		Message:      message,
 		Name:         name,
 		IsActive:     true,
@@ -50,7 +53,7 @@ func (s *MessageService) CreateMessage(clientID, clientSecret, message, name str
 		UpdatedAt:    time.Now(),
 	}
 
-	err := s.repo.CreateMessage(msg)
+	err := s.repo.CreateMessage(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -58,20 +61,20 @@ func (s *MessageService) CreateMessage(clientID, clientSecret, message, name str
 	return msg, nil
 }
 
-func (s *MessageService) GetMessageByID(id string) (*Message, error) {
-	return s.repo.GetMessageByID(id)
+func (s *MessageService) GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	return s.repo.GetMessageByID(ctx, id)
 }
 
-func (s *MessageService) GetMessagesByClientID(clientID string) ([]*Message, error) {
-	return s.repo.GetMessagesByClientID(clientID)
+func (s *MessageService) GetMessagesByClientID(ctx context.Context, clientID string) ([]*Message, error) {
+	return s.repo.GetMessagesByClientID(ctx, clientID)
 }
 
-func (s *MessageService) GetMessageByClientCredentials(clientID, clientSecret string) (*Message, error) {
-	return s.repo.GetMessageByClientCredentials(clientID, clientSecret)
+func (s *MessageService) GetMessageByClientCredentials(ctx context.Context, clientID, clientSecret string) (*Message, error) {
+	return s.repo.GetMessageByClientCredentials(ctx, clientID, clientSecret)
 }
 
-func (s *MessageService) UpdateMessage(id string, message, name string, isActive bool) (*Message, error) {
-	msg, err := s.repo.GetMessageByID(id)
+func (s *MessageService) UpdateMessage(ctx context.Context, id string, message, name string, isActive bool) (*Message, error) {
+	msg, err := s.repo.GetMessageByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +88,7 @@ func (s *MessageService) UpdateMessage(id string, message, name string, isActive
 	msg.IsActive = isActive
 	msg.UpdatedAt = time.Now()
 
-	err = s.repo.UpdateMessage(msg)
+	err = s.repo.UpdateMessage(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -93,14 +96,14 @@ func (s *MessageService) UpdateMessage(id string, message, name string, isActive
 	return msg, nil
 }
 
-func (s *MessageService) DeleteMessage(id string) error {
-	return s.repo.DeleteMessage(id)
+func (s *MessageService) DeleteMessage(ctx context.Context, id string) error {
+	return s.repo.DeleteMessage(ctx, id)
 }
 
-func (s *MessageService) CountMessagesByClientID(clientID string) (int, error) {
-	return s.repo.CountMessagesByClientID(clientID)
+func (s *MessageService) CountMessagesByClientID(ctx context.Context, clientID string) (int, error) {
+	return s.repo.CountMessagesByClientID(ctx, clientID)
 }
 
-func (s *MessageService) CountMessagesByClientIDInTimeRange(clientID string, duration time.Duration) (int, error) {
-	return s.repo.CountMessagesByClientIDInTimeRange(clientID, duration)
+func (s *MessageService) CountMessagesByClientIDInTimeRange(ctx context.Context, clientID string, duration time.Duration) (int, error) {
+	return s.repo.CountMessagesByClientIDInTimeRange(ctx, clientID, duration)
 }
\ No newline at end of file
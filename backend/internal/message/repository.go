@@ -1,31 +1,37 @@
 package message
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"mini-app-backend/internal/database"
 	"time"
 )
 
 type SQLMessageRepository struct {
-	db *sql.DB
+	db database.DBTX
 }
 
-func NewSQLMessageRepository(db *sql.DB) *SQLMessageRepository {
+func NewSQLMessageRepository(db database.DBTX) *SQLMessageRepository {
 	return &SQLMessageRepository{
 		db: db,
 	}
 }
 
-func (r *SQLMessageRepository) CreateMessage(message *Message) error {
+func (r *SQLMessageRepository) CreateMessage(ctx context.Context, message *Message) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		INSERT INTO messages (id, client_id, client_secret, message, name, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO messages (id, client_id, client_secret, workspace_id, message, name, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
 		message.ID,
 		message.ClientID,
 		message.ClientSecret,
+		message.WorkspaceID,
 		message.Message,
 		message.Name,
 		message.IsActive,
@@ -41,20 +47,24 @@ func (r *SQLMessageRepository) CreateMessage(message *Message) error {
 	return nil
 }
 
-func (r *SQLMessageRepository) GetMessageByID(id string) (*Message, error) {
+func (r *SQLMessageRepository) GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, message, name, is_active, created_at, updated_at
+		SELECT id, client_id, client_secret, COALESCE(workspace_id, 0), message, name, is_active, created_at, updated_at
 		FROM messages
 		WHERE id = $1
 	`
 
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), id)
 
 	msg := &Message{}
 	err := row.Scan(
 		&msg.ID,
 		&msg.ClientID,
 		&msg.ClientSecret,
+		&msg.WorkspaceID,
 		&msg.Message,
 		&msg.Name,
 		&msg.IsActive,
@@ -73,15 +83,18 @@ func (r *SQLMessageRepository) GetMessageByID(id string) (*Message, error) {
 	return msg, nil
 }
 
-func (r *SQLMessageRepository) GetMessagesByClientID(clientID string) ([]*Message, error) {
+func (r *SQLMessageRepository) GetMessagesByClientID(ctx context.Context, clientID string) ([]*Message, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, message, name, is_active, created_at, updated_at
+		SELECT id, client_id, client_secret, COALESCE(workspace_id, 0), message, name, is_active, created_at, updated_at
 		FROM messages
 		WHERE client_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, clientID)
+	rows, err := r.db.QueryContext(ctx, database.Tag(ctx, query), clientID)
 	if err != nil {
 		log.Printf("Error getting messages by client ID: %v", err)
 		return nil, err
@@ -95,6 +108,7 @@ func (r *SQLMessageRepository) GetMessagesByClientID(clientID string) ([]*Messag
 			&msg.ID,
 			&msg.ClientID,
 			&msg.ClientSecret,
+			&msg.WorkspaceID,
 			&msg.Message,
 			&msg.Name,
 			&msg.IsActive,
@@ -111,22 +125,26 @@ func (r *SQLMessageRepository) GetMessagesByClientID(clientID string) ([]*Messag
 	return messages, nil
 }
 
-func (r *SQLMessageRepository) GetMessageByClientCredentials(clientID, clientSecret string) (*Message, error) {
+func (r *SQLMessageRepository) GetMessageByClientCredentials(ctx context.Context, clientID, clientSecret string) (*Message, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, message, name, is_active, created_at, updated_at
+		SELECT id, client_id, client_secret, COALESCE(workspace_id, 0), message, name, is_active, created_at, updated_at
 		FROM messages
 		WHERE client_id = $1 AND client_secret = $2
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, clientID, clientSecret)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientID, clientSecret)
 
 	msg := &Message{}
 	err := row.Scan(
 		&msg.ID,
 		&msg.ClientID,
 		&msg.ClientSecret,
+		&msg.WorkspaceID,
 		&msg.Message,
 		&msg.Name,
 		&msg.IsActive,
@@ -145,14 +163,17 @@ func (r *SQLMessageRepository) GetMessageByClientCredentials(clientID, clientSec
 	return msg, nil
 }
 
-func (r *SQLMessageRepository) UpdateMessage(message *Message) error {
+func (r *SQLMessageRepository) UpdateMessage(ctx context.Context, message *Message) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE messages
 		SET client_id = $2, client_secret = $3, message = $4, name = $5, is_active = $6, updated_at = $7
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
 		message.ID,
 		message.ClientID,
 		message.ClientSecret,
@@ -170,10 +191,13 @@ func (r *SQLMessageRepository) UpdateMessage(message *Message) error {
 	return nil
 }
 
-func (r *SQLMessageRepository) DeleteMessage(id string) error {
+func (r *SQLMessageRepository) DeleteMessage(ctx context.Context, id string) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM messages WHERE id = $1`
 
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query), id)
 	if err != nil {
 		log.Printf("Error deleting message: %v", err)
 		return err
@@ -182,7 +206,10 @@ func (r *SQLMessageRepository) DeleteMessage(id string) error {
 	return nil
 }
 
-func (r *SQLMessageRepository) CountMessagesByClientID(clientID string) (int, error) {
+func (r *SQLMessageRepository) CountMessagesByClientID(ctx context.Context, clientID string) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT COUNT(*)
 		FROM messages
@@ -190,7 +217,7 @@ func (r *SQLMessageRepository) CountMessagesByClientID(clientID string) (int, er
 	`
 
 	var count int
-	err := r.db.QueryRow(query, clientID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientID).Scan(&count)
 	if err != nil {
 		log.Printf("Error counting messages by client ID: %v", err)
 		return 0, err
@@ -199,7 +226,10 @@ func (r *SQLMessageRepository) CountMessagesByClientID(clientID string) (int, er
 	return count, nil
 }
 
-func (r *SQLMessageRepository) CountMessagesByClientIDInTimeRange(clientID string, duration time.Duration) (int, error) {
+func (r *SQLMessageRepository) CountMessagesByClientIDInTimeRange(ctx context.Context, clientID string, duration time.Duration) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT COUNT(*)
 		FROM messages
@@ -208,7 +238,7 @@ func (r *SQLMessageRepository) CountMessagesByClientIDInTimeRange(clientID strin
 
 	since := time.Now().Add(-duration)
 	var count int
-	err := r.db.QueryRow(query, clientID, since).Scan(&count)
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientID, since).Scan(&count)
 	if err != nil {
 		log.Printf("Error counting messages by client ID in time range: %v", err)
 		return 0, err
@@ -216,36 +246,3 @@ func (r *SQLMessageRepository) CountMessagesByClientIDInTimeRange(clientID strin
 
 	return count, nil
 }
-
-func (r *SQLMessageRepository) CreateMessagesTable() error {
-	messagesTable := `
-		CREATE TABLE IF NOT EXISTS messages (
-			id UUID PRIMARY KEY,
-			client_id VARCHAR(255) NOT NULL,
-			client_secret VARCHAR(255) NOT NULL,
-			message TEXT NOT NULL,
-			name VARCHAR(255) NOT NULL,
-			is_active BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-	`
-
-	_, err := r.db.Exec(messagesTable)
-	if err != nil {
-		log.Printf("Error creating messages table: %v", err)
-		return err
-	}
-
-	indexQuery := `
-		CREATE INDEX IF NOT EXISTS idx_messages_client_id ON messages(client_id);
-	`
-
-	_, err = r.db.Exec(indexQuery)
-	if err != nil {
-		log.Printf("Error creating index: %v", err)
-		return err
-	}
-
-	return nil
-}
\ No newline at end of file
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"mini-app-backend/internal/apikey"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/logger"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxAPIKeyRequestsPerWindow = 60
+	apiKeyRateLimitWindow      = time.Minute
+)
+
+const APIKeyContextKey contextKey = "apiKey"
+
+type apiKeyScopeRule struct {
+	method string
+	prefix string
+	scope  apikey.Scope
+}
+
+// apiKeyScopeRules lists everything reachable with an API key. Any other
+// route, including key management itself, is cookie-session only.
+var apiKeyScopeRules = []apiKeyScopeRule{
+	{http.MethodGet, "/api/messages/", apikey.ScopeMessagesRead},
+	{http.MethodGet, "/api/message/", apikey.ScopeMessagesRead},
+	{http.MethodGet, "/api/user/clients/", apikey.ScopeMessagesRead},
+	{http.MethodPost, "/api/message/", apikey.ScopeMessagesWrite},
+	{http.MethodPut, "/api/message/", apikey.ScopeMessagesWrite},
+	{http.MethodDelete, "/api/message/", apikey.ScopeMessagesWrite},
+	{http.MethodPost, "/api/avito/messenger/", apikey.ScopeAvitoSend},
+}
+
+func requiredAPIKeyScope(r *http.Request) (apikey.Scope, bool) {
+	for _, rule := range apiKeyScopeRules {
+		if r.Method == rule.method && strings.HasPrefix(r.URL.Path, rule.prefix) {
+			return rule.scope, true
+		}
+	}
+	return "", false
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type APIKeyAuthMiddleware struct {
+	service *apikey.APIKeyService
+
+	mu         sync.Mutex
+	windows    map[int64]*rateWindow
+	lastPruned time.Time
+}
+
+func NewAPIKeyAuthMiddleware(service *apikey.APIKeyService) *APIKeyAuthMiddleware {
+	return &APIKeyAuthMiddleware{
+		service: service,
+		windows: make(map[int64]*rateWindow),
+	}
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && apikey.IsAPIKey(token) {
+		return token
+	}
+
+	return ""
+}
+
+// allow applies a fixed-window limit per key, independent of cookie sessions.
+func (m *APIKeyAuthMiddleware) allow(keyID int64, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	window, ok := m.windows[keyID]
+	if !ok || now.Sub(window.start) >= apiKeyRateLimitWindow {
+		m.pruneWindows(now)
+		m.windows[keyID] = &rateWindow{start: now, count: 1}
+		return true
+	}
+
+	if window.count >= maxAPIKeyRequestsPerWindow {
+		return false
+	}
+
+	window.count++
+	return true
+}
+
+// pruneWindows drops the windows of keys that have gone quiet, so revoked or
+// idle keys do not stay in memory. It sweeps at most once per window length.
+func (m *APIKeyAuthMiddleware) pruneWindows(now time.Time) {
+	if now.Sub(m.lastPruned) < apiKeyRateLimitWindow {
+		return
+	}
+	m.lastPruned = now
+
+	for keyID, window := range m.windows {
+		if now.Sub(window.start) >= apiKeyRateLimitWindow {
+			delete(m.windows, keyID)
+		}
+	}
+}
+
+// Authenticate accepts personal API keys alongside the cookie session. Requests
+// without a key pass through untouched; requests with one act as the key's owner.
+func (m *APIKeyAuthMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plaintext := apiKeyFromRequest(r)
+		if plaintext == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := m.service.Authenticate(plaintext)
+		if err != nil {
+			errors.SendErrorResponse(w, err)
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer m.audit(r, key, rw)
+
+		scope, allowed := requiredAPIKeyScope(r)
+		if !allowed {
+			errors.SendErrorResponse(rw, errors.NewAppError(http.StatusForbidden, "Endpoint is not available for API keys"))
+			return
+		}
+
+		if !key.HasScope(scope) {
+			errors.SendErrorResponse(rw, errors.NewAppErrorWithDetails(http.StatusForbidden, "API key is missing a required scope", string(scope)))
+			return
+		}
+
+		if !m.allow(key.ID, time.Now()) {
+			errors.SendErrorResponse(rw, errors.NewAppError(http.StatusTooManyRequests, "API key rate limit exceeded. Please wait before sending another request."))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "user_id", key.UserID)
+		ctx = context.WithValue(ctx, APIKeyContextKey, key)
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+func (m *APIKeyAuthMiddleware) audit(r *http.Request, key *apikey.APIKey, rw *responseWriter) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	requestID, _ := r.Context().Value(RequestIDKey).(string)
+
+	err := m.service.RecordAudit(&apikey.AuditEntry{
+		KeyID:      key.ID,
+		UserID:     key.UserID,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		StatusCode: status,
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  requestID,
+	})
+	if err != nil {
+		logger.Errorf("Error recording API key audit entry: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAPIKeyRateLimit(t *testing.T) {
+	m := NewAPIKeyAuthMiddleware(nil)
+	start := time.Now()
+
+	for i := 0; i < maxAPIKeyRequestsPerWindow; i++ {
+		if !m.allow(1, start) {
+			t.Fatalf("request %d rejected inside the limit", i+1)
+		}
+	}
+	if m.allow(1, start) {
+		t.Fatal("request over the limit allowed")
+	}
+	if !m.allow(1, start.Add(apiKeyRateLimitWindow)) {
+		t.Fatal("request in the next window rejected")
+	}
+}
+
+func TestAPIKeyRateLimitPrunesIdleKeys(t *testing.T) {
+	m := NewAPIKeyAuthMiddleware(nil)
+	start := time.Now()
+
+	for keyID := int64(1); keyID <= 100; keyID++ {
+		m.allow(keyID, start)
+	}
+
+	later := start.Add(apiKeyRateLimitWindow)
+	m.allow(1, later)
+	if len(m.windows) != 1 {
+		t.Fatalf("%d windows kept after a window passed, want only the active key", len(m.windows))
+	}
+
+	// Keys still inside their window keep their count.
+	m.allow(2, later.Add(time.Second))
+	m.allow(3, later.Add(apiKeyRateLimitWindow))
+	if _, ok := m.windows[2]; !ok {
+		t.Error("window of a key still inside its minute was pruned")
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const avitoAccountHeaderName = "X-Avito-Account-ID"
+
+// AvitoTokenSource resolves OAuth tokens for connected Avito accounts.
+// An empty access token with a nil error means the user has not connected one.
+type AvitoTokenSource interface {
+	AccessToken(ctx context.Context, userID, accountID int64) (tokenType string, accessToken string, err error)
+}
+
+// AvitoOAuth puts the connected account's token into the context for Avito
+// routes. Credentials set explicitly through cookies take precedence.
+func AvitoOAuth(source AvitoTokenSource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/avito/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			userID, ok := ctx.Value("user_id").(int64)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if _, hasCredentials := ctx.Value("avito_client_id").(string); hasCredentials {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var accountID int64
+			if value := r.Header.Get(avitoAccountHeaderName); value != "" {
+				parsed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || parsed <= 0 {
+					errors.SendErrorResponse(w, errors.NewAppError(http.StatusBadRequest, "Invalid Avito account ID"))
+					return
+				}
+				accountID = parsed
+			}
+
+			tokenType, accessToken, err := source.AccessToken(ctx, userID, accountID)
+			if err != nil {
+				if _, ok := err.(*errors.AppError); !ok {
+					err = errors.NewAppErrorWithDetails(http.StatusBadGateway, "Failed to get Avito token", err.Error())
+				}
+				errors.SendErrorResponse(w, err)
+				return
+			}
+
+			if accessToken != "" {
+				ctx = context.WithValue(ctx, "avito_token_type", tokenType)
+				ctx = context.WithValue(ctx, "avito_access_token", accessToken)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
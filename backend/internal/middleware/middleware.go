@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"mini-app-backend/internal/database"
 	"mini-app-backend/internal/logger"
 	"mini-app-backend/internal/utils"
 	"net/http"
@@ -25,6 +26,7 @@ func Logging(next http.Handler) http.Handler {
 		}
 		
 		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx = database.WithRequestID(ctx, requestID)
 		r = r.WithContext(ctx)
 		
 		w.Header().Set("X-Request-ID", requestID)
@@ -50,6 +52,19 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush lets streaming handlers such as the SSE endpoint push data through
+// the logging wrapper.
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -59,7 +74,7 @@ func CORS(next http.Handler) http.Handler {
 		}
 		
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Workspace-ID, X-Avito-Account-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if r.Method == "OPTIONS" {
@@ -111,12 +126,12 @@ func UserCookie(next http.Handler) http.Handler {
 				if err == nil {
 					ctx = context.WithValue(ctx, "user_id", userID)
 				}
-			} else {
-				userID, err := strconv.ParseInt(cookie.Value, 10, 64)
-				if err == nil {
-					ctx = context.WithValue(ctx, "user_id", userID)
+				if encryptionUtil.NeedsRotation(cookie.Value) {
+					rotateCookie(w, encryptionUtil, "user_id", decryptedValue)
 				}
 			}
+			// A cookie that does not decrypt is not a session: accepting a plain
+			// user_id would let anyone pick whose data they see.
 		}
 		
 		clientIDCookie, err := r.Cookie("avito_client_id")
@@ -125,15 +140,8 @@ func UserCookie(next http.Handler) http.Handler {
 		if err == nil && err2 == nil {
 			encryptionUtil := utils.NewEncryptionUtil()
 			
-			decryptedClientID, err := encryptionUtil.Decrypt(clientIDCookie.Value)
-			if err != nil {
-				decryptedClientID = clientIDCookie.Value
-			}
-			
-			decryptedClientSecret, err := encryptionUtil.Decrypt(clientSecretCookie.Value)
-			if err != nil {
-				decryptedClientSecret = clientSecretCookie.Value
-			}
+			decryptedClientID := avitoCookieValue(w, encryptionUtil, clientIDCookie)
+			decryptedClientSecret := avitoCookieValue(w, encryptionUtil, clientSecretCookie)
 			
 			ctx = context.WithValue(ctx, "avito_client_id", decryptedClientID)
 			ctx = context.WithValue(ctx, "avito_client_secret", decryptedClientSecret)
@@ -144,6 +152,41 @@ func UserCookie(next http.Handler) http.Handler {
 	})
 }
 
+// avitoCookieValue reads an Avito credential cookie. These carry the user's own
+// credentials, so a value that does not decrypt is used as typed; a sealed one
+// is re-issued with the active key when it needs rotation.
+func avitoCookieValue(w http.ResponseWriter, encryptionUtil *utils.EncryptionUtil, cookie *http.Cookie) string {
+	decrypted, err := encryptionUtil.Decrypt(cookie.Value)
+	if err != nil {
+		return cookie.Value
+	}
+
+	if encryptionUtil.NeedsRotation(cookie.Value) {
+		rotateCookie(w, encryptionUtil, cookie.Name, decrypted)
+	}
+	return decrypted
+}
+
+// rotateCookie re-issues a cookie sealed with the active key, so retiring an
+// old key later does not log the user out or drop their Avito credentials.
+func rotateCookie(w http.ResponseWriter, encryptionUtil *utils.EncryptionUtil, name, value string) {
+	encrypted, err := encryptionUtil.Encrypt(value)
+	if err != nil {
+		logger.Errorf("Error re-encrypting %s cookie: %v", name, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encrypted,
+		Path:     "/",
+		MaxAge:   86400 * 30,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
 func AvitoAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		clientIDCookie, err := r.Cookie("avito_client_id")
@@ -160,15 +203,8 @@ func AvitoAuth(next http.Handler) http.Handler {
 
 		encryptionUtil := utils.NewEncryptionUtil()
 		
-		decryptedClientID, err := encryptionUtil.Decrypt(clientIDCookie.Value)
-		if err != nil {
-			decryptedClientID = clientIDCookie.Value
-		}
-		
-		decryptedClientSecret, err := encryptionUtil.Decrypt(clientSecretCookie.Value)
-		if err != nil {
-			decryptedClientSecret = clientSecretCookie.Value
-		}
+		decryptedClientID := avitoCookieValue(w, encryptionUtil, clientIDCookie)
+		decryptedClientSecret := avitoCookieValue(w, encryptionUtil, clientSecretCookie)
 
 		ctx := context.WithValue(r.Context(), "avito_client_id", decryptedClientID)
 		ctx = context.WithValue(ctx, "avito_client_secret", decryptedClientSecret)
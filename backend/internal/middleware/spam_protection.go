@@ -53,7 +53,7 @@ func (m *SpamProtectionMiddleware) Protect(next http.Handler) http.Handler {
 		
 		clientID := req.ClientID
 
-		totalMessages, err := m.messageService.CountMessagesByClientID(clientID)
+		totalMessages, err := m.messageService.CountMessagesByClientID(r.Context(), clientID)
 		if err != nil {
 			errors.SendErrorResponse(w, errors.NewAppError(http.StatusInternalServerError, "Error checking message count"))
 			return
@@ -64,7 +64,7 @@ func (m *SpamProtectionMiddleware) Protect(next http.Handler) http.Handler {
 			return
 		}
 
-		recentMessages, err := m.messageService.CountMessagesByClientIDInTimeRange(clientID, rateLimitWindowDuration)
+		recentMessages, err := m.messageService.CountMessagesByClientIDInTimeRange(r.Context(), clientID, rateLimitWindowDuration)
 		if err != nil {
 			errors.SendErrorResponse(w, errors.NewAppError(http.StatusInternalServerError, "Error checking recent message count"))
 			return
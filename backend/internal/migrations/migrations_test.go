@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
+)
+
+func TestEmbeddedMigrationsAreSequential(t *testing.T) {
+	for _, dialect := range []database.Dialect{database.Postgres, database.SQLite} {
+		migrations, err := Embedded(dialect)
+		if err != nil {
+			t.Fatalf("Embedded(%s): %v", dialect, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("no embedded %s migrations", dialect)
+		}
+
+		for i, migration := range migrations {
+			if migration.Version != int64(i+1) {
+				t.Errorf("%s migration %d has version %d; versions must not skip", dialect, i+1, migration.Version)
+			}
+		}
+	}
+}
+
+func TestDialectsHaveTheSameMigrations(t *testing.T) {
+	postgres, err := Embedded(database.Postgres)
+	if err != nil {
+		t.Fatalf("Embedded(postgres): %v", err)
+	}
+	sqlite, err := Embedded(database.SQLite)
+	if err != nil {
+		t.Fatalf("Embedded(sqlite): %v", err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("%d Postgres migrations but %d SQLite ones", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("migration %04d_%s has no SQLite twin (found %04d_%s)",
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestSQLiteMigrationsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open(ctx, &config.Config{
+		DatabaseDriver:       "sqlite",
+		SQLitePath:           filepath.Join(t.TempDir(), "test.db"),
+		SQLiteBusyTimeout:    5 * time.Second,
+		PostgresMaxOpenConns: 4,
+		PostgresMaxIdleConns: 4,
+		PostgresQueryTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	migrations, err := Embedded(database.SQLite)
+	if err != nil {
+		t.Fatalf("Embedded: %v", err)
+	}
+	migrator := New(db, migrations)
+
+	if applied, err := migrator.Up(ctx); err != nil || applied != len(migrations) {
+		t.Fatalf("Up = %d, %v; want %d", applied, err, len(migrations))
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Fatalf("second Up = %d, %v; want 0", applied, err)
+	}
+	if reverted, err := migrator.Down(ctx, len(migrations)); err != nil || reverted != len(migrations) {
+		t.Fatalf("Down = %d, %v; want %d", reverted, err, len(migrations))
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != len(migrations) {
+		t.Fatalf("Up after Down = %d, %v; want %d", applied, err, len(migrations))
+	}
+}
+
+func TestLoadRejectsIncompleteMigrations(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_users.up.sql": {Data: []byte("CREATE TABLE users (id BIGINT);")},
+		},
+		"bad name": {
+			"users.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"name mismatch": {
+			"0001_users.up.sql":     {Data: []byte("SELECT 1;")},
+			"0001_clients.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadSortsByVersion(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0010_b.up.sql":   {Data: []byte("SELECT 10;")},
+		"0010_b.down.sql": {Data: []byte("SELECT -10;")},
+		"0002_a.up.sql":   {Data: []byte("SELECT 2;")},
+		"0002_a.down.sql": {Data: []byte("SELECT -2;")},
+		"README.md":       {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Name != "b" || migrations[1].Down != "SELECT -10;" {
+		t.Fatalf("migrations = %+v %+v", migrations[0], migrations[1])
+	}
+}
+
+func TestCreateNumbersAfterLatest(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_users.up.sql", "0001_users.down.sql", "0002_messages.up.sql", "0002_messages.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upPath, downPath, err := Create(dir, "Add chat notes")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if filepath.Base(upPath) != "0003_add_chat_notes.up.sql" || !strings.HasSuffix(downPath, "0003_add_chat_notes.down.sql") {
+		t.Fatalf("paths = %s, %s", upPath, downPath)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil || len(migrations) != 3 {
+		t.Fatalf("Load after Create = %d, %v", len(migrations), err)
+	}
+
+	if _, _, err := Create(dir, "drop table;"); err == nil {
+		t.Error("expected invalid name to be rejected")
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS user_data;
+DROP TABLE IF EXISTS users;
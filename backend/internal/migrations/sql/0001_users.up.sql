@@ -0,0 +1,31 @@
+-- Baseline migrations keep IF NOT EXISTS so databases created by the old
+-- CreateTables start-up code are adopted without changes.
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT PRIMARY KEY,
+	first_name VARCHAR(255) NOT NULL,
+	last_name VARCHAR(255),
+	username VARCHAR(255),
+	language_code VARCHAR(10),
+	is_premium BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_data (
+	id SERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	data TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_data_user_id ON user_data(user_id);
+
+CREATE TABLE IF NOT EXISTS clients (
+	id SERIAL PRIMARY KEY,
+	client_id VARCHAR(255) NOT NULL,
+	client_secret VARCHAR(255) NOT NULL,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_clients_user_id ON clients(user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS workspace_id;
+DROP INDEX IF EXISTS idx_clients_workspace_id;
+ALTER TABLE clients DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
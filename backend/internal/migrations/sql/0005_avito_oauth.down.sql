@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS avito_connections;
+DROP TABLE IF EXISTS avito_oauth_states;
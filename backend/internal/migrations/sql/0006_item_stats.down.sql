@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS avito_item_replies;
+DROP TABLE IF EXISTS avito_item_stats_daily;
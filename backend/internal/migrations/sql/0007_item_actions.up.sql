@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS avito_item_actions (
+	id VARCHAR(36) PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	avito_user_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	value VARCHAR(64) NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	resolved_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_avito_item_actions_account ON avito_item_actions (user_id, avito_user_id, created_at);
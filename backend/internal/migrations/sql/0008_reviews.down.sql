@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS review_settings;
+DROP TABLE IF EXISTS review_templates;
+DROP TABLE IF EXISTS avito_review_sync;
+DROP TABLE IF EXISTS avito_reviews;
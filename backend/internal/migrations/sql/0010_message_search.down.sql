@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_avito_messages_search;
+ALTER TABLE avito_messages DROP COLUMN IF EXISTS search_vector;
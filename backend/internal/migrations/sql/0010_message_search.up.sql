@@ -0,0 +1,4 @@
+-- Buyers write in Russian and English, so both stemmers feed one vector.
+ALTER TABLE avito_messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('russian', text) || to_tsvector('english', text)) STORED;
+CREATE INDEX IF NOT EXISTS idx_avito_messages_search ON avito_messages USING GIN (search_vector);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS export_jobs (
+	id UUID PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	client_id VARCHAR(255) NOT NULL,
+	format VARCHAR(8) NOT NULL,
+	date_from VARCHAR(10) NOT NULL,
+	date_to VARCHAR(10) NOT NULL,
+	chat_id VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(16) NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	rows INTEGER NOT NULL DEFAULT 0,
+	size INTEGER NOT NULL DEFAULT 0,
+	token_hash VARCHAR(64) NOT NULL,
+	notify_chat_id BIGINT NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	started_at TIMESTAMP,
+	finished_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_user ON export_jobs (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs (status, created_at);
+
+CREATE TABLE IF NOT EXISTS export_files (
+	job_id UUID PRIMARY KEY REFERENCES export_jobs(id) ON DELETE CASCADE,
+	content BYTEA NOT NULL
+);
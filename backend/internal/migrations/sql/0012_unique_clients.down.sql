@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_clients_client_secret_unique;
+DROP INDEX IF EXISTS idx_clients_client_id_unique;
@@ -0,0 +1,6 @@
+-- CreateClient used to check for duplicates before inserting, which two
+-- concurrent requests could both pass. If this fails, find the duplicates with
+--   SELECT client_id, COUNT(*) FROM clients GROUP BY client_id HAVING COUNT(*) > 1;
+-- (and the same for client_secret) and remove the extra rows first.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_clients_client_id_unique ON clients (client_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_clients_client_secret_unique ON clients (client_secret);
@@ -0,0 +1,32 @@
+-- SQLite twins of the Postgres migrations in ../sql, one per version.
+-- Timestamps are TEXT in the driver's fixed-width UTC format and the
+-- autoincrement keys never reuse ids, like Postgres sequences.
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY,
+	first_name VARCHAR(255) NOT NULL,
+	last_name VARCHAR(255),
+	username VARCHAR(255),
+	language_code VARCHAR(10),
+	is_premium BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_data (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	data TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_data_user_id ON user_data(user_id);
+
+CREATE TABLE IF NOT EXISTS clients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id VARCHAR(255) NOT NULL,
+	client_secret VARCHAR(255) NOT NULL,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_clients_user_id ON clients(user_id);
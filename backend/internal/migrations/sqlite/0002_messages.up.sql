@@ -0,0 +1,12 @@
+-- Postgres compares UUIDs regardless of case; NOCASE keeps lookups the same.
+CREATE TABLE IF NOT EXISTS messages (
+	id TEXT PRIMARY KEY COLLATE NOCASE,
+	client_id VARCHAR(255) NOT NULL,
+	client_secret VARCHAR(255) NOT NULL,
+	message TEXT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	is_active BOOLEAN DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_messages_client_id ON messages(client_id);
@@ -0,0 +1,35 @@
+-- SQLite cannot drop a column with a foreign key, so clients and messages are
+-- rebuilt without workspace_id.
+CREATE TABLE messages_without_workspace (
+	id TEXT PRIMARY KEY COLLATE NOCASE,
+	client_id VARCHAR(255) NOT NULL,
+	client_secret VARCHAR(255) NOT NULL,
+	message TEXT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	is_active BOOLEAN DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+INSERT INTO messages_without_workspace (id, client_id, client_secret, message, name, is_active, created_at, updated_at)
+SELECT id, client_id, client_secret, message, name, is_active, created_at, updated_at FROM messages;
+DROP TABLE messages;
+ALTER TABLE messages_without_workspace RENAME TO messages;
+CREATE INDEX IF NOT EXISTS idx_messages_client_id ON messages(client_id);
+
+CREATE TABLE clients_without_workspace (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id VARCHAR(255) NOT NULL,
+	client_secret VARCHAR(255) NOT NULL,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+INSERT INTO clients_without_workspace (id, client_id, client_secret, user_id, created_at, updated_at)
+SELECT id, client_id, client_secret, user_id, created_at, updated_at FROM clients;
+DROP TABLE clients;
+ALTER TABLE clients_without_workspace RENAME TO clients;
+CREATE INDEX IF NOT EXISTS idx_clients_user_id ON clients(user_id);
+
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
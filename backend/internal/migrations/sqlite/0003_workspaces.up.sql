@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(255) NOT NULL,
+	owner_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	is_personal BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_personal_owner ON workspaces(owner_id) WHERE is_personal;
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+	workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role VARCHAR(16) NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+	token VARCHAR(64) PRIMARY KEY,
+	workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	role VARCHAR(16) NOT NULL,
+	created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL,
+	accepted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+	accepted_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE clients ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_clients_workspace_id ON clients(workspace_id);
+ALTER TABLE messages ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+
+INSERT INTO workspaces (name, owner_id, is_personal, created_at, updated_at)
+SELECT COALESCE(NULLIF(u.username, ''), u.first_name), u.id, TRUE,
+	strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now')
+FROM users u
+WHERE EXISTS (SELECT 1 FROM clients c WHERE c.user_id = u.id AND c.workspace_id IS NULL)
+ON CONFLICT (owner_id) WHERE is_personal DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT w.id, w.owner_id, 'owner', strftime('%Y-%m-%d %H:%M:%f', 'now')
+FROM workspaces w
+WHERE w.is_personal
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+UPDATE clients
+SET workspace_id = w.id
+FROM workspaces w
+WHERE clients.workspace_id IS NULL AND w.owner_id = clients.user_id AND w.is_personal;
+
+UPDATE messages
+SET workspace_id = c.workspace_id
+FROM clients c
+WHERE messages.workspace_id IS NULL AND c.client_id = messages.client_id AND c.workspace_id IS NOT NULL;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(32) NOT NULL UNIQUE,
+	hash VARCHAR(64) NOT NULL,
+	scopes TEXT NOT NULL,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+CREATE TABLE IF NOT EXISTS api_key_audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL,
+	method VARCHAR(16) NOT NULL,
+	path TEXT NOT NULL,
+	status_code INT NOT NULL,
+	remote_addr VARCHAR(255),
+	request_id VARCHAR(64),
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_audit_log_key_id ON api_key_audit_log(key_id, created_at);
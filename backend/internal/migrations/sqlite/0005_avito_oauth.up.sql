@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS avito_oauth_states (
+	state VARCHAR(64) PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS avito_connections (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	avito_user_id BIGINT NOT NULL,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	scope TEXT NOT NULL DEFAULT '',
+	token_type VARCHAR(32) NOT NULL,
+	access_token TEXT NOT NULL,
+	refresh_token TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	UNIQUE (user_id, avito_user_id)
+);
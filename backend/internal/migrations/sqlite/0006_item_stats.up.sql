@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS avito_item_stats_daily (
+	avito_user_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	date DATE NOT NULL,
+	uniq_views INTEGER NOT NULL DEFAULT 0,
+	uniq_contacts INTEGER NOT NULL DEFAULT 0,
+	uniq_favorites INTEGER NOT NULL DEFAULT 0,
+	fetched_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (avito_user_id, item_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS avito_item_replies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	avito_user_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL,
+	chat_id VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_avito_item_replies_item ON avito_item_replies (avito_user_id, item_id, created_at);
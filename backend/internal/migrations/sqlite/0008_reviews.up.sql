@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS avito_reviews (
+	id BIGINT NOT NULL,
+	avito_user_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL DEFAULT 0,
+	item_title TEXT NOT NULL DEFAULT '',
+	score INTEGER NOT NULL,
+	stage VARCHAR(32) NOT NULL DEFAULT '',
+	text TEXT NOT NULL DEFAULT '',
+	sender_name VARCHAR(255) NOT NULL DEFAULT '',
+	can_answer BOOLEAN NOT NULL DEFAULT FALSE,
+	answer_text TEXT NOT NULL DEFAULT '',
+	answer_status VARCHAR(32) NOT NULL DEFAULT '',
+	answered_by VARCHAR(16) NOT NULL DEFAULT '',
+	answered_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	synced_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (avito_user_id, id)
+);
+CREATE INDEX IF NOT EXISTS idx_avito_reviews_created ON avito_reviews (avito_user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS avito_review_sync (
+	avito_user_id BIGINT PRIMARY KEY,
+	synced_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS review_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+	text TEXT NOT NULL,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS review_settings (
+	user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	auto_answer BOOLEAN NOT NULL DEFAULT FALSE,
+	notify_negative BOOLEAN NOT NULL DEFAULT TRUE
+);
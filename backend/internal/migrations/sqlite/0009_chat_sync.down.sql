@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS avito_messages;
+DROP TABLE IF EXISTS avito_chats;
+DROP TABLE IF EXISTS avito_sync_state;
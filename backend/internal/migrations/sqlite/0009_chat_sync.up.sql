@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS avito_sync_state (
+	avito_user_id BIGINT PRIMARY KEY,
+	client_id VARCHAR(255) NOT NULL DEFAULT '',
+	chats_cursor BIGINT NOT NULL DEFAULT 0,
+	synced_at TIMESTAMP,
+	last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS avito_chats (
+	id VARCHAR(255) NOT NULL,
+	avito_user_id BIGINT NOT NULL,
+	item_id BIGINT NOT NULL DEFAULT 0,
+	updated BIGINT NOT NULL,
+	message_cursor BIGINT NOT NULL DEFAULT 0,
+	data TEXT NOT NULL,
+	synced_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (avito_user_id, id)
+);
+CREATE INDEX IF NOT EXISTS idx_avito_chats_updated ON avito_chats (avito_user_id, updated DESC);
+
+CREATE TABLE IF NOT EXISTS avito_messages (
+	id VARCHAR(255) NOT NULL,
+	avito_user_id BIGINT NOT NULL,
+	chat_id VARCHAR(255) NOT NULL,
+	author_id BIGINT NOT NULL DEFAULT 0,
+	direction VARCHAR(8) NOT NULL DEFAULT '',
+	type VARCHAR(32) NOT NULL DEFAULT '',
+	text TEXT NOT NULL DEFAULT '',
+	is_read BOOLEAN NOT NULL DEFAULT FALSE,
+	created BIGINT NOT NULL,
+	PRIMARY KEY (avito_user_id, id)
+);
+CREATE INDEX IF NOT EXISTS idx_avito_messages_chat ON avito_messages (avito_user_id, chat_id, created DESC);
@@ -0,0 +1,27 @@
+-- FTS5 stands in for the Postgres tsvector: unicode61 folds case for Russian
+-- and English alike but does not stem, so the search repository adds prefix
+-- matching. Rows carry the message key rather than the rowid, which VACUUM
+-- may renumber, and triggers keep them in step with avito_messages.
+CREATE VIRTUAL TABLE IF NOT EXISTS avito_messages_fts USING fts5(
+	text,
+	avito_user_id UNINDEXED,
+	id UNINDEXED,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS avito_messages_fts_insert AFTER INSERT ON avito_messages BEGIN
+	INSERT INTO avito_messages_fts (text, avito_user_id, id) VALUES (new.text, new.avito_user_id, new.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS avito_messages_fts_update AFTER UPDATE OF text ON avito_messages
+WHEN old.text IS NOT new.text BEGIN
+	DELETE FROM avito_messages_fts WHERE avito_user_id = old.avito_user_id AND id = old.id;
+	INSERT INTO avito_messages_fts (text, avito_user_id, id) VALUES (new.text, new.avito_user_id, new.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS avito_messages_fts_delete AFTER DELETE ON avito_messages BEGIN
+	DELETE FROM avito_messages_fts WHERE avito_user_id = old.avito_user_id AND id = old.id;
+END;
+
+INSERT INTO avito_messages_fts (text, avito_user_id, id)
+SELECT text, avito_user_id, id FROM avito_messages;
@@ -0,0 +1,156 @@
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/message"
+
+	"github.com/google/uuid"
+)
+
+// Messages runs the message.MessageRepository contract against the stores
+// from open.
+func Messages(t *testing.T, open Open) {
+	ctx := context.Background()
+
+	newMessage := func(t *testing.T, repo message.MessageRepository, clientID, secret string, workspaceID int64, minutesAgo int) *message.Message {
+		t.Helper()
+		m := &message.Message{
+			ID:           uuid.New().String(),
+			ClientID:     clientID,
+			ClientSecret: secret,
+			WorkspaceID:  workspaceID,
+			Message:      "text",
+			Name:         "name",
+			IsActive:     true,
+			CreatedAt:    at(minutesAgo),
+			UpdatedAt:    at(minutesAgo),
+		}
+		if err := repo.CreateMessage(ctx, m); err != nil {
+			t.Fatalf("CreateMessage: %v", err)
+		}
+		return m
+	}
+
+	t.Run("missing rows are nil", func(t *testing.T) {
+		repo := open(t).Messages
+
+		m, err := repo.GetMessageByID(ctx, uuid.New().String())
+		if err != nil || m != nil {
+			t.Errorf("GetMessageByID = %v, %v; want nil, nil", m, err)
+		}
+		m, err = repo.GetMessageByClientCredentials(ctx, "missing", "missing")
+		if err != nil || m != nil {
+			t.Errorf("GetMessageByClientCredentials = %v, %v; want nil, nil", m, err)
+		}
+		messages, err := repo.GetMessagesByClientID(ctx, "missing")
+		if err != nil || len(messages) != 0 {
+			t.Errorf("GetMessagesByClientID = %v, %v; want empty", messages, err)
+		}
+		if err := repo.DeleteMessage(ctx, uuid.New().String()); err != nil {
+			t.Errorf("DeleteMessage of a missing message = %v", err)
+		}
+	})
+
+	t.Run("lookups", func(t *testing.T) {
+		stores := open(t)
+		repo := stores.Messages
+		if err := stores.Users.CreateUser(ctx, newTestUser(1)); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		workspaceID := stores.Workspace(t, 1)
+
+		older := newMessage(t, repo, "client", "secret", 0, 20)
+		newer := newMessage(t, repo, "client", "secret", workspaceID, 10)
+		newMessage(t, repo, "client", "other-secret", 0, 5)
+		newMessage(t, repo, "other", "secret", 0, 1)
+
+		got, err := repo.GetMessageByID(ctx, older.ID)
+		if err != nil || got == nil || got.ClientID != "client" || got.WorkspaceID != 0 || !got.IsActive {
+			t.Errorf("GetMessageByID = %+v, %v", got, err)
+		}
+		if got, _ := repo.GetMessageByID(ctx, strings.ToUpper(older.ID)); got == nil || got.ID != older.ID {
+			t.Errorf("GetMessageByID with an upper-case id = %+v; ids are UUIDs and ignore case", got)
+		}
+
+		messages, _ := repo.GetMessagesByClientID(ctx, "client")
+		if len(messages) != 3 || messages[1].ID != newer.ID || messages[2].ID != older.ID {
+			t.Errorf("GetMessagesByClientID returned %d messages, want 3 newest first", len(messages))
+		}
+
+		got, _ = repo.GetMessageByClientCredentials(ctx, "client", "secret")
+		if got == nil || got.ID != newer.ID || got.WorkspaceID != workspaceID {
+			t.Errorf("GetMessageByClientCredentials = %+v; want the latest match", got)
+		}
+
+		got.Name = "changed by caller"
+		again, _ := repo.GetMessageByID(ctx, newer.ID)
+		if again.Name != "name" {
+			t.Error("changing a returned message changed the stored one")
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		stores := open(t)
+		repo := stores.Messages
+		if err := stores.Users.CreateUser(ctx, newTestUser(1)); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		workspaceID := stores.Workspace(t, 1)
+
+		m := newMessage(t, repo, "client", "secret", workspaceID, 10)
+		m.Message = "updated"
+		m.Name = "renamed"
+		m.IsActive = false
+		m.WorkspaceID = 0
+		m.UpdatedAt = at(0)
+		if err := repo.UpdateMessage(ctx, m); err != nil {
+			t.Fatalf("UpdateMessage: %v", err)
+		}
+
+		got, _ := repo.GetMessageByID(ctx, m.ID)
+		if got.Message != "updated" || got.Name != "renamed" || got.IsActive {
+			t.Errorf("message after UpdateMessage = %+v", got)
+		}
+		if got.WorkspaceID != workspaceID {
+			t.Errorf("UpdateMessage moved the message to workspace %d; it must keep %d", got.WorkspaceID, workspaceID)
+		}
+
+		if err := repo.DeleteMessage(ctx, m.ID); err != nil {
+			t.Fatalf("DeleteMessage: %v", err)
+		}
+		if got, _ := repo.GetMessageByID(ctx, m.ID); got != nil {
+			t.Errorf("message after DeleteMessage = %+v", got)
+		}
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		repo := open(t).Messages
+
+		newMessage(t, repo, "client", "secret", 0, 120)
+		newMessage(t, repo, "client", "secret", 0, 30)
+		newMessage(t, repo, "client", "secret", 0, 1)
+		newMessage(t, repo, "other", "secret", 0, 1)
+
+		if got, err := repo.CountMessagesByClientID(ctx, "client"); err != nil || got != 3 {
+			t.Errorf("CountMessagesByClientID = %d, %v; want 3", got, err)
+		}
+		if got, err := repo.CountMessagesByClientID(ctx, "missing"); err != nil || got != 0 {
+			t.Errorf("CountMessagesByClientID for a missing client = %d, %v; want 0", got, err)
+		}
+
+		ranges := map[time.Duration]int{
+			10 * time.Minute: 1,
+			time.Hour:        2,
+			24 * time.Hour:   3,
+		}
+		for duration, want := range ranges {
+			if got, err := repo.CountMessagesByClientIDInTimeRange(ctx, "client", duration); err != nil || got != want {
+				t.Errorf("CountMessagesByClientIDInTimeRange(%v) = %d, %v; want %d", duration, got, err, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Package repotest is the contract shared by the user and message
+// repositories. Every implementation runs the same suite, so the in-memory
+// ones used in tests cannot drift from what Postgres and SQLite do.
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/message"
+	"mini-app-backend/internal/migrations"
+	"mini-app-backend/internal/user"
+)
+
+// DSNEnv names the variable with the Postgres DSN for the SQL run of the
+// suite. The database is truncated before every test, so point it at a
+// throwaway database.
+const DSNEnv = "TEST_DATABASE_DSN"
+
+// Stores is one empty set of repositories for a single test.
+type Stores struct {
+	Users    user.UserRepository
+	Messages message.MessageRepository
+	// Workspace returns the id of a workspace owned by ownerID. The SQL
+	// stores need a real row behind workspace_id foreign keys.
+	Workspace func(t *testing.T, ownerID int64) int64
+}
+
+// Open returns fresh stores; it is called once per test.
+type Open func(t *testing.T) *Stores
+
+// Memory opens the in-memory repositories.
+func Memory(t *testing.T) *Stores {
+	var workspaceID atomic.Int64
+	return &Stores{
+		Users:    user.NewMemoryRepository(),
+		Messages: message.NewMemoryRepository(),
+		Workspace: func(t *testing.T, ownerID int64) int64 {
+			return workspaceID.Add(1)
+		},
+	}
+}
+
+// Postgres opens the SQL repositories on the database named by DSNEnv,
+// migrated and emptied. The test is skipped when the variable is not set.
+func Postgres(t *testing.T) *Stores {
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if err := migrations.Up(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `TRUNCATE users, messages RESTART IDENTITY CASCADE`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	return sqlStores(db)
+}
+
+// SQLite opens the SQL repositories on a fresh SQLite file.
+func SQLite(t *testing.T) *Stores {
+	return sqlStores(SQLiteDB(t))
+}
+
+// SQLiteDB opens a migrated SQLite database in a temporary file, the way
+// database.Open does for DATABASE_DRIVER=sqlite.
+func SQLiteDB(t *testing.T) *sql.DB {
+	ctx := context.Background()
+	db, err := database.Open(ctx, &config.Config{
+		DatabaseDriver:       string(database.SQLite),
+		SQLitePath:           filepath.Join(t.TempDir(), "test.db"),
+		SQLiteBusyTimeout:    5 * time.Second,
+		PostgresMaxOpenConns: 4,
+		PostgresMaxIdleConns: 4,
+		PostgresQueryTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrations.Up(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func sqlStores(db *sql.DB) *Stores {
+	return &Stores{
+		Users:    user.NewSQLRepository(db),
+		Messages: message.NewSQLMessageRepository(db),
+		Workspace: func(t *testing.T, ownerID int64) int64 {
+			var id int64
+			err := db.QueryRowContext(context.Background(), `
+				INSERT INTO workspaces (name, owner_id, is_personal, created_at, updated_at)
+				VALUES ('test', $1, FALSE, $2, $2)
+				RETURNING id
+			`, ownerID, at(0)).Scan(&id)
+			if err != nil {
+				t.Fatalf("create workspace: %v", err)
+			}
+			return id
+		},
+	}
+}
+
+// at returns distinct timestamps the way the services take them, from
+// time.Now, rounded to the microseconds a TIMESTAMP keeps in either database.
+func at(minutesAgo int) time.Time {
+	return time.Now().Truncate(time.Microsecond).Add(-time.Duration(minutesAgo) * time.Minute)
+}
+
+func newTestUser(id int64) *user.User {
+	return &user.User{ID: id, FirstName: "Ivan", Username: "ivan", CreatedAt: at(60), UpdatedAt: at(60)}
+}
@@ -0,0 +1,27 @@
+package repotest
+
+import "testing"
+
+func TestMemoryUsers(t *testing.T) {
+	Users(t, Memory)
+}
+
+func TestMemoryMessages(t *testing.T) {
+	Messages(t, Memory)
+}
+
+func TestPostgresUsers(t *testing.T) {
+	Users(t, Postgres)
+}
+
+func TestPostgresMessages(t *testing.T) {
+	Messages(t, Postgres)
+}
+
+func TestSQLiteUsers(t *testing.T) {
+	Users(t, SQLite)
+}
+
+func TestSQLiteMessages(t *testing.T) {
+	Messages(t, SQLite)
+}
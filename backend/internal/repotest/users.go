@@ -0,0 +1,291 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"mini-app-backend/internal/user"
+)
+
+// Users runs the user.UserRepository contract against the stores from open.
+func Users(t *testing.T, open Open) {
+	ctx := context.Background()
+
+	newUser := func(t *testing.T, repo user.UserRepository, id int64) *user.User {
+		t.Helper()
+		u := newTestUser(id)
+		if err := repo.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		return u
+	}
+
+	newClient := func(t *testing.T, repo user.UserRepository, userID, workspaceID int64, clientID string, minutesAgo int) *user.Client {
+		t.Helper()
+		c := &user.Client{
+			ClientID:     clientID,
+			ClientSecret: clientID + "-secret",
+			UserID:       userID,
+			WorkspaceID:  workspaceID,
+			CreatedAt:    at(minutesAgo),
+			UpdatedAt:    at(minutesAgo),
+		}
+		if err := repo.CreateClient(ctx, c); err != nil {
+			t.Fatalf("CreateClient: %v", err)
+		}
+		if c.ID == 0 {
+			t.Fatal("CreateClient did not assign an id")
+		}
+		return c
+	}
+
+	clientIDs := func(clients []*user.Client) []string {
+		var ids []string
+		for _, c := range clients {
+			ids = append(ids, c.ClientID)
+		}
+		return ids
+	}
+
+	t.Run("missing rows are nil", func(t *testing.T) {
+		repo := open(t).Users
+
+		u, err := repo.GetUserByID(ctx, 404)
+		if err != nil || u != nil {
+			t.Errorf("GetUserByID = %v, %v; want nil, nil", u, err)
+		}
+		data, err := repo.GetUserDataByUserID(ctx, 404)
+		if err != nil || data != nil {
+			t.Errorf("GetUserDataByUserID = %v, %v; want nil, nil", data, err)
+		}
+		c, err := repo.GetClientByID(ctx, "missing")
+		if err != nil || c != nil {
+			t.Errorf("GetClientByID = %v, %v; want nil, nil", c, err)
+		}
+		c, err = repo.GetClientByCredentials(ctx, "missing", "missing")
+		if err != nil || c != nil {
+			t.Errorf("GetClientByCredentials = %v, %v; want nil, nil", c, err)
+		}
+		clients, err := repo.GetClientsWithPagination(ctx, 10, 0)
+		if err != nil || len(clients) != 0 {
+			t.Errorf("GetClientsWithPagination = %v, %v; want empty", clients, err)
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		repo := open(t).Users
+		newUser(t, repo, 1)
+
+		if err := repo.CreateUser(ctx, &user.User{ID: 1, FirstName: "Again", CreatedAt: at(0), UpdatedAt: at(0)}); err == nil {
+			t.Error("CreateUser with a taken id succeeded")
+		}
+
+		if err := repo.UpdateUser(ctx, &user.User{ID: 1, FirstName: "Pyotr", IsPremium: true, UpdatedAt: at(0)}); err != nil {
+			t.Fatalf("UpdateUser: %v", err)
+		}
+		got, err := repo.GetUserByTelegramID(ctx, 1)
+		if err != nil || got == nil {
+			t.Fatalf("GetUserByTelegramID = %v, %v", got, err)
+		}
+		if got.FirstName != "Pyotr" || !got.IsPremium || got.Username != "" {
+			t.Errorf("user after UpdateUser = %+v", got)
+		}
+
+		got.FirstName = "changed by caller"
+		again, _ := repo.GetUserByID(ctx, 1)
+		if again.FirstName != "Pyotr" {
+			t.Error("changing a returned user changed the stored one")
+		}
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		repo := open(t).Users
+
+		first := &user.User{ID: 7, FirstName: "Anna", CreatedAt: at(60), UpdatedAt: at(60)}
+		created, err := repo.UpsertUser(ctx, first)
+		if err != nil || !created {
+			t.Fatalf("first UpsertUser = %v, %v; want created", created, err)
+		}
+		stored, _ := repo.GetUserByID(ctx, 7)
+
+		second := &user.User{ID: 7, FirstName: "Anya", Username: "anya", CreatedAt: at(0), UpdatedAt: at(0)}
+		created, err = repo.UpsertUser(ctx, second)
+		if err != nil || created {
+			t.Fatalf("second UpsertUser = %v, %v; want updated", created, err)
+		}
+		if !second.CreatedAt.Equal(stored.CreatedAt) {
+			t.Errorf("UpsertUser wrote back created_at %v, want the original %v", second.CreatedAt, stored.CreatedAt)
+		}
+
+		got, _ := repo.GetUserByID(ctx, 7)
+		if got.FirstName != "Anya" || got.Username != "anya" || !got.CreatedAt.Equal(stored.CreatedAt) {
+			t.Errorf("user after upsert = %+v", got)
+		}
+	})
+
+	t.Run("user data", func(t *testing.T) {
+		repo := open(t).Users
+
+		if err := repo.CreateUserData(ctx, &user.UserData{UserID: 404, Data: "{}", CreatedAt: at(0), UpdatedAt: at(0)}); err == nil {
+			t.Error("CreateUserData for a missing user succeeded")
+		}
+
+		newUser(t, repo, 1)
+		older := &user.UserData{UserID: 1, Data: "older", CreatedAt: at(10), UpdatedAt: at(10)}
+		newer := &user.UserData{UserID: 1, Data: "newer", CreatedAt: at(5), UpdatedAt: at(5)}
+		for _, data := range []*user.UserData{newer, older} {
+			if err := repo.CreateUserData(ctx, data); err != nil {
+				t.Fatalf("CreateUserData: %v", err)
+			}
+		}
+		if older.ID == 0 || older.ID == newer.ID {
+			t.Fatalf("CreateUserData ids = %d, %d", newer.ID, older.ID)
+		}
+
+		got, err := repo.GetUserDataByUserID(ctx, 1)
+		if err != nil || got == nil || got.Data != "newer" {
+			t.Fatalf("GetUserDataByUserID = %+v, %v; want the latest", got, err)
+		}
+
+		got.Data = "updated"
+		got.UpdatedAt = at(0)
+		if err := repo.UpdateUserData(ctx, got); err != nil {
+			t.Fatalf("UpdateUserData: %v", err)
+		}
+		got, _ = repo.GetUserDataByUserID(ctx, 1)
+		if got.Data != "updated" || got.ID != newer.ID {
+			t.Errorf("user data after update = %+v", got)
+		}
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		stores := open(t)
+		repo := stores.Users
+
+		if err := repo.CreateClient(ctx, &user.Client{ClientID: "x", ClientSecret: "y", UserID: 404, CreatedAt: at(0), UpdatedAt: at(0)}); err == nil {
+			t.Error("CreateClient for a missing user succeeded")
+		}
+
+		newUser(t, repo, 1)
+		newUser(t, repo, 2)
+		workspaceID := stores.Workspace(t, 1)
+
+		old := newClient(t, repo, 1, 0, "old", 30)
+		newClient(t, repo, 1, workspaceID, "new", 10)
+		newClient(t, repo, 2, workspaceID, "other", 20)
+
+		if err := repo.CreateClient(ctx, &user.Client{ClientID: "old", ClientSecret: "fresh", UserID: 1, CreatedAt: at(0), UpdatedAt: at(0)}); err != user.ErrClientIDExists {
+			t.Errorf("CreateClient with a taken client_id = %v, want ErrClientIDExists", err)
+		}
+		if err := repo.CreateClient(ctx, &user.Client{ClientID: "fresh", ClientSecret: "old-secret", UserID: 1, CreatedAt: at(0), UpdatedAt: at(0)}); err != user.ErrClientSecretExists {
+			t.Errorf("CreateClient with a taken client_secret = %v, want ErrClientSecretExists", err)
+		}
+
+		got, err := repo.GetClientByUserID(ctx, 1)
+		if err != nil || got == nil || got.ClientID != "new" {
+			t.Errorf("GetClientByUserID = %+v, %v; want the latest client", got, err)
+		}
+		got, _ = repo.GetClientByCredentials(ctx, "old", "old-secret")
+		if got == nil || got.ID != old.ID || got.WorkspaceID != 0 {
+			t.Errorf("GetClientByCredentials = %+v", got)
+		}
+		if got, _ := repo.GetClientByCredentials(ctx, "old", "other-secret"); got != nil {
+			t.Errorf("GetClientByCredentials with a wrong secret = %+v", got)
+		}
+		if got, _ := repo.GetClientBySecret(ctx, "new-secret"); got == nil || got.WorkspaceID != workspaceID {
+			t.Errorf("GetClientBySecret = %+v", got)
+		}
+
+		all, _ := repo.GetClientsWithPagination(ctx, 10, 0)
+		if ids := clientIDs(all); len(ids) != 3 || ids[0] != "new" || ids[1] != "other" || ids[2] != "old" {
+			t.Errorf("GetClientsWithPagination = %v, want newest first", ids)
+		}
+		pageTwo, _ := repo.GetClientsWithPagination(ctx, 2, 2)
+		if ids := clientIDs(pageTwo); len(ids) != 1 || ids[0] != "old" {
+			t.Errorf("second page = %v", ids)
+		}
+		if beyond, _ := repo.GetClientsWithPagination(ctx, 2, 10); len(beyond) != 0 {
+			t.Errorf("page past the end = %v", clientIDs(beyond))
+		}
+
+		byUser, _ := repo.GetClientsByUserIDWithPagination(ctx, 1, 1, 1)
+		if ids := clientIDs(byUser); len(ids) != 1 || ids[0] != "old" {
+			t.Errorf("GetClientsByUserIDWithPagination = %v", ids)
+		}
+		byWorkspace, _ := repo.GetClientsByWorkspaceIDWithPagination(ctx, workspaceID, 10, 0)
+		if ids := clientIDs(byWorkspace); len(ids) != 2 || ids[0] != "new" || ids[1] != "other" {
+			t.Errorf("GetClientsByWorkspaceIDWithPagination = %v", ids)
+		}
+
+		counts := []struct {
+			name string
+			fn   func() (int, error)
+			want int
+		}{
+			{"GetClientsCount", func() (int, error) { return repo.GetClientsCount(ctx) }, 3},
+			{"GetClientsCountByUserID", func() (int, error) { return repo.GetClientsCountByUserID(ctx, 1) }, 2},
+			{"GetClientsCountByUserID missing", func() (int, error) { return repo.GetClientsCountByUserID(ctx, 404) }, 0},
+			{"GetClientsCountByWorkspaceID", func() (int, error) { return repo.GetClientsCountByWorkspaceID(ctx, workspaceID) }, 2},
+			{"GetClientsCountByWorkspaceID unassigned", func() (int, error) { return repo.GetClientsCountByWorkspaceID(ctx, 0) }, 0},
+		}
+		for _, count := range counts {
+			if got, err := count.fn(); err != nil || got != count.want {
+				t.Errorf("%s = %d, %v; want %d", count.name, got, err, count.want)
+			}
+		}
+
+		old.ClientSecret = "rotated"
+		old.WorkspaceID = workspaceID
+		old.UpdatedAt = at(0)
+		if err := repo.UpdateClient(ctx, old); err != nil {
+			t.Fatalf("UpdateClient: %v", err)
+		}
+		got, _ = repo.GetClientByID(ctx, "old")
+		if got.ClientSecret != "rotated" || got.WorkspaceID != workspaceID {
+			t.Errorf("client after UpdateClient = %+v", got)
+		}
+
+		old.ClientID = "new"
+		if err := repo.UpdateClient(ctx, old); err != user.ErrClientIDExists {
+			t.Errorf("UpdateClient to a taken client_id = %v, want ErrClientIDExists", err)
+		}
+	})
+	t.Run("concurrent creates", func(t *testing.T) {
+		repo := open(t).Users
+		newUser(t, repo, 1)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- repo.CreateClient(ctx, &user.Client{
+					ClientID:     "shared",
+					ClientSecret: fmt.Sprintf("secret-%d", i),
+					UserID:       1,
+					CreatedAt:    at(0),
+					UpdatedAt:    at(0),
+				})
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch err {
+			case nil:
+				created++
+			case user.ErrClientIDExists:
+			default:
+				t.Errorf("CreateClient = %v", err)
+			}
+		}
+		if created != 1 {
+			t.Errorf("%d concurrent creates of one client_id succeeded, want 1", created)
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package reviews
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+const reviewColumns = `id, avito_user_id, item_id, item_title, score, stage, text, sender_name, can_answer, answer_text, answer_status, answered_by, answered_at, created_at, synced_at`
+
+func scanReview(scan func(dest ...interface{}) error) (*Review, error) {
+	review := &Review{}
+	var answeredAt sql.NullTime
+	err := scan(
+		&review.ID,
+		&review.AvitoUserID,
+		&review.ItemID,
+		&review.ItemTitle,
+		&review.Score,
+		&review.Stage,
+		&review.Text,
+		&review.SenderName,
+		&review.CanAnswer,
+		&review.AnswerText,
+		&review.AnswerStatus,
+		&review.AnsweredBy,
+		&answeredAt,
+		&review.CreatedAt,
+		&review.SyncedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if answeredAt.Valid {
+		review.AnsweredAt = &answeredAt.Time
+	}
+
+	return review, nil
+}
+
+// UpsertReview refreshes Avito-owned fields but keeps local answer metadata.
+// It reports whether the review is new; only Postgres can tell that from a
+// single upsert, so the insert and the update are separate statements.
+func (r *SQLRepository) UpsertReview(review *Review) (bool, error) {
+	insert := `
+		INSERT INTO avito_reviews (id, avito_user_id, item_id, item_title, score, stage, text, sender_name, can_answer, answer_text, answer_status, created_at, synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (avito_user_id, id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(insert,
+		review.ID, review.AvitoUserID, review.ItemID, review.ItemTitle, review.Score, review.Stage,
+		review.Text, review.SenderName, review.CanAnswer, review.AnswerText, review.AnswerStatus,
+		review.CreatedAt, review.SyncedAt,
+	)
+	if err != nil {
+		log.Printf("Error upserting review: %v", err)
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error upserting review: %v", err)
+		return false, err
+	}
+	if inserted == 1 {
+		return true, nil
+	}
+
+	update := `
+		UPDATE avito_reviews SET
+			item_id = $3,
+			item_title = $4,
+			score = $5,
+			stage = $6,
+			text = $7,
+			sender_name = $8,
+			can_answer = $9,
+			answer_text = CASE WHEN $10 <> '' THEN $10 ELSE answer_text END,
+			answer_status = CASE WHEN $11 <> '' THEN $11 ELSE answer_status END,
+			synced_at = $12
+		WHERE avito_user_id = $1 AND id = $2
+	`
+
+	_, err = r.db.Exec(update,
+		review.AvitoUserID, review.ID, review.ItemID, review.ItemTitle, review.Score, review.Stage,
+		review.Text, review.SenderName, review.CanAnswer, review.AnswerText, review.AnswerStatus,
+		review.SyncedAt,
+	)
+	if err != nil {
+		log.Printf("Error upserting review: %v", err)
+		return false, err
+	}
+
+	return false, nil
+}
+
+func (r *SQLRepository) GetReview(avitoUserID, id int64) (*Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM avito_reviews WHERE avito_user_id = $1 AND id = $2`
+
+	review, err := scanReview(r.db.QueryRow(query, avitoUserID, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting review: %v", err)
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *SQLRepository) ListReviews(avitoUserID int64, filter Filter) ([]*Review, int, error) {
+	conditions := []string{"avito_user_id = $1"}
+	args := []interface{}{avitoUserID}
+
+	if filter.Score > 0 {
+		args = append(args, filter.Score)
+		conditions = append(conditions, fmt.Sprintf("score = $%d", len(args)))
+	}
+	if filter.ItemID > 0 {
+		args = append(args, filter.ItemID)
+		conditions = append(conditions, fmt.Sprintf("item_id = $%d", len(args)))
+	}
+	if filter.Answered != nil {
+		if *filter.Answered {
+			conditions = append(conditions, "answer_text <> ''")
+		} else {
+			conditions = append(conditions, "answer_text = ''")
+		}
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM avito_reviews WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		log.Printf("Error counting reviews: %v", err)
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM avito_reviews WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`,
+		reviewColumns, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Error listing reviews: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []*Review{}
+	for rows.Next() {
+		review, err := scanReview(rows.Scan)
+		if err != nil {
+			log.Printf("Error scanning review: %v", err)
+			return nil, 0, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+func (r *SQLRepository) SaveAnswer(avitoUserID, id int64, text, status, answeredBy string, answeredAt time.Time) error {
+	query := `
+		UPDATE avito_reviews
+		SET answer_text = $3, answer_status = $4, answered_by = $5, answered_at = $6, can_answer = FALSE
+		WHERE avito_user_id = $1 AND id = $2
+	`
+
+	_, err := r.db.Exec(query, avitoUserID, id, text, status, answeredBy, answeredAt)
+	if err != nil {
+		log.Printf("Error saving review answer: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetLastSync(avitoUserID int64) (*time.Time, error) {
+	var syncedAt time.Time
+	err := r.db.QueryRow(`SELECT synced_at FROM avito_review_sync WHERE avito_user_id = $1`, avitoUserID).Scan(&syncedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting review sync state: %v", err)
+		return nil, err
+	}
+
+	return &syncedAt, nil
+}
+
+func (r *SQLRepository) SetLastSync(avitoUserID int64, syncedAt time.Time) error {
+	query := `
+		INSERT INTO avito_review_sync (avito_user_id, synced_at)
+		VALUES ($1, $2)
+		ON CONFLICT (avito_user_id) DO UPDATE SET synced_at = EXCLUDED.synced_at
+	`
+
+	_, err := r.db.Exec(query, avitoUserID, syncedAt)
+	if err != nil {
+		log.Printf("Error saving review sync state: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) CreateTemplate(template *Template) error {
+	query := `
+		INSERT INTO review_templates (user_id, rating, text, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.QueryRow(query, template.UserID, template.Rating, template.Text, template.IsActive,
+		template.CreatedAt, template.UpdatedAt).Scan(&template.ID)
+	if err != nil {
+		log.Printf("Error creating review template: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) queryTemplates(query string, args ...interface{}) ([]*Template, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Error getting review templates: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*Template{}
+	for rows.Next() {
+		template := &Template{}
+		err := rows.Scan(&template.ID, &template.UserID, &template.Rating, &template.Text,
+			&template.IsActive, &template.CreatedAt, &template.UpdatedAt)
+		if err != nil {
+			log.Printf("Error scanning review template: %v", err)
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *SQLRepository) GetTemplatesByUserID(userID int64) ([]*Template, error) {
+	return r.queryTemplates(`
+		SELECT id, user_id, rating, text, is_active, created_at, updated_at
+		FROM review_templates
+		WHERE user_id = $1
+		ORDER BY rating, id
+	`, userID)
+}
+
+func (r *SQLRepository) GetActiveTemplatesByRating(userID int64, rating int) ([]*Template, error) {
+	return r.queryTemplates(`
+		SELECT id, user_id, rating, text, is_active, created_at, updated_at
+		FROM review_templates
+		WHERE user_id = $1 AND rating = $2 AND is_active = TRUE
+		ORDER BY id
+	`, userID, rating)
+}
+
+func (r *SQLRepository) UpdateTemplate(template *Template) (bool, error) {
+	query := `
+		UPDATE review_templates
+		SET rating = $3, text = $4, is_active = $5, updated_at = $6
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.Exec(query, template.ID, template.UserID, template.Rating, template.Text,
+		template.IsActive, template.UpdatedAt)
+	if err != nil {
+		log.Printf("Error updating review template: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *SQLRepository) DeleteTemplate(userID, id int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM review_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting review template: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *SQLRepository) GetSettings(userID int64) (*Settings, error) {
+	settings := &Settings{}
+	err := r.db.QueryRow(`SELECT user_id, auto_answer, notify_negative FROM review_settings WHERE user_id = $1`, userID).
+		Scan(&settings.UserID, &settings.AutoAnswer, &settings.NotifyNegative)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting review settings: %v", err)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *SQLRepository) SaveSettings(settings *Settings) error {
+	query := `
+		INSERT INTO review_settings (user_id, auto_answer, notify_negative)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			auto_answer = EXCLUDED.auto_answer,
+			notify_negative = EXCLUDED.notify_negative
+	`
+
+	_, err := r.db.Exec(query, settings.UserID, settings.AutoAnswer, settings.NotifyNegative)
+	if err != nil {
+		log.Printf("Error saving review settings: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,455 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mini-app-backend/internal/avito"
+	"mini-app-backend/internal/errors"
+	"mini-app-backend/internal/events"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	AnsweredByManual = "manual"
+	AnsweredByAuto   = "auto"
+
+	// NegativeScore is the highest rating that triggers a bot notification.
+	NegativeScore = 2
+
+	syncPageSize = 50
+	syncMaxPages = 20
+	// syncStaleAfter lets listing requests refresh the local copy lazily.
+	syncStaleAfter  = 5 * time.Minute
+	maxAnswerLength = 1000
+)
+
+var (
+	ErrReviewNotFound   = errors.NewAppError(http.StatusNotFound, "Review not found")
+	ErrCannotAnswer     = errors.NewAppError(http.StatusConflict, "Review cannot be answered")
+	ErrInvalidAnswer    = errors.NewAppError(http.StatusBadRequest, "Answer text is required and must be at most 1000 characters")
+	ErrInvalidRating    = errors.NewAppError(http.StatusBadRequest, "Rating must be between 1 and 5")
+	ErrTemplateNotFound = errors.NewAppError(http.StatusNotFound, "Review template not found")
+)
+
+// Review is the local copy of an Avito review, keyed by Avito's own ID.
+type Review struct {
+	ID           int64      `json:"id" db:"id"`
+	AvitoUserID  int64      `json:"avito_user_id" db:"avito_user_id"`
+	ItemID       int64      `json:"item_id" db:"item_id"`
+	ItemTitle    string     `json:"item_title" db:"item_title"`
+	Score        int        `json:"score" db:"score"`
+	Stage        string     `json:"stage" db:"stage"`
+	Text         string     `json:"text" db:"text"`
+	SenderName   string     `json:"sender_name" db:"sender_name"`
+	CanAnswer    bool       `json:"can_answer" db:"can_answer"`
+	AnswerText   string     `json:"answer_text,omitempty" db:"answer_text"`
+	AnswerStatus string     `json:"answer_status,omitempty" db:"answer_status"`
+	AnsweredBy   string     `json:"answered_by,omitempty" db:"answered_by"`
+	AnsweredAt   *time.Time `json:"answered_at,omitempty" db:"answered_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	SyncedAt     time.Time  `json:"synced_at" db:"synced_at"`
+}
+
+// Template is a canned answer for reviews with a given star rating. It is kept
+// apart from the chat autoresponder messages.
+type Template struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Rating    int       `json:"rating" db:"rating"`
+	Text      string    `json:"text" db:"text"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Settings struct {
+	UserID         int64 `json:"user_id" db:"user_id"`
+	AutoAnswer     bool  `json:"auto_answer" db:"auto_answer"`
+	NotifyNegative bool  `json:"notify_negative" db:"notify_negative"`
+}
+
+type Filter struct {
+	Score    int
+	Answered *bool
+	ItemID   int64
+	Limit    int
+	Offset   int
+}
+
+type SyncResult struct {
+	Fetched      int       `json:"fetched"`
+	New          int       `json:"new"`
+	AutoAnswered int       `json:"auto_answered"`
+	Notified     int       `json:"notified"`
+	SyncedAt     time.Time `json:"synced_at"`
+}
+
+type Repository interface {
+	// UpsertReview stores the review and reports whether it was new.
+	UpsertReview(review *Review) (bool, error)
+	GetReview(avitoUserID, id int64) (*Review, error)
+	ListReviews(avitoUserID int64, filter Filter) ([]*Review, int, error)
+	SaveAnswer(avitoUserID, id int64, text, status, answeredBy string, answeredAt time.Time) error
+	GetLastSync(avitoUserID int64) (*time.Time, error)
+	SetLastSync(avitoUserID int64, syncedAt time.Time) error
+
+	CreateTemplate(template *Template) error
+	GetTemplatesByUserID(userID int64) ([]*Template, error)
+	GetActiveTemplatesByRating(userID int64, rating int) ([]*Template, error)
+	UpdateTemplate(template *Template) (bool, error)
+	DeleteTemplate(userID, id int64) (bool, error)
+
+	GetSettings(userID int64) (*Settings, error)
+	SaveSettings(settings *Settings) error
+}
+
+type Client interface {
+	ListReviews(ctx context.Context, offset, limit int) (*avito.ReviewsResponse, error)
+	AnswerReview(ctx context.Context, reviewID int64, text string) (*avito.ReviewAnswer, error)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, chatID int64, text string) error
+}
+
+type Publisher interface {
+	Publish(userID int64, eventType events.Type, data interface{})
+}
+
+// ReviewEvent reports an automatic answer and its outcome.
+type ReviewEvent struct {
+	AvitoUserID int64  `json:"avito_user_id"`
+	ReviewID    int64  `json:"review_id"`
+	ItemID      int64  `json:"item_id,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type TemplateEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+}
+
+type Service struct {
+	repo     Repository
+	client   Client
+	notifier Notifier
+	events   Publisher
+	now      func() time.Time
+}
+
+// NewService builds the reviews service; notifier and events may be nil.
+func NewService(repo Repository, client Client, notifier Notifier, events Publisher) *Service {
+	return &Service{
+		repo:     repo,
+		client:   client,
+		notifier: notifier,
+		events:   events,
+		now:      time.Now,
+	}
+}
+
+// SyncIfStale refreshes the account's reviews unless that happened recently.
+func (s *Service) SyncIfStale(ctx context.Context, userID, avitoUserID int64) error {
+	lastSync, err := s.repo.GetLastSync(avitoUserID)
+	if err != nil {
+		return err
+	}
+
+	if lastSync != nil && s.now().Sub(*lastSync) < syncStaleAfter {
+		return nil
+	}
+
+	_, err = s.Sync(ctx, userID, avitoUserID)
+	return err
+}
+
+// Sync pulls reviews newest first and stops at the first page that brings
+// nothing new. New reviews get an automatic answer and a bot notification
+// according to the user's settings, except on the account's first import so
+// that the whole history is not answered or announced at once.
+func (s *Service) Sync(ctx context.Context, userID, avitoUserID int64) (*SyncResult, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastSync, err := s.repo.GetLastSync(avitoUserID)
+	if err != nil {
+		return nil, err
+	}
+	initial := lastSync == nil
+
+	now := s.now()
+	result := &SyncResult{SyncedAt: now}
+	var fresh []*Review
+
+	for page := 0; page < syncMaxPages; page++ {
+		response, err := s.client.ListReviews(ctx, page*syncPageSize, syncPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		newOnPage := 0
+		for _, remote := range response.Reviews {
+			review := fromAvito(avitoUserID, remote, now)
+			isNew, err := s.repo.UpsertReview(review)
+			if err != nil {
+				return nil, err
+			}
+			result.Fetched++
+			if isNew {
+				newOnPage++
+				fresh = append(fresh, review)
+			}
+		}
+
+		if newOnPage == 0 || len(response.Reviews) < syncPageSize {
+			break
+		}
+	}
+
+	result.New = len(fresh)
+	if initial {
+		fresh = nil
+	}
+
+	for _, review := range fresh {
+		if settings.AutoAnswer && review.CanAnswer && review.AnswerText == "" {
+			answered, err := s.autoAnswer(ctx, userID, review)
+			if err != nil {
+				log.Printf("Error auto-answering review %d: %v", review.ID, err)
+			} else if answered {
+				result.AutoAnswered++
+			}
+		}
+
+		if settings.NotifyNegative && review.Score > 0 && review.Score <= NegativeScore && s.notifier != nil {
+			if err := s.notifier.Notify(ctx, userID, negativeReviewText(review)); err != nil {
+				log.Printf("Error notifying about review %d: %v", review.ID, err)
+			} else {
+				result.Notified++
+			}
+		}
+	}
+
+	if err := s.repo.SetLastSync(avitoUserID, now); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func fromAvito(avitoUserID int64, remote avito.Review, syncedAt time.Time) *Review {
+	review := &Review{
+		ID:          remote.ID,
+		AvitoUserID: avitoUserID,
+		Score:       remote.Score,
+		Stage:       remote.Stage,
+		Text:        remote.Text,
+		CanAnswer:   remote.CanAnswer,
+		CreatedAt:   time.Unix(remote.CreatedAt, 0),
+		SyncedAt:    syncedAt,
+	}
+
+	if remote.Item != nil {
+		review.ItemID = remote.Item.ID
+		review.ItemTitle = remote.Item.Title
+	}
+	if remote.Sender != nil {
+		review.SenderName = remote.Sender.Name
+	}
+	if remote.Answer != nil {
+		review.AnswerText = remote.Answer.Text
+		review.AnswerStatus = remote.Answer.Status
+	}
+
+	return review
+}
+
+// autoAnswer picks one of the active templates for the review's rating. The
+// choice is keyed by review ID so that answers vary but stay reproducible.
+func (s *Service) autoAnswer(ctx context.Context, userID int64, review *Review) (bool, error) {
+	templates, err := s.repo.GetActiveTemplatesByRating(userID, review.Score)
+	if err != nil || len(templates) == 0 {
+		return false, err
+	}
+
+	template := templates[review.ID%int64(len(templates))]
+	event := ReviewEvent{AvitoUserID: review.AvitoUserID, ReviewID: review.ID, ItemID: review.ItemID, Text: template.Text}
+
+	if err := s.answer(ctx, review, template.Text, AnsweredByAuto); err != nil {
+		event.Error = "Avito did not accept the answer"
+		s.publish(userID, events.TypeDeliveryFailed, event)
+		return false, err
+	}
+
+	s.publish(userID, events.TypeAutoReplySent, event)
+	return true, nil
+}
+
+func (s *Service) publish(userID int64, eventType events.Type, data interface{}) {
+	if s.events != nil {
+		s.events.Publish(userID, eventType, data)
+	}
+}
+
+func (s *Service) answer(ctx context.Context, review *Review, text, answeredBy string) error {
+	answer, err := s.client.AnswerReview(ctx, review.ID, text)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.repo.SaveAnswer(review.AvitoUserID, review.ID, text, answer.Status, answeredBy, now); err != nil {
+		return err
+	}
+
+	review.AnswerText = text
+	review.AnswerStatus = answer.Status
+	review.AnsweredBy = answeredBy
+	review.AnsweredAt = &now
+	review.CanAnswer = false
+
+	return nil
+}
+
+func negativeReviewText(review *Review) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠️ Новый отзыв с оценкой %d★", review.Score)
+	if review.ItemTitle != "" {
+		fmt.Fprintf(&b, " на «%s»", review.ItemTitle)
+	}
+	if review.SenderName != "" {
+		fmt.Fprintf(&b, " от %s", review.SenderName)
+	}
+	if review.Text != "" {
+		fmt.Fprintf(&b, "\n\n%s", review.Text)
+	}
+	return b.String()
+}
+
+func (s *Service) ListReviews(avitoUserID int64, filter Filter) ([]*Review, int, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.ListReviews(avitoUserID, filter)
+}
+
+func (s *Service) AnswerReview(ctx context.Context, avitoUserID, reviewID int64, text string) (*Review, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxAnswerLength {
+		return nil, ErrInvalidAnswer
+	}
+
+	review, err := s.repo.GetReview(avitoUserID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrReviewNotFound
+	}
+	if !review.CanAnswer || review.AnswerText != "" {
+		return nil, ErrCannotAnswer
+	}
+
+	if err := s.answer(ctx, review, text, AnsweredByManual); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (s *Service) GetTemplates(userID int64) ([]*Template, error) {
+	return s.repo.GetTemplatesByUserID(userID)
+}
+
+func (s *Service) CreateTemplate(userID int64, rating int, text string) (*Template, error) {
+	if err := validateTemplate(rating, text); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	template := &Template{
+		UserID:    userID,
+		Rating:    rating,
+		Text:      strings.TrimSpace(text),
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	s.publish(userID, events.TypeTemplateChanged, TemplateEvent{ID: template.ID, Action: "created"})
+	return template, nil
+}
+
+func (s *Service) UpdateTemplate(template *Template) error {
+	if err := validateTemplate(template.Rating, template.Text); err != nil {
+		return err
+	}
+
+	template.Text = strings.TrimSpace(template.Text)
+	template.UpdatedAt = s.now()
+
+	updated, err := s.repo.UpdateTemplate(template)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTemplateNotFound
+	}
+
+	s.publish(template.UserID, events.TypeTemplateChanged, TemplateEvent{ID: template.ID, Action: "updated"})
+	return nil
+}
+
+func (s *Service) DeleteTemplate(userID, id int64) error {
+	deleted, err := s.repo.DeleteTemplate(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTemplateNotFound
+	}
+
+	s.publish(userID, events.TypeTemplateChanged, TemplateEvent{ID: id, Action: "deleted"})
+	return nil
+}
+
+func validateTemplate(rating int, text string) error {
+	if rating < 1 || rating > 5 {
+		return ErrInvalidRating
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > maxAnswerLength {
+		return ErrInvalidAnswer
+	}
+	return nil
+}
+
+// GetSettings falls back to notifications on and auto answers off for users
+// who never changed them.
+func (s *Service) GetSettings(userID int64) (*Settings, error) {
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &Settings{UserID: userID, NotifyNegative: true}
+	}
+	return settings, nil
+}
+
+func (s *Service) SaveSettings(settings *Settings) error {
+	return s.repo.SaveSettings(settings)
+}
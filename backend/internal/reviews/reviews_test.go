@@ -0,0 +1,183 @@
+package reviews
+
+import (
+	"context"
+	"mini-app-backend/internal/avito"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	reviews   map[int64]*Review
+	lastSync  *time.Time
+	templates []*Template
+	settings  *Settings
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{reviews: make(map[int64]*Review)}
+}
+
+func (m *memoryRepository) UpsertReview(review *Review) (bool, error) {
+	_, exists := m.reviews[review.ID]
+	copied := *review
+	m.reviews[review.ID] = &copied
+	return !exists, nil
+}
+
+func (m *memoryRepository) GetReview(avitoUserID, id int64) (*Review, error) {
+	review, ok := m.reviews[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *review
+	return &copied, nil
+}
+
+func (m *memoryRepository) ListReviews(avitoUserID int64, filter Filter) ([]*Review, int, error) {
+	return nil, len(m.reviews), nil
+}
+
+func (m *memoryRepository) SaveAnswer(avitoUserID, id int64, text, status, answeredBy string, answeredAt time.Time) error {
+	m.reviews[id].AnswerText = text
+	m.reviews[id].AnsweredBy = answeredBy
+	m.reviews[id].CanAnswer = false
+	return nil
+}
+
+func (m *memoryRepository) GetLastSync(avitoUserID int64) (*time.Time, error) { return m.lastSync, nil }
+
+func (m *memoryRepository) SetLastSync(avitoUserID int64, syncedAt time.Time) error {
+	m.lastSync = &syncedAt
+	return nil
+}
+
+func (m *memoryRepository) CreateTemplate(template *Template) error {
+	template.ID = int64(len(m.templates) + 1)
+	m.templates = append(m.templates, template)
+	return nil
+}
+
+func (m *memoryRepository) GetTemplatesByUserID(userID int64) ([]*Template, error) {
+	return m.templates, nil
+}
+
+func (m *memoryRepository) GetActiveTemplatesByRating(userID int64, rating int) ([]*Template, error) {
+	var templates []*Template
+	for _, template := range m.templates {
+		if template.Rating == rating && template.IsActive {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+func (m *memoryRepository) UpdateTemplate(template *Template) (bool, error) { return true, nil }
+
+func (m *memoryRepository) DeleteTemplate(userID, id int64) (bool, error) { return true, nil }
+
+func (m *memoryRepository) GetSettings(userID int64) (*Settings, error) { return m.settings, nil }
+
+func (m *memoryRepository) SaveSettings(settings *Settings) error {
+	m.settings = settings
+	return nil
+}
+
+type fakeClient struct {
+	reviews []avito.Review
+	answers map[int64]string
+}
+
+func (f *fakeClient) ListReviews(ctx context.Context, offset, limit int) (*avito.ReviewsResponse, error) {
+	response := &avito.ReviewsResponse{Total: len(f.reviews)}
+	if offset < len(f.reviews) {
+		end := offset + limit
+		if end > len(f.reviews) {
+			end = len(f.reviews)
+		}
+		response.Reviews = f.reviews[offset:end]
+	}
+	return response, nil
+}
+
+func (f *fakeClient) AnswerReview(ctx context.Context, reviewID int64, text string) (*avito.ReviewAnswer, error) {
+	f.answers[reviewID] = text
+	return &avito.ReviewAnswer{Text: text, Status: "moderation"}, nil
+}
+
+type fakeNotifier struct {
+	messages []string
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, chatID int64, text string) error {
+	f.messages = append(f.messages, text)
+	return nil
+}
+
+func TestSyncAutoAnswersAndNotifies(t *testing.T) {
+	repo := newMemoryRepository()
+	client := &fakeClient{answers: make(map[int64]string)}
+	notifier := &fakeNotifier{}
+	service := NewService(repo, client, notifier, nil)
+
+	repo.settings = &Settings{UserID: 1, AutoAnswer: true, NotifyNegative: true}
+	service.CreateTemplate(1, 5, "Спасибо за отзыв!")
+
+	client.reviews = []avito.Review{{ID: 1, Score: 5, CanAnswer: true}}
+	result, err := service.Sync(context.Background(), 1, 42)
+	if err != nil {
+		t.Fatalf("initial Sync: %v", err)
+	}
+	if result.New != 1 || len(client.answers) != 0 {
+		t.Errorf("initial import must not answer: %+v answers=%v", result, client.answers)
+	}
+
+	client.reviews = append([]avito.Review{
+		{ID: 3, Score: 1, Text: "Плохо", CanAnswer: true, Item: &avito.ReviewItem{ID: 7, Title: "Велосипед"}},
+		{ID: 2, Score: 5, CanAnswer: true},
+	}, client.reviews...)
+
+	result, err = service.Sync(context.Background(), 1, 42)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.New != 2 || result.AutoAnswered != 1 || result.Notified != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if client.answers[2] != "Спасибо за отзыв!" || repo.reviews[2].AnsweredBy != AnsweredByAuto {
+		t.Errorf("review 2 not auto-answered: %v", client.answers)
+	}
+	if _, answered := client.answers[3]; answered {
+		t.Error("review without a matching template must not be answered")
+	}
+	if len(notifier.messages) != 1 {
+		t.Errorf("notifications = %v", notifier.messages)
+	}
+}
+
+func TestAnswerReview(t *testing.T) {
+	repo := newMemoryRepository()
+	client := &fakeClient{answers: make(map[int64]string)}
+	service := NewService(repo, client, nil, nil)
+
+	repo.UpsertReview(&Review{ID: 1, AvitoUserID: 42, Score: 4, CanAnswer: true})
+
+	if _, err := service.AnswerReview(context.Background(), 42, 1, "  "); err != ErrInvalidAnswer {
+		t.Errorf("blank answer err = %v", err)
+	}
+	if _, err := service.AnswerReview(context.Background(), 42, 9, "ok"); err != ErrReviewNotFound {
+		t.Errorf("missing review err = %v", err)
+	}
+
+	review, err := service.AnswerReview(context.Background(), 42, 1, "Спасибо")
+	if err != nil {
+		t.Fatalf("AnswerReview: %v", err)
+	}
+	if review.AnsweredBy != AnsweredByManual || client.answers[1] != "Спасибо" {
+		t.Errorf("review = %+v", review)
+	}
+
+	if _, err := service.AnswerReview(context.Background(), 42, 1, "Ещё раз"); err != ErrCannotAnswer {
+		t.Errorf("second answer err = %v", err)
+	}
+}
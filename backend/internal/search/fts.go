@@ -0,0 +1,81 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ftsQuery turns the websearch-style text Postgres accepts into an SQLite FTS5
+// query: words match as prefixes, since FTS5 does not stem, "quoted phrases"
+// match exactly, "or" separates alternatives and -word excludes. It returns ""
+// when nothing is left to match.
+func ftsQuery(text string) string {
+	var groups []string
+	var positive, negative []string
+
+	flush := func() {
+		if len(positive) > 0 {
+			group := strings.Join(positive, " AND ")
+			for _, term := range negative {
+				group += " NOT " + term
+			}
+			groups = append(groups, "("+group+")")
+		}
+		positive, negative = nil, nil
+	}
+
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		negated := false
+		if text[0] == '-' {
+			negated = true
+			text = text[1:]
+		}
+
+		var term string
+		if strings.HasPrefix(text, `"`) {
+			end := strings.Index(text[1:], `"`)
+			if end < 0 {
+				end = len(text) - 1
+			}
+			term, text = text[1:end+1], text[min(end+2, len(text)):]
+			term = ftsString(term, false)
+		} else {
+			end := strings.IndexFunc(text, unicode.IsSpace)
+			if end < 0 {
+				end = len(text)
+			}
+			word := text[:end]
+			text = text[end:]
+			if !negated && strings.EqualFold(word, "or") {
+				flush()
+				continue
+			}
+			term = ftsString(word, true)
+		}
+
+		switch {
+		case term == "":
+		case negated:
+			negative = append(negative, term)
+		default:
+			positive = append(positive, term)
+		}
+	}
+	flush()
+
+	return strings.Join(groups, " OR ")
+}
+
+// ftsString quotes s as an FTS5 string, or returns "" when it holds nothing the
+// tokenizer would index.
+func ftsString(s string, prefix bool) string {
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+
+	quoted := `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	if prefix {
+		quoted += "*"
+	}
+	return quoted
+}
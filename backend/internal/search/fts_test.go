@@ -0,0 +1,101 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/chatsync"
+	"mini-app-backend/internal/repotest"
+)
+
+func TestFTSQuery(t *testing.T) {
+	cases := map[string]string{
+		"доставка Казань":       `("доставка"* AND "Казань"*)`,
+		`"самовывоз сегодня"`:   `("самовывоз сегодня")`,
+		"доставка or самовывоз": `("доставка"*) OR ("самовывоз"*)`,
+		"доставка -почта":       `("доставка"* NOT "почта"*)`,
+		`экран 5"`:              `("экран"* AND "5"""*)`,
+		"-почта":                "",
+		"?? !!":                 "",
+	}
+	for text, want := range cases {
+		if got := ftsQuery(text); got != want {
+			t.Errorf("ftsQuery(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestSQLiteSearchMessages(t *testing.T) {
+	db := repotest.SQLiteDB(t)
+
+	exec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	now := time.Now()
+	exec(`INSERT INTO users (id, first_name, created_at, updated_at) VALUES (1, 'Ivan', $1, $1)`, now)
+	exec(`INSERT INTO clients (client_id, client_secret, user_id, created_at, updated_at) VALUES ('client', 'secret', 1, $1, $1)`, now)
+
+	chats := chatsync.NewSQLRepository(db)
+	if err := chats.SaveState(&chatsync.State{AvitoUserID: 1001, ClientID: "client"}); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+	err := chats.UpsertChat(&chatsync.Chat{
+		ID:          "chat",
+		AvitoUserID: 1001,
+		ItemID:      2001,
+		Updated:     300,
+		Data:        []byte(`{"context":{"value":{"title":"Диван"}},"users":[{"id":1001,"name":"Продавец"},{"id":42,"name":"Анна"}]}`),
+		SyncedAt:    now,
+	})
+	if err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	_, err = chats.UpsertMessages([]*chatsync.Message{
+		{ID: "m1", AvitoUserID: 1001, ChatID: "chat", Direction: "in", Text: "Когда будет доставка в Казань?", Created: 100},
+		{ID: "m2", AvitoUserID: 1001, ChatID: "chat", Direction: "out", Text: "Доставка почтой завтра", Created: 200},
+		{ID: "m3", AvitoUserID: 1001, ChatID: "chat", Direction: "in", Text: "Спасибо", Created: 300},
+	})
+	if err != nil {
+		t.Fatalf("UpsertMessages: %v", err)
+	}
+
+	repo := NewSQLRepository(db)
+	search := func(text string) []*Hit {
+		t.Helper()
+		hits, err := repo.SearchMessages(Query{UserID: 1, Text: text, Limit: 10})
+		if err != nil {
+			t.Fatalf("SearchMessages(%q): %v", text, err)
+		}
+		return hits
+	}
+
+	hits := search("достав")
+	if len(hits) != 2 || hits[0].MessageID != "m2" || hits[1].MessageID != "m1" {
+		t.Fatalf("hits = %+v, want m2 and m1 newest first", hits)
+	}
+	if hits[0].ClientID != "client" || hits[0].ItemID != 2001 || hits[0].ItemTitle != "Диван" || hits[0].BuyerName != "Анна" {
+		t.Errorf("hit = %+v", hits[0])
+	}
+	if hits[0].Snippet != highlightStart+"Доставка"+highlightStop+" почтой завтра" {
+		t.Errorf("snippet = %q", hits[0].Snippet)
+	}
+
+	if hits := search("доставка -почтой"); len(hits) != 1 || hits[0].MessageID != "m1" {
+		t.Errorf("negated search = %+v", hits)
+	}
+
+	// Edited text must be searchable by the new words only.
+	if _, err := chats.UpsertMessages([]*chatsync.Message{{ID: "m3", AvitoUserID: 1001, ChatID: "chat", Text: "Спасибо за доставку", Created: 300}}); err != nil {
+		t.Fatalf("UpsertMessages: %v", err)
+	}
+	if hits := search("доставку"); len(hits) != 1 || hits[0].MessageID != "m3" {
+		t.Errorf("search after edit = %+v", hits)
+	}
+
+	if hits, err := repo.SearchMessages(Query{UserID: 2, Text: "доставка", Limit: 10}); err != nil || len(hits) != 0 {
+		t.Errorf("search by a stranger = %+v, %v", hits, err)
+	}
+}
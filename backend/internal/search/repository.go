@@ -0,0 +1,124 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"mini-app-backend/internal/database"
+)
+
+type SQLRepository struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{
+		db:      db,
+		dialect: database.DialectOf(db),
+	}
+}
+
+// SearchMessages matches the query with both the Russian and the English
+// configuration, over accounts the user reaches through a stored client or an
+// OAuth connection. On SQLite the FTS5 index stands in for both.
+func (r *SQLRepository) SearchMessages(query Query) ([]*Hit, error) {
+	text, match, from := query.Text, "m.search_vector @@ q.query", `avito_messages m
+		CROSS JOIN (
+			SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('english', $2) AS query
+		) q`
+	if r.dialect == database.SQLite {
+		text = ftsQuery(query.Text)
+		if text == "" {
+			return nil, nil
+		}
+		match, from = "avito_messages_fts MATCH $2", `avito_messages_fts f
+		JOIN avito_messages m ON m.avito_user_id = f.avito_user_id AND m.id = f.id`
+	}
+
+	args := []interface{}{query.UserID, text}
+	conditions := []string{
+		match,
+		`m.avito_user_id IN (
+			SELECT s.avito_user_id FROM avito_sync_state s
+			JOIN clients cl ON cl.client_id = s.client_id
+			WHERE cl.user_id = $1
+			UNION
+			SELECT ac.avito_user_id FROM avito_connections ac WHERE ac.user_id = $1
+		)`,
+	}
+
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if query.ClientID != "" {
+		addCondition("s.client_id = $%d", query.ClientID)
+	}
+	if query.ItemID > 0 {
+		addCondition("c.item_id = $%d", query.ItemID)
+	}
+	if query.Direction != "" {
+		addCondition("m.direction = $%d", query.Direction)
+	}
+	if query.From != nil {
+		addCondition("m.created >= $%d", query.From.Unix())
+	}
+	if query.To != nil {
+		addCondition("m.created < $%d", query.To.Unix())
+	}
+	if query.After != nil {
+		args = append(args, query.After.Created, query.After.AvitoUserID, query.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(m.created, m.avito_user_id, m.id) < ($%d, $%d, $%d)", len(args)-2, len(args)-1, len(args)))
+	}
+
+	var snippet string
+	if r.dialect == database.SQLite {
+		args = append(args, highlightStart, highlightStop)
+		snippet = fmt.Sprintf("snippet(avito_messages_fts, 0, $%d, $%d, ' ... ', 25)", len(args)-1, len(args))
+	} else {
+		args = append(args, fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=25, MinWords=8, MaxFragments=2", highlightStart, highlightStop))
+		snippet = fmt.Sprintf(`ts_headline(CASE WHEN m.text ~ '[а-яА-ЯёЁ]' THEN 'russian' ELSE 'english' END::regconfig,
+				m.text, q.query, $%d)`, len(args))
+	}
+	args = append(args, query.Limit)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT m.id, m.avito_user_id, COALESCE(s.client_id, ''), m.chat_id,
+			COALESCE(c.item_id, 0),
+			COALESCE(c.data->'context'->'value'->>'title', ''),
+			COALESCE((%s), ''),
+			m.direction, m.created,
+			%s
+		FROM %s
+		LEFT JOIN avito_chats c ON c.avito_user_id = m.avito_user_id AND c.id = m.chat_id
+		LEFT JOIN avito_sync_state s ON s.avito_user_id = m.avito_user_id
+		WHERE %s
+		ORDER BY m.created DESC, m.avito_user_id DESC, m.id DESC
+		LIMIT $%d
+	`, database.BuyerName(r.dialect), snippet, from, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		log.Printf("Error searching messages: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*Hit
+	for rows.Next() {
+		hit := &Hit{}
+		err := rows.Scan(&hit.MessageID, &hit.AvitoUserID, &hit.ClientID, &hit.ChatID, &hit.ItemID,
+			&hit.ItemTitle, &hit.BuyerName, &hit.Direction, &hit.Created, &hit.Snippet)
+		if err != nil {
+			log.Printf("Error scanning search hit: %v", err)
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
@@ -0,0 +1,232 @@
+package search
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout   = "2006-01-02"
+	defaultLimit = 20
+	maxLimit     = 100
+	maxQueryLen  = 200
+
+	// The database wraps matches in these markers; the service escapes the text
+	// and turns them into <mark> tags, so message text never reaches the
+	// client as markup.
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// Chat dates are Moscow calendar days, as everywhere else in the Avito views.
+var searchLocation = time.FixedZone("MSK", 3*60*60)
+
+var (
+	ErrEmptyQuery       = errors.NewAppError(http.StatusBadRequest, "q is required")
+	ErrQueryTooLong     = errors.NewAppError(http.StatusBadRequest, "Search query is too long")
+	ErrInvalidDirection = errors.NewAppError(http.StatusBadRequest, "direction must be in or out")
+	ErrInvalidDate      = errors.NewAppError(http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+	ErrInvalidCursor    = errors.NewAppError(http.StatusBadRequest, "Invalid cursor")
+)
+
+type Query struct {
+	UserID    int64
+	Text      string
+	ClientID  string
+	ItemID    int64
+	Direction string
+	From      *time.Time
+	To        *time.Time
+	After     *Cursor
+	Limit     int
+}
+
+// Cursor is the keyset position of the last returned hit. Hits are ordered
+// newest first and (created, avito_user_id, id) is unique.
+type Cursor struct {
+	Created     int64
+	AvitoUserID int64
+	ID          string
+}
+
+func (c *Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d:%s", c.Created, c.AvitoUserID, c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	created, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	avitoUserID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{Created: created, AvitoUserID: avitoUserID, ID: parts[2]}, nil
+}
+
+type Hit struct {
+	MessageID   string `json:"message_id" db:"id"`
+	AvitoUserID int64  `json:"avito_user_id" db:"avito_user_id"`
+	ClientID    string `json:"client_id,omitempty" db:"client_id"`
+	ChatID      string `json:"chat_id" db:"chat_id"`
+	ItemID      int64  `json:"item_id" db:"item_id"`
+	ItemTitle   string `json:"item_title,omitempty" db:"item_title"`
+	BuyerName   string `json:"buyer_name,omitempty" db:"buyer_name"`
+	Direction   string `json:"direction" db:"direction"`
+	Created     int64  `json:"created" db:"created"`
+	Snippet     string `json:"snippet" db:"snippet"`
+}
+
+type Result struct {
+	Hits       []*Hit `json:"hits"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Repository interface {
+	SearchMessages(query Query) ([]*Hit, error)
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// ParseQuery validates the raw request parameters.
+func ParseQuery(userID int64, text, clientID, itemID, direction, from, to, cursor, limit string) (Query, error) {
+	query := Query{
+		UserID:   userID,
+		Text:     strings.TrimSpace(text),
+		ClientID: strings.TrimSpace(clientID),
+		Limit:    defaultLimit,
+	}
+
+	if query.Text == "" {
+		return query, ErrEmptyQuery
+	}
+	if utf8.RuneCountInString(query.Text) > maxQueryLen {
+		return query, ErrQueryTooLong
+	}
+
+	if itemID != "" {
+		id, err := strconv.ParseInt(itemID, 10, 64)
+		if err != nil || id <= 0 {
+			return query, errors.NewAppError(http.StatusBadRequest, "Invalid item_id")
+		}
+		query.ItemID = id
+	}
+
+	switch direction {
+	case "", "in", "out":
+		query.Direction = direction
+	default:
+		return query, ErrInvalidDirection
+	}
+
+	if from != "" {
+		date, err := time.ParseInLocation(dateLayout, from, searchLocation)
+		if err != nil {
+			return query, ErrInvalidDate
+		}
+		query.From = &date
+	}
+
+	if to != "" {
+		date, err := time.ParseInLocation(dateLayout, to, searchLocation)
+		if err != nil {
+			return query, ErrInvalidDate
+		}
+		// date_to is inclusive.
+		date = date.AddDate(0, 0, 1)
+		query.To = &date
+	}
+
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return query, ErrInvalidDate
+	}
+
+	if cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.After = after
+	}
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return query, errors.NewAppError(http.StatusBadRequest, "Invalid limit")
+		}
+		if n > maxLimit {
+			n = maxLimit
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// Search runs the query over the user's mirrored accounts only.
+func (s *Service) Search(query Query) (*Result, error) {
+	if query.Limit <= 0 || query.Limit > maxLimit {
+		query.Limit = defaultLimit
+	}
+
+	// One extra row tells whether another page exists.
+	limit := query.Limit
+	query.Limit++
+
+	hits, err := s.repo.SearchMessages(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: hits}
+	if len(hits) > limit {
+		result.Hits = hits[:limit]
+		last := result.Hits[limit-1]
+		result.NextCursor = (&Cursor{Created: last.Created, AvitoUserID: last.AvitoUserID, ID: last.MessageID}).Encode()
+	}
+
+	for _, hit := range result.Hits {
+		hit.Snippet = highlight(hit.Snippet)
+	}
+
+	if result.Hits == nil {
+		result.Hits = []*Hit{}
+	}
+
+	return result, nil
+}
+
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
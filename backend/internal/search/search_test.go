@@ -0,0 +1,89 @@
+package search
+
+import (
+	"testing"
+)
+
+type memoryRepository struct {
+	hits      []*Hit
+	lastQuery Query
+}
+
+func (m *memoryRepository) SearchMessages(query Query) ([]*Hit, error) {
+	m.lastQuery = query
+	var hits []*Hit
+	for _, hit := range m.hits {
+		if query.After != nil && hit.Created >= query.After.Created {
+			continue
+		}
+		copied := *hit
+		hits = append(hits, &copied)
+		if len(hits) == query.Limit {
+			break
+		}
+	}
+	return hits, nil
+}
+
+func TestParseQuery(t *testing.T) {
+	query, err := ParseQuery(1, "  доставка в Казань ", "client", "2001", "in", "2026-03-01", "2026-03-07", "", "500")
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	if query.Text != "доставка в Казань" || query.ItemID != 2001 || query.Limit != maxLimit {
+		t.Fatalf("unexpected query %+v", query)
+	}
+	if got := query.To.Sub(*query.From).Hours(); got != 7*24 {
+		t.Fatalf("date range spans %vh, want date_to to be inclusive", got)
+	}
+
+	cases := map[string][]string{
+		"empty query":   {" ", "", "", "", "", "", "", ""},
+		"bad direction": {"x", "", "", "both", "", "", "", ""},
+		"bad date":      {"x", "", "", "", "01.03.2026", "", "", ""},
+		"reversed":      {"x", "", "", "", "2026-03-07", "2026-03-01", "", ""},
+		"bad cursor":    {"x", "", "", "", "", "", "???", ""},
+		"bad item":      {"x", "", "abc", "", "", "", "", ""},
+	}
+	for name, args := range cases {
+		if _, err := ParseQuery(1, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7]); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSearchPagesWithCursor(t *testing.T) {
+	repo := &memoryRepository{hits: []*Hit{
+		{MessageID: "m3", AvitoUserID: 1001, Created: 300, Snippet: "<b>доставка</b> \x02Казань\x03"},
+		{MessageID: "m2", AvitoUserID: 1001, Created: 200, Snippet: "m2"},
+		{MessageID: "m1", AvitoUserID: 1001, Created: 100, Snippet: "m1"},
+	}}
+	service := NewService(repo)
+
+	first, err := service.Search(Query{Text: "Казань", Limit: 2})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(first.Hits) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %d hits, cursor %q", len(first.Hits), first.NextCursor)
+	}
+	if want := "&lt;b&gt;доставка&lt;/b&gt; <mark>Казань</mark>"; first.Hits[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", first.Hits[0].Snippet, want)
+	}
+
+	cursor, err := DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if cursor.Created != 200 || cursor.ID != "m2" || cursor.AvitoUserID != 1001 {
+		t.Fatalf("cursor = %+v", cursor)
+	}
+
+	second, err := service.Search(Query{Text: "Казань", Limit: 2, After: cursor})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(second.Hits) != 1 || second.Hits[0].MessageID != "m1" || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+}
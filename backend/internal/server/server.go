@@ -1,18 +1,36 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"mini-app-backend/internal/apikey"
+	"mini-app-backend/internal/auth"
+	"mini-app-backend/internal/authz"
+	avitoapi "mini-app-backend/internal/avito"
+	"mini-app-backend/internal/avitoauth"
+	"mini-app-backend/internal/chatsync"
 	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/events"
+	"mini-app-backend/internal/export"
 	"mini-app-backend/internal/handlers"
 	"mini-app-backend/internal/handlers/avito"
+	"mini-app-backend/internal/httpclient"
+	"mini-app-backend/internal/inbox"
+	"mini-app-backend/internal/itemactions"
+	"mini-app-backend/internal/itemstats"
 	"mini-app-backend/internal/logger"
 	"mini-app-backend/internal/message"
 	"mini-app-backend/internal/middleware"
+	"mini-app-backend/internal/migrations"
+	"mini-app-backend/internal/reviews"
+	"mini-app-backend/internal/search"
+	"mini-app-backend/internal/telegram"
 	"mini-app-backend/internal/user"
+	"mini-app-backend/internal/utils"
+	"mini-app-backend/internal/workspace"
 	"net/http"
-
-	_ "github.com/lib/pq"
 )
 
 type Server struct {
@@ -24,56 +42,96 @@ type Server struct {
 	userRepo      *user.SQLRepository
 	messageRepo    *message.SQLMessageRepository
 	messageService *message.MessageService
+	workspaceRepo    *workspace.SQLRepository
+	workspaceService *workspace.WorkspaceService
+	workspaceHandler *handlers.WorkspaceHandler
+	apiKeyRepo       *apikey.SQLRepository
+	apiKeyService    *apikey.APIKeyService
+	apiKeyHandler    *handlers.APIKeyHandler
+	avitoAuthRepo    *avitoauth.SQLRepository
+	itemStatsRepo    *itemstats.SQLRepository
+	itemActionRepo   *itemactions.SQLRepository
+	reviewRepo       *reviews.SQLRepository
+	chatSyncRepo     *chatsync.SQLRepository
+	chatSyncService  *chatsync.Service
+	searchHandler    *handlers.SearchHandler
+	exportRepo       *export.SQLRepository
+	exportService    *export.Service
+	exportHandler    *handlers.ExportHandler
+	eventBroker      *events.Broker
+	eventsHandler    *handlers.EventsHandler
+	avitoAuthService *avitoauth.Service
+	avitoOAuthHandler *handlers.AvitoOAuthHandler
+	avitoClient      *avitoapi.Client
+	avitoHandler     *avito.AvitoHandler
 }
 
-func New(cfg *config.Config) *Server {
+// New takes the pool opened by database.Open; the caller owns and closes it.
+func New(cfg *config.Config, db *sql.DB) *Server {
 	return &Server{
 		config: cfg,
+		db:     db,
 	}
 }
 
 func (s *Server) initDB() error {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		s.config.PostgresUser, s.config.PostgresPassword, s.config.PostgresHost, s.config.PostgresPort, s.config.PostgresDB)
-
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
-	}
-
-	s.db = db
-
-	err = db.Ping()
-	if err != nil {
-		return fmt.Errorf("failed to ping database: %v", err)
-	}
-
-	logger.GetLogger().Info("✅ Connected to database")
+	db := s.db
 
 	s.userRepo = user.NewSQLRepository(db)
 	s.messageRepo = message.NewSQLMessageRepository(db)
+	s.workspaceRepo = workspace.NewSQLRepository(db)
+	s.apiKeyRepo = apikey.NewSQLRepository(db)
+	s.avitoAuthRepo = avitoauth.NewSQLRepository(db)
+	s.itemStatsRepo = itemstats.NewSQLRepository(db)
+	s.itemActionRepo = itemactions.NewSQLRepository(db)
+	s.reviewRepo = reviews.NewSQLRepository(db)
+	s.chatSyncRepo = chatsync.NewSQLRepository(db)
+	s.exportRepo = export.NewSQLRepository(db)
 
-	err = s.userRepo.CreateTables()
+	err := migrations.Up(context.Background(), db)
 	if err != nil {
-		return fmt.Errorf("failed to create user tables: %v", err)
+		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
-	err = s.messageRepo.CreateMessagesTable()
-	if err != nil {
-		return fmt.Errorf("failed to create messages table: %v", err)
-	}
-
-	logger.GetLogger().Info("✅ Database tables created")
+	logger.GetLogger().Info("✅ Database migrated")
 
 	return nil
 }
 
 func (s *Server) initServices() {
-	s.userService = user.NewUserService(s.userRepo)
+	s.userService = user.NewUserService(s.userRepo, user.NewTransactor(s.db))
 	s.messageService = message.NewMessageService(s.messageRepo)
+	s.workspaceService = workspace.NewWorkspaceService(s.workspaceRepo, workspace.NewTransactor(s.db))
+	s.apiKeyService = apikey.NewAPIKeyService(s.apiKeyRepo)
+	s.avitoClient = avitoapi.NewClient(
+		avitoapi.WithBaseURL(s.config.AvitoAPIURL),
+		avitoapi.WithCredentials(s.config.AvitoClientId, s.config.AvitoClientSecret),
+		avitoapi.WithRateLimit(float64(s.config.AvitoRateLimit), s.config.AvitoRateBurst),
+	)
+	s.avitoAuthService = avitoauth.NewService(s.avitoAuthRepo, utils.GetKeyring(), s.avitoClient, s.config)
+	s.eventBroker = events.NewBroker(events.NewSQLRepository(s.db))
+	s.chatSyncService = chatsync.NewService(s.chatSyncRepo, s.avitoClient, s.eventBroker, s.config.AvitoSyncInterval)
 
-	s.authHandler = handlers.NewAuthHandler(s.userService, s.messageService, s.config.TelegramBotToken, s.db, s.config)
-	s.messageHandler = handlers.NewMessageHandler(s.messageService, s.db)
+	authService := auth.NewService(auth.NewTransactor(s.db), s.config.AvitoClientId, s.config.AvitoClientSecret)
+	s.authHandler = handlers.NewAuthHandler(authService, s.userService, s.messageService, s.workspaceService, s.config.TelegramBotToken, s.db, s.config)
+	authorizer := authz.NewAuthorizer(s.userService, s.messageService)
+	s.messageHandler = handlers.NewMessageHandler(s.messageService, s.workspaceService, authorizer, s.db)
+	s.workspaceHandler = handlers.NewWorkspaceHandler(s.workspaceService, s.config.TelegramBotName)
+	s.apiKeyHandler = handlers.NewAPIKeyHandler(s.apiKeyService)
+	s.avitoOAuthHandler = handlers.NewAvitoOAuthHandler(s.avitoAuthService, s.config.AvitoOAuthReturnURL)
+	s.searchHandler = handlers.NewSearchHandler(search.NewService(search.NewSQLRepository(s.db)))
+	s.exportService = export.NewService(s.exportRepo, telegram.NewNotifier(s.config.TelegramBotToken), s.config.PublicURL)
+	s.exportHandler = handlers.NewExportHandler(s.exportService)
+	s.eventsHandler = handlers.NewEventsHandler(s.eventBroker)
+	s.avitoHandler = avito.NewAvitoHandler(
+		s.avitoClient,
+		itemstats.NewService(s.itemStatsRepo, s.avitoClient),
+		itemactions.NewService(s.itemActionRepo, s.avitoClient),
+		reviews.NewService(s.reviewRepo, s.avitoClient, telegram.NewNotifier(s.config.TelegramBotToken), s.eventBroker),
+		s.chatSyncService,
+		inbox.NewService(s.userRepo, s.avitoClient, s.chatSyncService),
+		s.eventBroker,
+	)
 }
 
 func (s *Server) setupRoutes(mux *http.ServeMux) {
@@ -89,6 +147,22 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/user/client/", s.authHandler.CreateClient)
 	mux.HandleFunc("GET /api/user/clients/", s.authHandler.GetClients)
 	mux.HandleFunc("POST /api/auth/avito/credentials/", s.authHandler.SetAvitoCredentials)
+	mux.HandleFunc("GET /api/auth/avito/connect/", s.avitoOAuthHandler.Connect)
+	mux.HandleFunc("GET /api/auth/avito/callback/", s.avitoOAuthHandler.Callback)
+	mux.HandleFunc("GET /api/auth/avito/accounts/", s.avitoOAuthHandler.GetAccounts)
+	mux.HandleFunc("DELETE /api/auth/avito/accounts/", s.avitoOAuthHandler.DeleteAccount)
+
+	mux.HandleFunc("POST /api/user/api-keys/", s.apiKeyHandler.CreateAPIKey)
+	mux.HandleFunc("GET /api/user/api-keys/", s.apiKeyHandler.GetAPIKeys)
+	mux.HandleFunc("DELETE /api/user/api-keys/", s.apiKeyHandler.RevokeAPIKey)
+
+	mux.HandleFunc("GET /api/workspaces/", s.workspaceHandler.GetWorkspaces)
+	mux.HandleFunc("POST /api/workspaces/", s.workspaceHandler.CreateWorkspace)
+	mux.HandleFunc("POST /api/workspace/active/", s.workspaceHandler.SetActiveWorkspace)
+	mux.HandleFunc("GET /api/workspace/members/", s.workspaceHandler.GetMembers)
+	mux.HandleFunc("PUT /api/workspace/members/", s.workspaceHandler.UpdateMember)
+	mux.HandleFunc("DELETE /api/workspace/members/", s.workspaceHandler.RemoveMember)
+	mux.HandleFunc("POST /api/workspace/invitations/", s.workspaceHandler.CreateInvitation)
 	
 	mux.HandleFunc("POST /api/message/", s.messageHandler.CreateMessage)
 	mux.HandleFunc("GET /api/messages/", s.messageHandler.GetMessages)
@@ -97,9 +171,34 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("PUT /api/message/", s.messageHandler.UpdateMessage)
 	mux.HandleFunc("DELETE /api/message/", s.messageHandler.DeleteMessage)
 	
-	mux.HandleFunc("GET /api/avito/items/", avito.GetItems)
-	mux.HandleFunc("GET /api/avito/messenger/chats/", avito.GetMesseges)
-	mux.HandleFunc("/api/avito/user/info/", avito.GetUserInfo)
+	mux.HandleFunc("GET /api/events/", s.eventsHandler.Stream)
+	mux.HandleFunc("GET /api/search/messages/", s.searchHandler.SearchMessages)
+	mux.HandleFunc("POST /api/exports/", s.exportHandler.CreateExport)
+	mux.HandleFunc("GET /api/exports/", s.exportHandler.GetExports)
+	mux.HandleFunc("GET /api/exports/download/", s.exportHandler.DownloadExport)
+
+	mux.HandleFunc("GET /api/avito/items/", s.avitoHandler.GetItems)
+	mux.HandleFunc("GET /api/avito/items/stats/", s.avitoHandler.GetItemStats)
+	mux.HandleFunc("POST /api/avito/items/price/", s.avitoHandler.ProposePriceUpdate)
+	mux.HandleFunc("POST /api/avito/items/vas/", s.avitoHandler.ProposeVAS)
+	mux.HandleFunc("GET /api/avito/items/actions/", s.avitoHandler.GetItemActions)
+	mux.HandleFunc("POST /api/avito/items/actions/confirm/", s.avitoHandler.ConfirmItemAction)
+	mux.HandleFunc("POST /api/avito/items/actions/cancel/", s.avitoHandler.CancelItemAction)
+	mux.HandleFunc("GET /api/avito/messenger/chats/", s.avitoHandler.GetMesseges)
+	mux.HandleFunc("GET /api/avito/inbox/", s.avitoHandler.GetInbox)
+	mux.HandleFunc("GET /api/avito/messenger/messages/", s.avitoHandler.GetChatMessages)
+	mux.HandleFunc("POST /api/avito/messenger/messages/", s.avitoHandler.SendMessege)
+	mux.HandleFunc("POST /api/avito/messenger/read/", s.avitoHandler.MarkChatRead)
+	mux.HandleFunc("GET /api/avito/reviews/", s.avitoHandler.GetReviews)
+	mux.HandleFunc("POST /api/avito/reviews/sync/", s.avitoHandler.SyncReviews)
+	mux.HandleFunc("POST /api/avito/reviews/answer/", s.avitoHandler.AnswerReview)
+	mux.HandleFunc("GET /api/avito/reviews/templates/", s.avitoHandler.GetReviewTemplates)
+	mux.HandleFunc("POST /api/avito/reviews/templates/", s.avitoHandler.CreateReviewTemplate)
+	mux.HandleFunc("PUT /api/avito/reviews/templates/", s.avitoHandler.UpdateReviewTemplate)
+	mux.HandleFunc("DELETE /api/avito/reviews/templates/", s.avitoHandler.DeleteReviewTemplate)
+	mux.HandleFunc("GET /api/avito/reviews/settings/", s.avitoHandler.GetReviewSettings)
+	mux.HandleFunc("PUT /api/avito/reviews/settings/", s.avitoHandler.UpdateReviewSettings)
+	mux.HandleFunc("/api/avito/user/info/", s.avitoHandler.GetUserInfo)
 }
 
 func (s *Server) Start() error {
@@ -107,17 +206,22 @@ func (s *Server) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %v", err)
 	}
-	defer s.db.Close()
 
 	s.initServices()
 
+	go avitoauth.NewTokenRotator(s.avitoAuthRepo, utils.GetKeyring()).Start(context.Background())
+	go s.chatSyncService.Start(context.Background(), s.userRepo)
+	go s.exportService.Start(context.Background())
+
 	mux := http.NewServeMux()
 
 	s.setupRoutes(mux)
 
 	spamProtection := middleware.NewSpamProtectionMiddleware(s.messageService)
+	apiKeyAuth := middleware.NewAPIKeyAuthMiddleware(s.apiKeyService)
+	avitoOAuth := middleware.AvitoOAuth(s.avitoAuthService)
 	
-	handler := middleware.Logging(middleware.CORS(middleware.RecoverPanic(middleware.ContentTypeJSON(middleware.UserCookie(spamProtection.Protect(mux))))))
+	handler := middleware.Logging(middleware.CORS(middleware.RecoverPanic(middleware.ContentTypeJSON(middleware.UserCookie(apiKeyAuth.Authenticate(avitoOAuth(spamProtection.Protect(mux))))))))
 
 	port := s.config.ServerPort
 
@@ -139,6 +243,21 @@ func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"status": "ok", "port": "%s"}`, s.config.ServerPort)
+	breakers := s.avitoClient.BreakerStatuses()
+
+	status := "ok"
+	for _, breaker := range breakers {
+		if breaker.State != httpclient.BreakerClosed {
+			status = "degraded"
+			break
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         status,
+		"port":           s.config.ServerPort,
+		"avito_tokens":   s.avitoClient.TokenMetrics(),
+		"avito_breakers": breakers,
+	})
 	logger.GetLogger().Infof("💓 Check health от %s", r.RemoteAddr)
 }
@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const botAPIURL = "https://api.telegram.org"
+
+// Notifier sends messages on behalf of the bot. Telegram user IDs double as
+// private chat IDs, so a user who has started the bot can be messaged directly.
+// It talks to the Bot API with a bare http.Client because the token is part of
+// the URL and must stay out of request logs and error messages.
+type Notifier struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func NewNotifier(botToken string) *Notifier {
+	return &Notifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: botAPIURL,
+		token:   botToken,
+	}
+}
+
+type sendMessageRequest struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type sendMessageResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func (n *Notifier) Notify(ctx context.Context, chatID int64, text string) error {
+	if n.token == "" {
+		return fmt.Errorf("bot token is not configured")
+	}
+
+	payload, err := json.Marshal(sendMessageRequest{ChatID: chatID, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/bot"+n.token+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating telegram request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if stderrors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram sendMessage failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response sendMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("telegram sendMessage returned status %d", resp.StatusCode)
+	}
+
+	if !response.OK {
+		return fmt.Errorf("telegram rejected message: %s", response.Description)
+	}
+
+	return nil
+}
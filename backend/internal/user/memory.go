@@ -0,0 +1,277 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryRepository is a UserRepository kept in process memory for tests and
+// local runs without Postgres. It follows SQLRepository: lookups return nil
+// when nothing matches, lists are newest first, clients and user data need an
+// existing user and client_id / client_secret are unique. It has no
+// transactions, so pair it with database.NoTx.
+type MemoryRepository struct {
+	mu       sync.Mutex
+	users    map[int64]*User
+	userData []*UserData
+	clients  []*Client
+
+	nextUserDataID int64
+	nextClientID   int64
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users: make(map[int64]*User),
+	}
+}
+
+func (r *MemoryRepository) CreateUser(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok {
+		return fmt.Errorf("user %d already exists", user.ID)
+	}
+
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (r *MemoryRepository) UpdateUser(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.users[user.ID]; ok {
+		r.updateProfile(existing, user)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpsertUser(ctx context.Context, user *User) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.users[user.ID]; ok {
+		r.updateProfile(existing, user)
+		user.CreatedAt = existing.CreatedAt
+		return false, nil
+	}
+
+	copied := *user
+	r.users[user.ID] = &copied
+	return true, nil
+}
+
+func (r *MemoryRepository) updateProfile(existing, user *User) {
+	existing.FirstName = user.FirstName
+	existing.LastName = user.LastName
+	existing.Username = user.Username
+	existing.LanguageCode = user.LanguageCode
+	existing.IsPremium = user.IsPremium
+	existing.UpdatedAt = user.UpdatedAt
+}
+
+func (r *MemoryRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*User, error) {
+	return r.GetUserByID(ctx, telegramID)
+}
+
+func (r *MemoryRepository) CreateUserData(ctx context.Context, userData *UserData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userData.UserID]; !ok {
+		return fmt.Errorf("user %d does not exist", userData.UserID)
+	}
+
+	r.nextUserDataID++
+	userData.ID = r.nextUserDataID
+
+	copied := *userData
+	r.userData = append(r.userData, &copied)
+	return nil
+}
+
+func (r *MemoryRepository) GetUserDataByUserID(ctx context.Context, userID int64) (*UserData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *UserData
+	for _, data := range r.userData {
+		if data.UserID == userID && (latest == nil || data.CreatedAt.After(latest.CreatedAt)) {
+			latest = data
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *MemoryRepository) UpdateUserData(ctx context.Context, userData *UserData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, data := range r.userData {
+		if data.ID == userData.ID {
+			data.Data = userData.Data
+			data.UpdatedAt = userData.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateClient(ctx context.Context, client *Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[client.UserID]; !ok {
+		return fmt.Errorf("user %d does not exist", client.UserID)
+	}
+	if err := r.checkUnique(client); err != nil {
+		return err
+	}
+
+	r.nextClientID++
+	client.ID = r.nextClientID
+
+	copied := *client
+	r.clients = append(r.clients, &copied)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateClient(ctx context.Context, client *Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(client); err != nil {
+		return err
+	}
+
+	for _, existing := range r.clients {
+		if existing.ID == client.ID {
+			existing.ClientID = client.ClientID
+			existing.ClientSecret = client.ClientSecret
+			existing.UserID = client.UserID
+			existing.WorkspaceID = client.WorkspaceID
+			existing.UpdatedAt = client.UpdatedAt
+		}
+	}
+	return nil
+}
+
+// checkUnique stands in for the unique indexes on clients.
+func (r *MemoryRepository) checkUnique(client *Client) error {
+	for _, existing := range r.clients {
+		if existing.ID == client.ID {
+			continue
+		}
+		if existing.ClientID == client.ClientID {
+			return ErrClientIDExists
+		}
+		if existing.ClientSecret == client.ClientSecret {
+			return ErrClientSecretExists
+		}
+	}
+	return nil
+}
+
+// findClients returns copies of the matching clients, newest first.
+func (r *MemoryRepository) findClients(match func(*Client) bool) []*Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var clients []*Client
+	for _, client := range r.clients {
+		if match(client) {
+			copied := *client
+			clients = append(clients, &copied)
+		}
+	}
+
+	sort.SliceStable(clients, func(i, j int) bool {
+		return clients[i].CreatedAt.After(clients[j].CreatedAt)
+	})
+	return clients
+}
+
+func (r *MemoryRepository) findClient(match func(*Client) bool) *Client {
+	clients := r.findClients(match)
+	if len(clients) == 0 {
+		return nil
+	}
+	return clients[0]
+}
+
+// page applies LIMIT / OFFSET the way Postgres does.
+func page(clients []*Client, limit, offset int) []*Client {
+	if offset >= len(clients) {
+		return nil
+	}
+	clients = clients[offset:]
+	if limit < len(clients) {
+		clients = clients[:limit]
+	}
+	if len(clients) == 0 {
+		return nil
+	}
+	return clients
+}
+
+func (r *MemoryRepository) GetClientByUserID(ctx context.Context, userID int64) (*Client, error) {
+	return r.findClient(func(c *Client) bool { return c.UserID == userID }), nil
+}
+
+func (r *MemoryRepository) GetClientByCredentials(ctx context.Context, clientID, clientSecret string) (*Client, error) {
+	return r.findClient(func(c *Client) bool { return c.ClientID == clientID && c.ClientSecret == clientSecret }), nil
+}
+
+func (r *MemoryRepository) GetClientByID(ctx context.Context, clientID string) (*Client, error) {
+	return r.findClient(func(c *Client) bool { return c.ClientID == clientID }), nil
+}
+
+func (r *MemoryRepository) GetClientBySecret(ctx context.Context, clientSecret string) (*Client, error) {
+	return r.findClient(func(c *Client) bool { return c.ClientSecret == clientSecret }), nil
+}
+
+func (r *MemoryRepository) GetClientsWithPagination(ctx context.Context, limit, offset int) ([]*Client, error) {
+	return page(r.findClients(func(c *Client) bool { return true }), limit, offset), nil
+}
+
+func (r *MemoryRepository) GetClientsCount(ctx context.Context) (int, error) {
+	return len(r.findClients(func(c *Client) bool { return true })), nil
+}
+
+func (r *MemoryRepository) GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*Client, error) {
+	return page(r.findClients(func(c *Client) bool { return c.UserID == userID }), limit, offset), nil
+}
+
+func (r *MemoryRepository) GetClientsCountByUserID(ctx context.Context, userID int64) (int, error) {
+	return len(r.findClients(func(c *Client) bool { return c.UserID == userID })), nil
+}
+
+func (r *MemoryRepository) GetClientsByWorkspaceIDWithPagination(ctx context.Context, workspaceID int64, limit, offset int) ([]*Client, error) {
+	return page(r.findClients(func(c *Client) bool { return workspaceID != 0 && c.WorkspaceID == workspaceID }), limit, offset), nil
+}
+
+func (r *MemoryRepository) GetClientsCountByWorkspaceID(ctx context.Context, workspaceID int64) (int, error) {
+	return len(r.findClients(func(c *Client) bool { return workspaceID != 0 && c.WorkspaceID == workspaceID })), nil
+}
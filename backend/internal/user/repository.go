@@ -1,28 +1,33 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"log"
+	"mini-app-backend/internal/database"
 )
 
 type SQLRepository struct {
-	db *sql.DB
+	db database.DBTX
 }
 
-func NewSQLRepository(db *sql.DB) *SQLRepository {
+func NewSQLRepository(db database.DBTX) *SQLRepository {
 	return &SQLRepository{
 		db: db,
 	}
 }
 
-func (r *SQLRepository) CreateUser(user *User) error {
+func (r *SQLRepository) CreateUser(ctx context.Context, user *User) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO users (id, first_name, last_name, username, language_code, is_premium, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
 		user.ID,
 		user.FirstName,
 		user.LastName,
@@ -41,14 +46,17 @@ func (r *SQLRepository) CreateUser(user *User) error {
 	return nil
 }
 
-func (r *SQLRepository) GetUserByID(id int64) (*User, error) {
+func (r *SQLRepository) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, first_name, last_name, username, language_code, is_premium, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), id)
 
 	user := &User{}
 	err := row.Scan(
@@ -73,14 +81,17 @@ func (r *SQLRepository) GetUserByID(id int64) (*User, error) {
 	return user, nil
 }
 
-func (r *SQLRepository) UpdateUser(user *User) error {
+func (r *SQLRepository) UpdateUser(ctx context.Context, user *User) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE users
 		SET first_name = $2, last_name = $3, username = $4, language_code = $5, is_premium = $6, updated_at = $7
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
 		user.ID,
 		user.FirstName,
 		user.LastName,
@@ -98,11 +109,74 @@ func (r *SQLRepository) UpdateUser(user *User) error {
 	return nil
 }
 
-func (r *SQLRepository) GetUserByTelegramID(telegramID int64) (*User, error) {
-	return r.GetUserByID(telegramID)
+// UpsertUser inserts the user or refreshes the profile of an existing one.
+// created_at keeps its original value and is written back. The insert and the
+// update are separate statements because only Postgres can tell them apart
+// within one.
+func (r *SQLRepository) UpsertUser(ctx context.Context, user *User) (bool, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
+	insert := `
+		INSERT INTO users (id, first_name, last_name, username, language_code, is_premium, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, database.Tag(ctx, insert),
+		user.ID,
+		user.FirstName,
+		user.LastName,
+		user.Username,
+		user.LanguageCode,
+		user.IsPremium,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	if err != nil {
+		log.Printf("Error upserting user: %v", err)
+		return false, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		log.Printf("Error upserting user: %v", err)
+		return false, err
+	} else if inserted == 1 {
+		return true, nil
+	}
+
+	update := `
+		UPDATE users
+		SET first_name = $2, last_name = $3, username = $4, language_code = $5, is_premium = $6, updated_at = $7
+		WHERE id = $1
+		RETURNING created_at
+	`
+
+	err = r.db.QueryRowContext(ctx, database.Tag(ctx, update),
+		user.ID,
+		user.FirstName,
+		user.LastName,
+		user.Username,
+		user.LanguageCode,
+		user.IsPremium,
+		user.UpdatedAt,
+	).Scan(&user.CreatedAt)
+
+	if err != nil {
+		log.Printf("Error upserting user: %v", err)
+		return false, err
+	}
+
+	return false, nil
 }
 
-func (r *SQLRepository) CreateUserData(userData *UserData) error {
+func (r *SQLRepository) GetUserByTelegramID(ctx context.Context, telegramID int64) (*User, error) {
+	return r.GetUserByID(ctx, telegramID)
+}
+
+func (r *SQLRepository) CreateUserData(ctx context.Context, userData *UserData) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO user_data (user_id, data, created_at, updated_at)
 		VALUES ($1, $2, $3, $4)
@@ -110,7 +184,7 @@ func (r *SQLRepository) CreateUserData(userData *UserData) error {
 	`
 
 	var id int64
-	err := r.db.QueryRow(query,
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query),
 		userData.UserID,
 		userData.Data,
 		userData.CreatedAt,
@@ -126,7 +200,10 @@ func (r *SQLRepository) CreateUserData(userData *UserData) error {
 	return nil
 }
 
-func (r *SQLRepository) GetUserDataByUserID(userID int64) (*UserData, error) {
+func (r *SQLRepository) GetUserDataByUserID(ctx context.Context, userID int64) (*UserData, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, data, created_at, updated_at
 		FROM user_data
@@ -135,7 +212,7 @@ func (r *SQLRepository) GetUserDataByUserID(userID int64) (*UserData, error) {
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, userID)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), userID)
 
 	userData := &UserData{}
 	err := row.Scan(
@@ -157,14 +234,17 @@ func (r *SQLRepository) GetUserDataByUserID(userID int64) (*UserData, error) {
 	return userData, nil
 }
 
-func (r *SQLRepository) UpdateUserData(userData *UserData) error {
+func (r *SQLRepository) UpdateUserData(ctx context.Context, userData *UserData) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE user_data
 		SET data = $2, updated_at = $3
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
 		userData.ID,
 		userData.Data,
 		userData.UpdatedAt,
@@ -178,83 +258,10 @@ func (r *SQLRepository) UpdateUserData(userData *UserData) error {
 	return nil
 }
 
-func (r *SQLRepository) CreateTables() error {
-	usersTable := `
-		CREATE TABLE IF NOT EXISTS users (
-			id BIGINT PRIMARY KEY,
-			first_name VARCHAR(255) NOT NULL,
-			last_name VARCHAR(255),
-			username VARCHAR(255),
-			language_code VARCHAR(10),
-			is_premium BOOLEAN DEFAULT FALSE,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-	`
-
-	_, err := r.db.Exec(usersTable)
-	if err != nil {
-		log.Printf("Error creating users table: %v", err)
-		return err
-	}
+func (r *SQLRepository) GetUsersWithPagination(ctx context.Context, limit, offset int) ([]*User, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
 
-	userDataTable := `
-		CREATE TABLE IF NOT EXISTS user_data (
-			id SERIAL PRIMARY KEY,
-			user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			data TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-	`
-
-	_, err = r.db.Exec(userDataTable)
-	if err != nil {
-		log.Printf("Error creating user_data table: %v", err)
-		return err
-	}
-
-	indexQuery := `
-		CREATE INDEX IF NOT EXISTS idx_user_data_user_id ON user_data(user_id);
-	`
-
-	_, err = r.db.Exec(indexQuery)
-	if err != nil {
-		log.Printf("Error creating index: %v", err)
-		return err
-	}
-
-	clientsTable := `
-		CREATE TABLE IF NOT EXISTS clients (
-			id SERIAL PRIMARY KEY,
-			client_id VARCHAR(255) NOT NULL,
-			client_secret VARCHAR(255) NOT NULL,
-			user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-	`
-
-	_, err = r.db.Exec(clientsTable)
-	if err != nil {
-		log.Printf("Error creating clients table: %v", err)
-		return err
-	}
-
-	clientsIndex := `
-		CREATE INDEX IF NOT EXISTS idx_clients_user_id ON clients(user_id);
-	`
-
-	_, err = r.db.Exec(clientsIndex)
-	if err != nil {
-		log.Printf("Error creating clients index: %v", err)
-		return err
-	}
-
-	return nil
-}
-
-func (r *SQLRepository) GetUsersWithPagination(limit, offset int) ([]*User, error) {
 	query := `
 		SELECT id, first_name, last_name, username, language_code, is_premium, created_at, updated_at
 		FROM users
@@ -262,7 +269,7 @@ func (r *SQLRepository) GetUsersWithPagination(limit, offset int) ([]*User, erro
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, database.Tag(ctx, query), limit, offset)
 	if err != nil {
 		log.Printf("Error getting users with pagination: %v", err)
 		return nil, err
@@ -292,11 +299,14 @@ func (r *SQLRepository) GetUsersWithPagination(limit, offset int) ([]*User, erro
 	return users, nil
 }
 
-func (r *SQLRepository) GetUsersCount() (int, error) {
+func (r *SQLRepository) GetUsersCount(ctx context.Context) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM users`
 
 	var count int
-	err := r.db.QueryRow(query).Scan(&count)
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query)).Scan(&count)
 	if err != nil {
 		log.Printf("Error getting users count: %v", err)
 		return 0, err
@@ -305,8 +315,8 @@ func (r *SQLRepository) GetUsersCount() (int, error) {
 	return count, nil
 }
 
-func (r *SQLRepository) GetUserDataJSON(userID int64) (map[string]interface{}, error) {
-	userData, err := r.GetUserDataByUserID(userID)
+func (r *SQLRepository) GetUserDataJSON(ctx context.Context, userID int64) (map[string]interface{}, error) {
+	userData, err := r.GetUserDataByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -325,23 +335,33 @@ func (r *SQLRepository) GetUserDataJSON(userID int64) (map[string]interface{}, e
 	return data, nil
 }
 
-func (r *SQLRepository) CreateClient(client *Client) error {
+func (r *SQLRepository) CreateClient(ctx context.Context, client *Client) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		INSERT INTO clients (client_id, client_secret, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO clients (client_id, client_secret, user_id, workspace_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)
 		RETURNING id
 	`
 
 	var id int64
-	err := r.db.QueryRow(query,
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query),
 		client.ClientID,
 		client.ClientSecret,
 		client.UserID,
+		client.WorkspaceID,
 		client.CreatedAt,
 		client.UpdatedAt,
 	).Scan(&id)
 
 	if err != nil {
+		switch database.UniqueViolation(err) {
+		case "idx_clients_client_id_unique", "clients.client_id":
+			return ErrClientIDExists
+		case "idx_clients_client_secret_unique", "clients.client_secret":
+			return ErrClientSecretExists
+		}
 		log.Printf("Error creating client: %v", err)
 		return err
 	}
@@ -350,16 +370,52 @@ func (r *SQLRepository) CreateClient(client *Client) error {
 	return nil
 }
 
-func (r *SQLRepository) GetClientByUserID(userID int64) (*Client, error) {
+func (r *SQLRepository) UpdateClient(ctx context.Context, client *Client) error {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		UPDATE clients
+		SET client_id = $2, client_secret = $3, user_id = $4, workspace_id = NULLIF($5, 0), updated_at = $6
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, database.Tag(ctx, query),
+		client.ID,
+		client.ClientID,
+		client.ClientSecret,
+		client.UserID,
+		client.WorkspaceID,
+		client.UpdatedAt,
+	)
+
+	if err != nil {
+		switch database.UniqueViolation(err) {
+		case "idx_clients_client_id_unique", "clients.client_id":
+			return ErrClientIDExists
+		case "idx_clients_client_secret_unique", "clients.client_secret":
+			return ErrClientSecretExists
+		}
+		log.Printf("Error updating client: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetClientByUserID(ctx context.Context, userID int64) (*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, userID)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), userID)
 
 	client := &Client{}
 	err := row.Scan(
@@ -367,6 +423,7 @@ func (r *SQLRepository) GetClientByUserID(userID int64) (*Client, error) {
 		&client.ClientID,
 		&client.ClientSecret,
 		&client.UserID,
+		&client.WorkspaceID,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -382,16 +439,19 @@ func (r *SQLRepository) GetClientByUserID(userID int64) (*Client, error) {
 	return client, nil
 }
 
-func (r *SQLRepository) GetClientByCredentials(clientID, clientSecret string) (*Client, error) {
+func (r *SQLRepository) GetClientByCredentials(ctx context.Context, clientID, clientSecret string) (*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		WHERE client_id = $1 AND client_secret = $2
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, clientID, clientSecret)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientID, clientSecret)
 
 	client := &Client{}
 	err := row.Scan(
@@ -399,6 +459,7 @@ func (r *SQLRepository) GetClientByCredentials(clientID, clientSecret string) (*
 		&client.ClientID,
 		&client.ClientSecret,
 		&client.UserID,
+		&client.WorkspaceID,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -414,16 +475,19 @@ func (r *SQLRepository) GetClientByCredentials(clientID, clientSecret string) (*
 	return client, nil
 }
 
-func (r *SQLRepository) GetClientByID(clientID string) (*Client, error) {
+func (r *SQLRepository) GetClientByID(ctx context.Context, clientID string) (*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		WHERE client_id = $1
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, clientID)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientID)
 
 	client := &Client{}
 	err := row.Scan(
@@ -431,6 +495,7 @@ func (r *SQLRepository) GetClientByID(clientID string) (*Client, error) {
 		&client.ClientID,
 		&client.ClientSecret,
 		&client.UserID,
+		&client.WorkspaceID,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -446,15 +511,18 @@ func (r *SQLRepository) GetClientByID(clientID string) (*Client, error) {
 	return client, nil
 }
 
-func (r *SQLRepository) GetClientsWithPagination(limit, offset int) ([]*Client, error) {
+func (r *SQLRepository) GetClientsWithPagination(ctx context.Context, limit, offset int) ([]*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, database.Tag(ctx, query), limit, offset)
 	if err != nil {
 		log.Printf("Error getting clients with pagination: %v", err)
 		return nil, err
@@ -469,6 +537,7 @@ func (r *SQLRepository) GetClientsWithPagination(limit, offset int) ([]*Client,
 			&client.ClientID,
 			&client.ClientSecret,
 			&client.UserID,
+			&client.WorkspaceID,
 			&client.CreatedAt,
 			&client.UpdatedAt,
 		)
@@ -482,16 +551,19 @@ func (r *SQLRepository) GetClientsWithPagination(limit, offset int) ([]*Client,
 	return clients, nil
 }
 
-func (r *SQLRepository) GetClientsByUserIDWithPagination(userID int64, limit, offset int) ([]*Client, error) {
+func (r *SQLRepository) GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.db.QueryContext(ctx, database.Tag(ctx, query), userID, limit, offset)
 	if err != nil {
 		log.Printf("Error getting clients by user ID with pagination: %v", err)
 		return nil, err
@@ -506,6 +578,7 @@ func (r *SQLRepository) GetClientsByUserIDWithPagination(userID int64, limit, of
 			&client.ClientID,
 			&client.ClientSecret,
 			&client.UserID,
+			&client.WorkspaceID,
 			&client.CreatedAt,
 			&client.UpdatedAt,
 		)
@@ -519,11 +592,14 @@ func (r *SQLRepository) GetClientsByUserIDWithPagination(userID int64, limit, of
 	return clients, nil
 }
 
-func (r *SQLRepository) GetClientsCount() (int, error) {
+func (r *SQLRepository) GetClientsCount(ctx context.Context) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM clients`
 
 	var count int
-	err := r.db.QueryRow(query).Scan(&count)
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query)).Scan(&count)
 	if err != nil {
 		log.Printf("Error getting clients count: %v", err)
 		return 0, err
@@ -532,11 +608,14 @@ func (r *SQLRepository) GetClientsCount() (int, error) {
 	return count, nil
 }
 
-func (r *SQLRepository) GetClientsCountByUserID(userID int64) (int, error) {
+func (r *SQLRepository) GetClientsCountByUserID(ctx context.Context, userID int64) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT COUNT(*) FROM clients WHERE user_id = $1`
 
 	var count int
-	err := r.db.QueryRow(query, userID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query), userID).Scan(&count)
 	if err != nil {
 		log.Printf("Error getting clients count by user ID: %v", err)
 		return 0, err
@@ -545,16 +624,76 @@ func (r *SQLRepository) GetClientsCountByUserID(userID int64) (int, error) {
 	return count, nil
 }
 
-func (r *SQLRepository) GetClientBySecret(clientSecret string) (*Client, error) {
+func (r *SQLRepository) GetClientsByWorkspaceIDWithPagination(ctx context.Context, workspaceID int64, limit, offset int) ([]*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
+		FROM clients
+		WHERE workspace_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, database.Tag(ctx, query), workspaceID, limit, offset)
+	if err != nil {
+		log.Printf("Error getting clients by workspace ID with pagination: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*Client
+	for rows.Next() {
+		client := &Client{}
+		err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.ClientSecret,
+			&client.UserID,
+			&client.WorkspaceID,
+			&client.CreatedAt,
+			&client.UpdatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning client: %v", err)
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+func (r *SQLRepository) GetClientsCountByWorkspaceID(ctx context.Context, workspaceID int64) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM clients WHERE workspace_id = $1`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, database.Tag(ctx, query), workspaceID).Scan(&count)
+	if err != nil {
+		log.Printf("Error getting clients count by workspace ID: %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *SQLRepository) GetClientBySecret(ctx context.Context, clientSecret string) (*Client, error) {
+	ctx, cancel := database.WithTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, client_id, client_secret, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret, user_id, COALESCE(workspace_id, 0), created_at, updated_at
 		FROM clients
 		WHERE client_secret = $1
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, clientSecret)
+	row := r.db.QueryRowContext(ctx, database.Tag(ctx, query), clientSecret)
 
 	client := &Client{}
 	err := row.Scan(
@@ -562,6 +701,7 @@ func (r *SQLRepository) GetClientBySecret(clientSecret string) (*Client, error)
 		&client.ClientID,
 		&client.ClientSecret,
 		&client.UserID,
+		&client.WorkspaceID,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -576,3 +716,10 @@ func (r *SQLRepository) GetClientBySecret(clientSecret string) (*Client, error)
 
 	return client, nil
 }
+
+// NewTransactor runs UserService units of work in a transaction on db.
+func NewTransactor(db *sql.DB) *database.SQLTransactor[UserRepository] {
+	return database.NewTransactor(db, func(tx database.DBTX) UserRepository {
+		return NewSQLRepository(tx)
+	})
+}
@@ -1,11 +1,18 @@
 package user
 
 import (
+	"context"
+	"mini-app-backend/internal/database"
 	"mini-app-backend/internal/errors"
 	"net/http"
 	"time"
 )
 
+var (
+	ErrClientIDExists     = errors.NewAppError(http.StatusConflict, "client with the same client_id already exists")
+	ErrClientSecretExists = errors.NewAppError(http.StatusConflict, "client with the same client_secret already exists")
+)
+
 type User struct {
 	ID           int64     `json:"id" db:"id"`
 	FirstName    string    `json:"first_name" db:"first_name"`
@@ -30,84 +37,72 @@ type Client struct {
 	ClientID     string    `json:"client_id" db:"client_id"`
 	ClientSecret string    `json:"client_secret" db:"client_secret"`
 	UserID       int64     `json:"user_id" db:"user_id"`
+	WorkspaceID  int64     `json:"workspace_id" db:"workspace_id"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type UserRepository interface {
-	CreateUser(user *User) error
-	GetUserByID(id int64) (*User, error)
-	UpdateUser(user *User) error
-	GetUserByTelegramID(telegramID int64) (*User, error)
-
-	CreateUserData(userData *UserData) error
-	GetUserDataByUserID(userID int64) (*UserData, error)
-	UpdateUserData(userData *UserData) error
+	CreateUser(ctx context.Context, user *User) error
+	GetUserByID(ctx context.Context, id int64) (*User, error)
+	UpdateUser(ctx context.Context, user *User) error
+	UpsertUser(ctx context.Context, user *User) (bool, error)
+	GetUserByTelegramID(ctx context.Context, telegramID int64) (*User, error)
+
+	CreateUserData(ctx context.Context, userData *UserData) error
+	GetUserDataByUserID(ctx context.Context, userID int64) (*UserData, error)
+	UpdateUserData(ctx context.Context, userData *UserData) error
 	
-	CreateClient(client *Client) error
-	GetClientByUserID(userID int64) (*Client, error)
-	GetClientByCredentials(clientID, clientSecret string) (*Client, error)
-	GetClientByID(clientID string) (*Client, error)
-	GetClientBySecret(clientSecret string) (*Client, error)
-	GetClientsWithPagination(limit, offset int) ([]*Client, error)
-	GetClientsCount() (int, error)
-	GetClientsByUserIDWithPagination(userID int64, limit, offset int) ([]*Client, error)
-	GetClientsCountByUserID(userID int64) (int, error)
+	CreateClient(ctx context.Context, client *Client) error
+	UpdateClient(ctx context.Context, client *Client) error
+	GetClientByUserID(ctx context.Context, userID int64) (*Client, error)
+	GetClientByCredentials(ctx context.Context, clientID, clientSecret string) (*Client, error)
+	GetClientByID(ctx context.Context, clientID string) (*Client, error)
+	GetClientBySecret(ctx context.Context, clientSecret string) (*Client, error)
+	GetClientsWithPagination(ctx context.Context, limit, offset int) ([]*Client, error)
+	GetClientsCount(ctx context.Context) (int, error)
+	GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*Client, error)
+	GetClientsCountByUserID(ctx context.Context, userID int64) (int, error)
+	GetClientsByWorkspaceIDWithPagination(ctx context.Context, workspaceID int64, limit, offset int) ([]*Client, error)
+	GetClientsCountByWorkspaceID(ctx context.Context, workspaceID int64) (int, error)
 }
 
 type UserService struct {
 	repo UserRepository
+	tx   database.Transactor[UserRepository]
 }
 
-func NewUserService(repo UserRepository) *UserService {
+func NewUserService(repo UserRepository, tx database.Transactor[UserRepository]) *UserService {
 	return &UserService{
 		repo: repo,
+		tx:   tx,
 	}
 }
 
-func (s *UserService) CreateOrUpdateUser(telegramUser *User) (*User, error) {
-	existingUser, err := s.repo.GetUserByTelegramID(telegramUser.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	if existingUser != nil {
-		existingUser.FirstName = telegramUser.FirstName
-		existingUser.LastName = telegramUser.LastName
-		existingUser.Username = telegramUser.Username
-		existingUser.LanguageCode = telegramUser.LanguageCode
-		existingUser.IsPremium = telegramUser.IsPremium
-		existingUser.UpdatedAt = time.Now()
-
-		err := s.repo.UpdateUser(existingUser)
-		if err != nil {
-			return nil, err
-		}
-
-		return existingUser, nil
-	}
+// CreateOrUpdateUser stores the Telegram profile with one upsert, so concurrent
+// logins of a new user cannot both try to insert it.
+func (s *UserService) CreateOrUpdateUser(ctx context.Context, telegramUser *User) (*User, error) {
+	now := time.Now()
+	telegramUser.CreatedAt = now
+	telegramUser.UpdatedAt = now
 
-	telegramUser.CreatedAt = time.Now()
-	telegramUser.UpdatedAt = time.Now()
-
-	err = s.repo.CreateUser(telegramUser)
-	if err != nil {
+	if _, err := s.repo.UpsertUser(ctx, telegramUser); err != nil {
 		return nil, err
 	}
 
 	return telegramUser, nil
 }
 
-func (s *UserService) GetUserByID(id int64) (*User, error) {
-	return s.repo.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	return s.repo.GetUserByID(ctx, id)
 }
 
-func (s *UserService) GetUserDataByUserID(userID int64) (*UserData, error) {
-	return s.repo.GetUserDataByUserID(userID)
+func (s *UserService) GetUserDataByUserID(ctx context.Context, userID int64) (*UserData, error) {
+	return s.repo.GetUserDataByUserID(ctx, userID)
 }
 
-func (s *UserService) SaveUserData(userID int64, data string) (*UserData, error) {
-	existingData, err := s.repo.GetUserDataByUserID(userID)
+func (s *UserService) SaveUserData(ctx context.Context, userID int64, data string) (*UserData, error) {
+	existingData, err := s.repo.GetUserDataByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +111,7 @@ func (s *UserService) SaveUserData(userID int64, data string) (*UserData, error)
 		existingData.Data = data
 		existingData.UpdatedAt = time.Now()
 
-		err := s.repo.UpdateUserData(existingData)
+		err := s.repo.UpdateUserData(ctx, existingData)
 		if err != nil {
 			return nil, err
 		}
@@ -131,7 +126,7 @@ func (s *UserService) SaveUserData(userID int64, data string) (*UserData, error)
 		UpdatedAt: time.Now(),
 	}
 
-	err = s.repo.CreateUserData(userData)
+	err = s.repo.CreateUserData(ctx, userData)
 	if err != nil {
 		return nil, err
 	}
@@ -139,53 +134,71 @@ func (s *UserService) SaveUserData(userID int64, data string) (*UserData, error)
 	return userData, nil
 }
 
-func (s *UserService) CreateClient(userID int64, clientID, clientSecret string) (*Client, error) {
-	existingClientByID, err := s.repo.GetClientByID(clientID)
-	if err != nil {
-		return nil, err
-	}
-	
-	if existingClientByID != nil {
-		return nil, errors.NewAppError(http.StatusConflict, "client with the same client_id already exists")
-	}
-	
-	existingClientBySecret, err := s.repo.GetClientBySecret(clientSecret)
-	if err != nil {
-		return nil, err
-	}
-	
-	if existingClientBySecret != nil {
-		return nil, errors.NewAppError(http.StatusConflict, "client with the same client_secret already exists")
-	}
-	
+// CreateClient rejects client IDs and secrets that are already registered.
+// The checks give the caller a clear message; the unique indexes behind
+// repo.CreateClient catch a concurrent request that passes them at the same time.
+func (s *UserService) CreateClient(ctx context.Context, userID, workspaceID int64, clientID, clientSecret string) (*Client, error) {
 	client := &Client{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		UserID:       userID,
+		WorkspaceID:  workspaceID,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
-	
-	err = s.repo.CreateClient(client)
+
+	err := s.tx.WithTx(ctx, func(repo UserRepository) error {
+		existingClientByID, err := repo.GetClientByID(ctx, clientID)
+		if err != nil {
+			return err
+		}
+
+		if existingClientByID != nil {
+			return ErrClientIDExists
+		}
+
+		existingClientBySecret, err := repo.GetClientBySecret(ctx, clientSecret)
+		if err != nil {
+			return err
+		}
+
+		if existingClientBySecret != nil {
+			return ErrClientSecretExists
+		}
+
+		return repo.CreateClient(ctx, client)
+	})
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return client, nil
 }
 
-func (s *UserService) GetClientsWithPagination(limit, offset int) ([]*Client, error) {
-	return s.repo.GetClientsWithPagination(limit, offset)
+func (s *UserService) GetClientByID(ctx context.Context, clientID string) (*Client, error) {
+	return s.repo.GetClientByID(ctx, clientID)
+}
+
+func (s *UserService) GetClientsWithPagination(ctx context.Context, limit, offset int) ([]*Client, error) {
+	return s.repo.GetClientsWithPagination(ctx, limit, offset)
+}
+
+func (s *UserService) GetClientsCount(ctx context.Context) (int, error) {
+	return s.repo.GetClientsCount(ctx)
+}
+
+func (s *UserService) GetClientsByUserIDWithPagination(ctx context.Context, userID int64, limit, offset int) ([]*Client, error) {
+	return s.repo.GetClientsByUserIDWithPagination(ctx, userID, limit, offset)
 }
 
-func (s *UserService) GetClientsCount() (int, error) {
-	return s.repo.GetClientsCount()
+func (s *UserService) GetClientsCountByUserID(ctx context.Context, userID int64) (int, error) {
+	return s.repo.GetClientsCountByUserID(ctx, userID)
 }
 
-func (s *UserService) GetClientsByUserIDWithPagination(userID int64, limit, offset int) ([]*Client, error) {
-	return s.repo.GetClientsByUserIDWithPagination(userID, limit, offset)
+func (s *UserService) GetClientsByWorkspaceIDWithPagination(ctx context.Context, workspaceID int64, limit, offset int) ([]*Client, error) {
+	return s.repo.GetClientsByWorkspaceIDWithPagination(ctx, workspaceID, limit, offset)
 }
 
-func (s *UserService) GetClientsCountByUserID(userID int64) (int, error) {
-	return s.repo.GetClientsCountByUserID(userID)
+func (s *UserService) GetClientsCountByWorkspaceID(ctx context.Context, workspaceID int64) (int, error) {
+	return s.repo.GetClientsCountByWorkspaceID(ctx, workspaceID)
 }
@@ -1,74 +1,42 @@
 package utils
 
-import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
-	"errors"
-	"io"
-	"mini-app-backend/internal/config"
-)
-
 type EncryptionUtil struct {
-	secretKey []byte
+	keyring *Keyring
 }
 
 func NewEncryptionUtil() *EncryptionUtil {
-	config := config.Load()
-	
 	return &EncryptionUtil{
-		secretKey: []byte(config.CookieEncryptionKey),
+		keyring: GetKeyring(),
 	}
 }
 
-func (e *EncryptionUtil) Encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(e.secretKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
+func NewEncryptionUtilWithKeyring(keyring *Keyring) *EncryptionUtil {
+	return &EncryptionUtil{
+		keyring: keyring,
 	}
+}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+func (e *EncryptionUtil) Encrypt(plaintext string) (string, error) {
+	if e.keyring == nil {
+		return "", ErrNoEncryptionKeys
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-
-	return base64.URLEncoding.EncodeToString(ciphertext), nil
+	return e.keyring.Encrypt(plaintext)
 }
 
 func (e *EncryptionUtil) Decrypt(ciphertext string) (string, error) {
-	decoded, err := base64.URLEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
+	if e.keyring == nil {
+		return "", ErrNoEncryptionKeys
 	}
 
-	block, err := aes.NewCipher(e.secretKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(decoded) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
+	return e.keyring.Decrypt(ciphertext)
+}
 
-	nonce, ciphertextBytes := decoded[:nonceSize], decoded[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
-	if err != nil {
-		return "", err
+// NeedsRotation reports whether the ciphertext should be re-issued with the active key.
+func (e *EncryptionUtil) NeedsRotation(ciphertext string) bool {
+	if e.keyring == nil {
+		return false
 	}
 
-	return string(plaintext), nil
-}
\ No newline at end of file
+	return e.keyring.NeedsRotation(ciphertext)
+}
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mini-app-backend/internal/config"
+	"mini-app-backend/internal/logger"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// keyIDSeparator splits the key ID from the payload. It is not part of the
+// URL-safe base64 alphabet, so a ciphertext without it is a legacy one.
+const keyIDSeparator = "."
+
+// legacyKeyID is used for the single COOKIE_ENCRYPTION_KEY when no keyring is configured.
+const legacyKeyID = "default"
+
+var (
+	ErrUnknownKeyID     = errors.New("unknown encryption key id")
+	ErrCiphertextShort  = errors.New("ciphertext too short")
+	ErrNoEncryptionKeys = errors.New("no encryption keys configured")
+)
+
+// Keyring holds every key that may decrypt stored values and the single
+// active key used for encryption. Ciphertexts are "<keyID>.<base64(nonce|data)>".
+type Keyring struct {
+	keys     map[string]cipher.AEAD
+	keyIDs   []string
+	activeID string
+}
+
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoEncryptionKeys
+	}
+
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not in the keyring", activeID)
+	}
+
+	k := &Keyring{
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+		activeID: activeID,
+	}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, keyIDSeparator) {
+			return nil, fmt.Errorf("invalid encryption key id %q", id)
+		}
+
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("encryption key %q must be 16, 24 or 32 bytes, got %d", id, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %v", id, err)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %v", id, err)
+		}
+
+		k.keys[id] = gcm
+		k.keyIDs = append(k.keyIDs, id)
+	}
+
+	// Active key first, so legacy ciphertexts are most likely opened on the first try.
+	sort.Slice(k.keyIDs, func(i, j int) bool {
+		if k.keyIDs[i] == activeID || k.keyIDs[j] == activeID {
+			return k.keyIDs[i] == activeID
+		}
+		return k.keyIDs[i] < k.keyIDs[j]
+	})
+
+	return k, nil
+}
+
+// NewKeyringFromConfig builds the keyring from COOKIE_ENCRYPTION_KEYS
+// ("id1:key1,id2:key2") and COOKIE_ENCRYPTION_ACTIVE_KEY. The legacy
+// COOKIE_ENCRYPTION_KEY is kept as a decrypt-only key so existing cookies keep working.
+func NewKeyringFromConfig(cfg *config.Config) (*Keyring, error) {
+	keys := make(map[string][]byte)
+
+	if cfg.CookieEncryptionKeys != "" {
+		for _, entry := range strings.Split(cfg.CookieEncryptionKeys, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			id, key, ok := strings.Cut(entry, ":")
+			if !ok || id == "" || key == "" {
+				return nil, fmt.Errorf("invalid COOKIE_ENCRYPTION_KEYS entry %q, expected id:key", entry)
+			}
+
+			if _, exists := keys[id]; exists {
+				return nil, fmt.Errorf("duplicate encryption key id %q", id)
+			}
+
+			keys[id] = []byte(key)
+		}
+	}
+
+	activeID := cfg.CookieEncryptionActiveKey
+
+	if cfg.CookieEncryptionKey != "" {
+		if _, exists := keys[legacyKeyID]; !exists {
+			keys[legacyKeyID] = []byte(cfg.CookieEncryptionKey)
+		}
+		if activeID == "" && len(keys) == 1 {
+			activeID = legacyKeyID
+		}
+	}
+
+	if activeID == "" && len(keys) == 1 {
+		for id := range keys {
+			activeID = id
+		}
+	}
+
+	if activeID == "" && len(keys) > 1 {
+		return nil, errors.New("COOKIE_ENCRYPTION_ACTIVE_KEY is required when several keys are configured")
+	}
+
+	return NewKeyring(activeID, keys)
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	gcm := k.keys[k.activeID]
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return k.activeID + keyIDSeparator + base64.URLEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	plaintext, _, err := k.DecryptWithKeyID(ciphertext)
+	return plaintext, err
+}
+
+// DecryptWithKeyID returns the plaintext together with the ID of the key that
+// opened it. Ciphertexts without a key ID are tried against every key.
+func (k *Keyring) DecryptWithKeyID(ciphertext string) (string, string, error) {
+	keyID, payload, tagged := strings.Cut(ciphertext, keyIDSeparator)
+	if !tagged {
+		payload = ciphertext
+	}
+
+	decoded, err := base64.URLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", err
+	}
+
+	if tagged {
+		gcm, ok := k.keys[keyID]
+		if !ok {
+			return "", "", ErrUnknownKeyID
+		}
+
+		plaintext, err := open(gcm, decoded)
+		if err != nil {
+			return "", "", err
+		}
+
+		return plaintext, keyID, nil
+	}
+
+	lastErr := ErrNoEncryptionKeys
+	for _, id := range k.keyIDs {
+		plaintext, err := open(k.keys[id], decoded)
+		if err == nil {
+			// Legacy values carry no ID; report an empty one so callers re-encrypt them.
+			return plaintext, "", nil
+		}
+		lastErr = err
+	}
+
+	return "", "", lastErr
+}
+
+// NeedsRotation reports whether a value produced by this keyring was sealed
+// with a key other than the active one. Values that are not keyring
+// ciphertexts at all return false.
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	_, keyID, err := k.DecryptWithKeyID(ciphertext)
+	if err != nil {
+		return false
+	}
+	return keyID != k.activeID
+}
+
+// Reencrypt seals the value with the active key if it was sealed with another one.
+func (k *Keyring) Reencrypt(ciphertext string) (string, bool, error) {
+	plaintext, keyID, err := k.DecryptWithKeyID(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	if keyID == k.activeID {
+		return ciphertext, false, nil
+	}
+
+	reencrypted, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return reencrypted, true, nil
+}
+
+func open(gcm cipher.AEAD, decoded []byte) (string, error) {
+	nonceSize := gcm.NonceSize()
+	if len(decoded) < nonceSize {
+		return "", ErrCiphertextShort
+	}
+
+	nonce, data := decoded[:nonceSize], decoded[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+var (
+	globalKeyring   *Keyring
+	globalKeyringMu sync.RWMutex
+)
+
+func SetKeyring(keyring *Keyring) {
+	globalKeyringMu.Lock()
+	defer globalKeyringMu.Unlock()
+	globalKeyring = keyring
+}
+
+// GetKeyring returns the process keyring. When the entrypoint did not set one,
+// it is built from the environment once.
+func GetKeyring() *Keyring {
+	globalKeyringMu.RLock()
+	keyring := globalKeyring
+	globalKeyringMu.RUnlock()
+
+	if keyring != nil {
+		return keyring
+	}
+
+	globalKeyringMu.Lock()
+	defer globalKeyringMu.Unlock()
+
+	if globalKeyring == nil {
+		k, err := NewKeyringFromConfig(config.Load())
+		if err != nil {
+			logger.Errorf("Failed to build encryption keyring: %v", err)
+			return nil
+		}
+		globalKeyring = k
+	}
+
+	return globalKeyring
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"mini-app-backend/internal/config"
+)
+
+var (
+	oldKey = []byte("0123456789abcdef")
+	newKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func newTestKeyring(t *testing.T, activeID string, keys map[string][]byte) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(activeID, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+// legacyCiphertext seals plaintext the way values were stored before key IDs:
+// base64(nonce|data) with no prefix.
+func legacyCiphertext(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("NewGCM: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	return base64.URLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestKeyringTagsCiphertextsWithKeyID(t *testing.T) {
+	keyring := newTestKeyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+
+	sealed, err := keyring.Encrypt("42")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "new.") {
+		t.Fatalf("ciphertext %q is not tagged with the active key", sealed)
+	}
+
+	plaintext, keyID, err := keyring.DecryptWithKeyID(sealed)
+	if err != nil || plaintext != "42" || keyID != "new" {
+		t.Fatalf("DecryptWithKeyID = %q, %q, %v", plaintext, keyID, err)
+	}
+
+	if _, err := keyring.Decrypt("gone." + strings.TrimPrefix(sealed, "new.")); err != ErrUnknownKeyID {
+		t.Errorf("Decrypt with an unknown key id = %v, want ErrUnknownKeyID", err)
+	}
+	if _, err := keyring.Decrypt("old." + strings.TrimPrefix(sealed, "new.")); err == nil {
+		t.Error("Decrypt with the wrong key id succeeded")
+	}
+}
+
+func TestKeyringOpensLegacyUntaggedCiphertexts(t *testing.T) {
+	keyring := newTestKeyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+
+	plaintext, keyID, err := keyring.DecryptWithKeyID(legacyCiphertext(t, oldKey, "42"))
+	if err != nil || plaintext != "42" {
+		t.Fatalf("DecryptWithKeyID = %q, %v", plaintext, err)
+	}
+	if keyID != "" {
+		t.Errorf("legacy key id = %q, want empty so callers re-encrypt", keyID)
+	}
+
+	if _, err := keyring.Decrypt(legacyCiphertext(t, []byte("not-in-the-ring!"), "42")); err == nil {
+		t.Error("Decrypt of a value sealed with a foreign key succeeded")
+	}
+}
+
+func TestKeyringNeedsRotation(t *testing.T) {
+	before := newTestKeyring(t, "old", map[string][]byte{"old": oldKey})
+	after := newTestKeyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+
+	sealedOld, _ := before.Encrypt("42")
+	sealedNew, _ := after.Encrypt("42")
+
+	cases := map[string]struct {
+		value string
+		want  bool
+	}{
+		"retired key": {sealedOld, true},
+		"active key":  {sealedNew, false},
+		"legacy":      {legacyCiphertext(t, newKey, "42"), true},
+		"plaintext":   {"42", false},
+		"garbage":     {"new.???", false},
+	}
+	for name, c := range cases {
+		if got := after.NeedsRotation(c.value); got != c.want {
+			t.Errorf("%s: NeedsRotation = %v, want %v", name, got, c.want)
+		}
+	}
+}
+
+func TestKeyringReencrypt(t *testing.T) {
+	before := newTestKeyring(t, "old", map[string][]byte{"old": oldKey})
+	after := newTestKeyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+
+	sealedOld, _ := before.Encrypt("42")
+	rotated, changed, err := after.Reencrypt(sealedOld)
+	if err != nil || !changed {
+		t.Fatalf("Reencrypt = %v, %v; want changed", changed, err)
+	}
+	if !strings.HasPrefix(rotated, "new.") || after.NeedsRotation(rotated) {
+		t.Fatalf("rotated value %q is not sealed with the active key", rotated)
+	}
+
+	// Once the old key is retired, only the rotated value still opens.
+	retired := newTestKeyring(t, "new", map[string][]byte{"new": newKey})
+	if plaintext, err := retired.Decrypt(rotated); err != nil || plaintext != "42" {
+		t.Errorf("Decrypt after retiring the old key = %q, %v", plaintext, err)
+	}
+	if _, err := retired.Decrypt(sealedOld); err == nil {
+		t.Error("value sealed with a retired key still opens")
+	}
+
+	same, changed, err := after.Reencrypt(rotated)
+	if err != nil || changed || same != rotated {
+		t.Errorf("Reencrypt of a current value = %q, %v, %v; want it unchanged", same, changed, err)
+	}
+
+	if _, _, err := after.Reencrypt("42"); err == nil {
+		t.Error("Reencrypt of plaintext succeeded")
+	}
+}
+
+func TestNewKeyringFromConfig(t *testing.T) {
+	keyring, err := NewKeyringFromConfig(&config.Config{
+		CookieEncryptionKey:       string(oldKey),
+		CookieEncryptionKeys:      "new:" + string(newKey),
+		CookieEncryptionActiveKey: "new",
+	})
+	if err != nil {
+		t.Fatalf("NewKeyringFromConfig: %v", err)
+	}
+	if keyring.ActiveKeyID() != "new" {
+		t.Errorf("active key = %q, want new", keyring.ActiveKeyID())
+	}
+	if plaintext, err := keyring.Decrypt(legacyCiphertext(t, oldKey, "42")); err != nil || plaintext != "42" {
+		t.Errorf("legacy COOKIE_ENCRYPTION_KEY value = %q, %v", plaintext, err)
+	}
+
+	invalid := map[string]*config.Config{
+		"no keys":            {},
+		"no active key":      {CookieEncryptionKeys: "a:" + string(oldKey) + ",b:" + string(newKey)},
+		"unknown active key": {CookieEncryptionKeys: "a:" + string(oldKey), CookieEncryptionActiveKey: "b"},
+		"short key":          {CookieEncryptionKeys: "a:short"},
+		"duplicate id":       {CookieEncryptionKeys: "a:" + string(oldKey) + ",a:" + string(oldKey)},
+		"malformed entry":    {CookieEncryptionKeys: string(oldKey)},
+	}
+	for name, cfg := range invalid {
+		if _, err := NewKeyringFromConfig(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
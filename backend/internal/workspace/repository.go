@@ -0,0 +1,367 @@
+package workspace
+
+import (
+	"database/sql"
+	"log"
+	"mini-app-backend/internal/database"
+	"time"
+)
+
+type SQLRepository struct {
+	db database.DBTX
+}
+
+func NewSQLRepository(db database.DBTX) *SQLRepository {
+	return &SQLRepository{
+		db: db,
+	}
+}
+
+func (r *SQLRepository) CreateWorkspace(workspace *Workspace) error {
+	query := `
+		INSERT INTO workspaces (name, owner_id, is_personal, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (owner_id) WHERE is_personal DO NOTHING
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRow(query,
+		workspace.Name,
+		workspace.OwnerID,
+		workspace.IsPersonal,
+		workspace.CreatedAt,
+		workspace.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Personal workspace already exists.
+			return nil
+		}
+		log.Printf("Error creating workspace: %v", err)
+		return err
+	}
+
+	workspace.ID = id
+	return nil
+}
+
+func (r *SQLRepository) GetWorkspaceByID(id int64) (*Workspace, error) {
+	query := `
+		SELECT id, name, owner_id, is_personal, created_at, updated_at
+		FROM workspaces
+		WHERE id = $1
+	`
+
+	workspace := &Workspace{}
+	err := r.db.QueryRow(query, id).Scan(
+		&workspace.ID,
+		&workspace.Name,
+		&workspace.OwnerID,
+		&workspace.IsPersonal,
+		&workspace.CreatedAt,
+		&workspace.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting workspace by ID: %v", err)
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func (r *SQLRepository) GetPersonalWorkspace(userID int64) (*Workspace, error) {
+	query := `
+		SELECT id, name, owner_id, is_personal, created_at, updated_at
+		FROM workspaces
+		WHERE owner_id = $1 AND is_personal
+	`
+
+	workspace := &Workspace{}
+	err := r.db.QueryRow(query, userID).Scan(
+		&workspace.ID,
+		&workspace.Name,
+		&workspace.OwnerID,
+		&workspace.IsPersonal,
+		&workspace.CreatedAt,
+		&workspace.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting personal workspace: %v", err)
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func (r *SQLRepository) GetMembershipsByUserID(userID int64) ([]*Membership, error) {
+	query := `
+		SELECT w.id, w.name, w.owner_id, w.is_personal, w.created_at, w.updated_at, m.role
+		FROM workspace_members m
+		JOIN workspaces w ON w.id = m.workspace_id
+		WHERE m.user_id = $1
+		ORDER BY w.is_personal DESC, w.created_at ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		log.Printf("Error getting workspaces by user ID: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []*Membership
+	for rows.Next() {
+		workspace := &Workspace{}
+		membership := &Membership{Workspace: workspace}
+		err := rows.Scan(
+			&workspace.ID,
+			&workspace.Name,
+			&workspace.OwnerID,
+			&workspace.IsPersonal,
+			&workspace.CreatedAt,
+			&workspace.UpdatedAt,
+			&membership.Role,
+		)
+		if err != nil {
+			log.Printf("Error scanning workspace: %v", err)
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, nil
+}
+
+func (r *SQLRepository) AddMember(member *Member) error {
+	query := `
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING
+	`
+
+	_, err := r.db.Exec(query,
+		member.WorkspaceID,
+		member.UserID,
+		member.Role,
+		member.CreatedAt,
+	)
+
+	if err != nil {
+		log.Printf("Error adding workspace member: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetMember(workspaceID, userID int64) (*Member, error) {
+	query := `
+		SELECT workspace_id, user_id, role, created_at
+		FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2
+	`
+
+	member := &Member{}
+	err := r.db.QueryRow(query, workspaceID, userID).Scan(
+		&member.WorkspaceID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting workspace member: %v", err)
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (r *SQLRepository) GetMembers(workspaceID int64) ([]*Member, error) {
+	query := `
+		SELECT workspace_id, user_id, role, created_at
+		FROM workspace_members
+		WHERE workspace_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query, workspaceID)
+	if err != nil {
+		log.Printf("Error getting workspace members: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*Member
+	for rows.Next() {
+		member := &Member{}
+		err := rows.Scan(
+			&member.WorkspaceID,
+			&member.UserID,
+			&member.Role,
+			&member.CreatedAt,
+		)
+		if err != nil {
+			log.Printf("Error scanning workspace member: %v", err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (r *SQLRepository) UpdateMemberRole(workspaceID, userID int64, role Role) error {
+	query := `UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2`
+
+	_, err := r.db.Exec(query, workspaceID, userID, role)
+	if err != nil {
+		log.Printf("Error updating workspace member role: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) RemoveMember(workspaceID, userID int64) error {
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+
+	_, err := r.db.Exec(query, workspaceID, userID)
+	if err != nil {
+		log.Printf("Error removing workspace member: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) CountOwners(workspaceID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner'`
+
+	var count int
+	err := r.db.QueryRow(query, workspaceID).Scan(&count)
+	if err != nil {
+		log.Printf("Error counting workspace owners: %v", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockWorkspace takes the row lock with a no-op update, which works the same
+// on Postgres and on SQLite, where it takes the database write lock.
+func (r *SQLRepository) LockWorkspace(workspaceID int64) error {
+	query := `UPDATE workspaces SET updated_at = updated_at WHERE id = $1`
+
+	_, err := r.db.Exec(query, workspaceID)
+	if err != nil {
+		log.Printf("Error locking workspace: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) CreateInvitation(invitation *Invitation) error {
+	query := `
+		INSERT INTO workspace_invitations (token, workspace_id, role, created_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.Exec(query,
+		invitation.Token,
+		invitation.WorkspaceID,
+		invitation.Role,
+		invitation.CreatedBy,
+		invitation.ExpiresAt,
+		invitation.CreatedAt,
+	)
+
+	if err != nil {
+		log.Printf("Error creating workspace invitation: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) GetInvitationByToken(token string) (*Invitation, error) {
+	query := `
+		SELECT token, workspace_id, role, created_by, expires_at, accepted_by, accepted_at, created_at
+		FROM workspace_invitations
+		WHERE token = $1
+	`
+
+	invitation := &Invitation{}
+	var acceptedBy sql.NullInt64
+	var acceptedAt sql.NullTime
+	err := r.db.QueryRow(query, token).Scan(
+		&invitation.Token,
+		&invitation.WorkspaceID,
+		&invitation.Role,
+		&invitation.CreatedBy,
+		&invitation.ExpiresAt,
+		&acceptedBy,
+		&acceptedAt,
+		&invitation.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("Error getting workspace invitation: %v", err)
+		return nil, err
+	}
+
+	if acceptedBy.Valid {
+		invitation.AcceptedBy = &acceptedBy.Int64
+	}
+	if acceptedAt.Valid {
+		invitation.AcceptedAt = &acceptedAt.Time
+	}
+
+	return invitation, nil
+}
+
+func (r *SQLRepository) AcceptInvitation(token string, userID int64, acceptedAt time.Time) (bool, error) {
+	query := `
+		UPDATE workspace_invitations
+		SET accepted_by = $2, accepted_at = $3
+		WHERE token = $1 AND accepted_by IS NULL AND expires_at > $3
+	`
+
+	result, err := r.db.Exec(query, token, userID, acceptedAt)
+	if err != nil {
+		log.Printf("Error accepting workspace invitation: %v", err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Error accepting workspace invitation: %v", err)
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// NewTransactor runs WorkspaceService units of work in a transaction on db.
+func NewTransactor(db *sql.DB) *database.SQLTransactor[WorkspaceRepository] {
+	return database.NewTransactor(db, func(tx database.DBTX) WorkspaceRepository {
+		return NewSQLRepository(tx)
+	})
+}
@@ -0,0 +1,431 @@
+package workspace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// InvitationStartPrefix marks bot deep links (t.me/<bot>?start=join_<token>).
+const InvitationStartPrefix = "join_"
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrWorkspaceNotFound  = errors.NewAppError(http.StatusNotFound, "Workspace not found")
+	ErrInvitationNotFound = errors.NewAppError(http.StatusNotFound, "Invitation not found or expired")
+	ErrInvitationUsed     = errors.NewAppError(http.StatusConflict, "Invitation has already been used")
+	ErrMemberNotFound     = errors.NewAppError(http.StatusNotFound, "Member not found")
+	ErrInsufficientRole   = errors.NewAppError(http.StatusForbidden, "Insufficient workspace role")
+	ErrInvalidRole        = errors.NewAppError(http.StatusBadRequest, "Role must be one of owner, editor, viewer")
+	ErrLastOwner          = errors.NewAppError(http.StatusConflict, "Workspace must keep at least one owner")
+)
+
+func ParseRole(value string) (Role, error) {
+	switch Role(value) {
+	case RoleOwner, RoleEditor, RoleViewer:
+		return Role(value), nil
+	}
+	return "", ErrInvalidRole
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// Allows reports whether the role grants at least the permissions of required.
+func (r Role) Allows(required Role) bool {
+	return r.rank() >= required.rank()
+}
+
+type Workspace struct {
+	ID         int64     `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	OwnerID    int64     `json:"owner_id" db:"owner_id"`
+	IsPersonal bool      `json:"is_personal" db:"is_personal"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Membership struct {
+	Workspace *Workspace `json:"workspace"`
+	Role      Role       `json:"role"`
+}
+
+type Member struct {
+	WorkspaceID int64     `json:"workspace_id" db:"workspace_id"`
+	UserID      int64     `json:"user_id" db:"user_id"`
+	Role        Role      `json:"role" db:"role"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type Invitation struct {
+	Token       string     `json:"token" db:"token"`
+	WorkspaceID int64      `json:"workspace_id" db:"workspace_id"`
+	Role        Role       `json:"role" db:"role"`
+	CreatedBy   int64      `json:"created_by" db:"created_by"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedBy  *int64     `json:"accepted_by,omitempty" db:"accepted_by"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Access is the caller's resolved membership in the active workspace.
+type Access struct {
+	WorkspaceID int64
+	UserID      int64
+	Role        Role
+}
+
+func (a *Access) Require(role Role) error {
+	if !a.Role.Allows(role) {
+		return ErrInsufficientRole
+	}
+	return nil
+}
+
+type WorkspaceRepository interface {
+	CreateWorkspace(workspace *Workspace) error
+	GetWorkspaceByID(id int64) (*Workspace, error)
+	GetPersonalWorkspace(userID int64) (*Workspace, error)
+	GetMembershipsByUserID(userID int64) ([]*Membership, error)
+
+	AddMember(member *Member) error
+	GetMember(workspaceID, userID int64) (*Member, error)
+	GetMembers(workspaceID int64) ([]*Member, error)
+	UpdateMemberRole(workspaceID, userID int64, role Role) error
+	RemoveMember(workspaceID, userID int64) error
+	CountOwners(workspaceID int64) (int, error)
+	// LockWorkspace holds the workspace row until the transaction ends, so
+	// membership changes of one workspace run one after another.
+	LockWorkspace(workspaceID int64) error
+
+	CreateInvitation(invitation *Invitation) error
+	GetInvitationByToken(token string) (*Invitation, error)
+	// AcceptInvitation marks an unused, unexpired invitation as accepted by
+	// userID and reports whether it did.
+	AcceptInvitation(token string, userID int64, acceptedAt time.Time) (bool, error)
+}
+
+type WorkspaceService struct {
+	repo WorkspaceRepository
+	tx   database.Transactor[WorkspaceRepository]
+}
+
+func NewWorkspaceService(repo WorkspaceRepository, tx database.Transactor[WorkspaceRepository]) *WorkspaceService {
+	return &WorkspaceService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+// EnsurePersonalWorkspace returns the user's personal workspace, creating it on first use.
+func (s *WorkspaceService) EnsurePersonalWorkspace(ctx context.Context, userID int64, name string) (*Workspace, error) {
+	existing, err := s.repo.GetPersonalWorkspace(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = fmt.Sprintf("Workspace %d", userID)
+	}
+
+	var workspace *Workspace
+	err = s.tx.WithTx(ctx, func(repo WorkspaceRepository) error {
+		err := repo.CreateWorkspace(&Workspace{
+			Name:       name,
+			OwnerID:    userID,
+			IsPersonal: true,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		// A concurrent login may have won the insert; re-read the canonical row.
+		workspace, err = repo.GetPersonalWorkspace(userID)
+		if err != nil {
+			return err
+		}
+
+		return repo.AddMember(&Member{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			Role:        RoleOwner,
+			CreatedAt:   time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// CreateWorkspace inserts the workspace and its owner's membership together, so
+// a failure cannot leave a workspace nobody can manage.
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, ownerID int64, name string) (*Workspace, error) {
+	workspace := &Workspace{
+		Name:      name,
+		OwnerID:   ownerID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := s.tx.WithTx(ctx, func(repo WorkspaceRepository) error {
+		if err := repo.CreateWorkspace(workspace); err != nil {
+			return err
+		}
+
+		return repo.AddMember(&Member{
+			WorkspaceID: workspace.ID,
+			UserID:      ownerID,
+			Role:        RoleOwner,
+			CreatedAt:   time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func (s *WorkspaceService) GetMembershipsByUserID(userID int64) ([]*Membership, error) {
+	return s.repo.GetMembershipsByUserID(userID)
+}
+
+// ResolveAccess returns the caller's access to workspaceID, or to the personal
+// workspace when workspaceID is zero. Non-members get ErrWorkspaceNotFound.
+func (s *WorkspaceService) ResolveAccess(ctx context.Context, userID, workspaceID int64) (*Access, error) {
+	if workspaceID == 0 {
+		personal, err := s.EnsurePersonalWorkspace(ctx, userID, "")
+		if err != nil {
+			return nil, err
+		}
+		workspaceID = personal.ID
+	}
+
+	member, err := s.repo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	return &Access{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Role:        member.Role,
+	}, nil
+}
+
+func (s *WorkspaceService) GetMembers(access *Access) ([]*Member, error) {
+	return s.repo.GetMembers(access.WorkspaceID)
+}
+
+func (s *WorkspaceService) CreateInvitation(access *Access, role Role) (*Invitation, error) {
+	if err := access.Require(RoleOwner); err != nil {
+		return nil, err
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		Token:       token,
+		WorkspaceID: access.WorkspaceID,
+		Role:        role,
+		CreatedBy:   access.UserID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.repo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation adds userID to the invitation's workspace. Invitations are single-use;
+// an existing member keeps the higher of the two roles. The invitation is claimed before
+// the membership changes and in the same transaction, so of two concurrent redemptions
+// only one gets in.
+func (s *WorkspaceService) AcceptInvitation(ctx context.Context, token string, userID int64) (*Workspace, error) {
+	var workspace *Workspace
+
+	err := s.tx.WithTx(ctx, func(repo WorkspaceRepository) error {
+		claimed, err := repo.AcceptInvitation(token, userID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		invitation, err := repo.GetInvitationByToken(token)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			if invitation != nil && invitation.AcceptedBy != nil {
+				return ErrInvitationUsed
+			}
+			return ErrInvitationNotFound
+		}
+
+		workspace, err = repo.GetWorkspaceByID(invitation.WorkspaceID)
+		if err != nil {
+			return err
+		}
+
+		if workspace == nil {
+			return ErrWorkspaceNotFound
+		}
+
+		existing, err := repo.GetMember(invitation.WorkspaceID, userID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			return repo.AddMember(&Member{
+				WorkspaceID: invitation.WorkspaceID,
+				UserID:      userID,
+				Role:        invitation.Role,
+				CreatedAt:   time.Now(),
+			})
+		}
+		if !existing.Role.Allows(invitation.Role) {
+			return repo.UpdateMemberRole(invitation.WorkspaceID, userID, invitation.Role)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// UpdateMemberRole changes a member's role. Membership changes of one workspace
+// run one at a time, so two owners demoting each other cannot both succeed.
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, access *Access, userID int64, role Role) error {
+	if err := access.Require(RoleOwner); err != nil {
+		return err
+	}
+
+	return s.tx.WithTx(ctx, func(repo WorkspaceRepository) error {
+		member, err := lockedMember(repo, access.WorkspaceID, userID)
+		if err != nil {
+			return err
+		}
+
+		if member.Role == RoleOwner && role != RoleOwner {
+			if err := ensureAnotherOwner(repo, access.WorkspaceID); err != nil {
+				return err
+			}
+		}
+
+		return repo.UpdateMemberRole(access.WorkspaceID, userID, role)
+	})
+}
+
+// RemoveMember lets owners remove anyone and any member leave on their own.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, access *Access, userID int64) error {
+	if userID != access.UserID {
+		if err := access.Require(RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	return s.tx.WithTx(ctx, func(repo WorkspaceRepository) error {
+		member, err := lockedMember(repo, access.WorkspaceID, userID)
+		if err != nil {
+			return err
+		}
+
+		if member.Role == RoleOwner {
+			if err := ensureAnotherOwner(repo, access.WorkspaceID); err != nil {
+				return err
+			}
+		}
+
+		return repo.RemoveMember(access.WorkspaceID, userID)
+	})
+}
+
+// lockedMember locks the workspace's memberships for the rest of the
+// transaction and returns userID's, read after the lock.
+func lockedMember(repo WorkspaceRepository, workspaceID, userID int64) (*Member, error) {
+	if err := repo.LockWorkspace(workspaceID); err != nil {
+		return nil, err
+	}
+
+	member, err := repo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+
+	return member, nil
+}
+
+func ensureAnotherOwner(repo WorkspaceRepository, workspaceID int64) error {
+	owners, err := repo.CountOwners(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// InvitationLink builds the bot deep link that accepts the invitation.
+func InvitationLink(botName, token string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", botName, InvitationStartPrefix, token)
+}
+
+func generateInvitationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,181 @@
+package workspace
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-app-backend/internal/database"
+	"mini-app-backend/internal/repotest"
+)
+
+func newInvitationFixture(t *testing.T) (*WorkspaceService, *SQLRepository, *Invitation) {
+	t.Helper()
+
+	db := repotest.SQLiteDB(t)
+	now := time.Now()
+	for _, id := range []int64{1, 2, 3} {
+		if _, err := db.Exec(`INSERT INTO users (id, first_name, created_at, updated_at) VALUES ($1, 'user', $2, $2)`, id, now); err != nil {
+			t.Fatalf("create user %d: %v", id, err)
+		}
+	}
+
+	repo := NewSQLRepository(db)
+	service := NewWorkspaceService(repo, NewTransactor(db))
+
+	owned, err := service.EnsurePersonalWorkspace(context.Background(), 1, "owner")
+	if err != nil {
+		t.Fatalf("EnsurePersonalWorkspace: %v", err)
+	}
+	invitation, err := service.CreateInvitation(&Access{WorkspaceID: owned.ID, UserID: 1, Role: RoleOwner}, RoleEditor)
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+
+	return service, repo, invitation
+}
+
+func TestAcceptInvitationIsSingleUse(t *testing.T) {
+	service, repo, invitation := newInvitationFixture(t)
+	ctx := context.Background()
+
+	if _, err := service.AcceptInvitation(ctx, invitation.Token, 2); err != nil {
+		t.Fatalf("first AcceptInvitation: %v", err)
+	}
+	if _, err := service.AcceptInvitation(ctx, invitation.Token, 3); err != ErrInvitationUsed {
+		t.Fatalf("second AcceptInvitation = %v, want ErrInvitationUsed", err)
+	}
+
+	if member, err := repo.GetMember(invitation.WorkspaceID, 2); err != nil || member == nil || member.Role != RoleEditor {
+		t.Errorf("first redeemer = %+v, %v; want an editor", member, err)
+	}
+	if member, err := repo.GetMember(invitation.WorkspaceID, 3); err != nil || member != nil {
+		t.Errorf("second redeemer = %+v, %v; want no membership", member, err)
+	}
+}
+
+func TestAcceptInvitationConcurrentRedemptions(t *testing.T) {
+	service, repo, invitation := newInvitationFixture(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, userID := range []int64{2, 3} {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			_, errs[i] = service.AcceptInvitation(context.Background(), invitation.Token, userID)
+		}(i, userID)
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			accepted++
+		case ErrInvitationUsed:
+		default:
+			t.Fatalf("AcceptInvitation: %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Fatalf("%d redemptions accepted, want 1", accepted)
+	}
+
+	members, err := repo.GetMembers(invitation.WorkspaceID)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("workspace has %d members, want the owner and one invitee", len(members))
+	}
+}
+
+func TestAcceptInvitationRejectsExpired(t *testing.T) {
+	service, repo, invitation := newInvitationFixture(t)
+
+	if _, err := repo.db.Exec(`UPDATE workspace_invitations SET expires_at = $2 WHERE token = $1`, invitation.Token, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("expire invitation: %v", err)
+	}
+
+	if _, err := service.AcceptInvitation(context.Background(), invitation.Token, 2); err != ErrInvitationNotFound {
+		t.Fatalf("AcceptInvitation = %v, want ErrInvitationNotFound", err)
+	}
+	if _, err := service.AcceptInvitation(context.Background(), "missing", 2); err != ErrInvitationNotFound {
+		t.Fatalf("AcceptInvitation of an unknown token = %v, want ErrInvitationNotFound", err)
+	}
+	if member, err := repo.GetMember(invitation.WorkspaceID, 2); err != nil || member != nil {
+		t.Errorf("member = %+v, %v; want none", member, err)
+	}
+}
+
+type failingMembers struct {
+	WorkspaceRepository
+}
+
+func (failingMembers) AddMember(member *Member) error {
+	return stderrors.New("boom")
+}
+
+func TestCreateWorkspaceRollsBackWithoutOwner(t *testing.T) {
+	sqlDB := repotest.SQLiteDB(t)
+	if _, err := sqlDB.Exec(`INSERT INTO users (id, first_name, created_at, updated_at) VALUES (1, 'user', $1, $1)`, time.Now()); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	service := NewWorkspaceService(NewSQLRepository(sqlDB), database.NewTransactor(sqlDB, func(tx database.DBTX) WorkspaceRepository {
+		return failingMembers{NewSQLRepository(tx)}
+	}))
+
+	if _, err := service.CreateWorkspace(context.Background(), 1, "team"); err == nil {
+		t.Fatal("CreateWorkspace succeeded without adding the owner")
+	}
+	var count int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM workspaces`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("workspaces = %d, %v; want the insert rolled back", count, err)
+	}
+}
+
+func TestConcurrentDemotionsKeepAnOwner(t *testing.T) {
+	service, repo, invitation := newInvitationFixture(t)
+	ctx := context.Background()
+
+	if _, err := service.AcceptInvitation(ctx, invitation.Token, 2); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if err := repo.UpdateMemberRole(invitation.WorkspaceID, 2, RoleOwner); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+
+	// Each owner demotes themselves at the same time as the other.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, userID := range []int64{1, 2} {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			access := &Access{WorkspaceID: invitation.WorkspaceID, UserID: userID, Role: RoleOwner}
+			errs[i] = service.UpdateMemberRole(ctx, access, userID, RoleEditor)
+		}(i, userID)
+	}
+	wg.Wait()
+
+	rejected := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+		case ErrLastOwner:
+			rejected++
+		default:
+			t.Fatalf("UpdateMemberRole: %v", err)
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("%d demotions rejected, want exactly 1", rejected)
+	}
+
+	if owners, err := repo.CountOwners(invitation.WorkspaceID); err != nil || owners != 1 {
+		t.Errorf("owners = %d, %v; want 1", owners, err)
+	}
+}